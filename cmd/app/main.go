@@ -1,16 +1,38 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
 	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"time"
 
 	"music_catalog/config"
 	"music_catalog/internal/api"
+	"music_catalog/internal/api/graphql_api"
+	"music_catalog/internal/api/grpc_api"
+	"music_catalog/internal/auth"
 	"music_catalog/internal/db"
+	"music_catalog/internal/events"
+	"music_catalog/internal/health"
+	"music_catalog/internal/idempotency"
+	"music_catalog/internal/lifecycle"
 	"music_catalog/internal/logger"
+	"music_catalog/internal/metrics"
+	"music_catalog/internal/ratelimit"
 	"music_catalog/internal/repository/external_api"
+	"music_catalog/internal/repository/memory_repo"
 	"music_catalog/internal/repository/pg_repo"
+	"music_catalog/internal/repository/sqlite_repo"
 	"music_catalog/internal/service"
+	"music_catalog/internal/tenant"
+	"music_catalog/internal/tracing"
+	"music_catalog/internal/webhooks"
 
 	_ "github.com/lib/pq"
 
@@ -18,42 +40,417 @@ import (
 )
 
 func main() {
+	// Ошибка до настройки журнала пишется журналом slog по умолчанию, после — в настроенном формате
+	if err := run(os.Args[1:]); err != nil {
+		slog.Error(err.Error())
+		os.Exit(1)
+	}
+}
 
-	logger := logger.NewLogger("debug")
+// run собирает приложение и блокируется до его остановки; ошибка запуска или остановки
+// завершает процесс с ненулевым кодом
+func run(args []string) error {
+	// Конфигурация: значения по умолчанию, файл (--config), переменные окружения, флаги
+	flags := flag.NewFlagSet("music_catalog", flag.ContinueOnError)
+	loader := config.NewLoader(flags)
+	printConfig := flags.Bool("print-config", false, "вывести итоговую конфигурацию (секреты скрыты) и выйти")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	cfg, err := loader.Load()
+	if err != nil {
+		return fmt.Errorf("ошибка при загрузке конфигурации: %w", err)
+	}
+	if *printConfig {
+		return cfg.Redacted().Print(os.Stdout)
+	}
 
-	// Загрузка конфигурации
-	config, err := config.LoadConfig()
+	// Журнал: уровень и формат из конфигурации; записи стандартного log и slog идут в него же
+	logger, err := logger.New(cfg.LogLevel, cfg.LogFormat, os.Stdout)
 	if err != nil {
-		logger.Fatal("ошибка при загрузке конфигурации: %v", err)
+		return err
 	}
-	logger.Debug(fmt.Sprintf("Loaded config: %+v", config))
+	logger.SetDefault()
+	logger.Debug(fmt.Sprintf("Loaded config: %+v", cfg.Redacted()))
 
-	// Подключение к базе данных
-	dbConnection, err := db.NewDB(config)
+	// Настройки с тегом reload применяются к работающему сервису по SIGHUP и при изменении
+	// файла конфигурации; компоненты подписываются на перезагрузку по мере создания
+	watcher := config.NewWatcher(loader, cfg, logger).
+		OnReload(func(c *config.Config) { logger.SetLevel(c.LogLevel) })
+
+	// Ресурсы освобождаются в обратном порядке после остановки серверов и фоновых задач:
+	// сначала пул соединений, последними — накопленные спаны трассировки
+	app := lifecycle.New(cfg.ShutdownTimeout, logger)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:    cfg.TracingExporter,
+		File:        cfg.TracingFile,
+		ServiceName: "music-catalog",
+		SampleRatio: cfg.TracingSampleRatio,
+	})
 	if err != nil {
-		logger.Fatal("Ошибка подключения к базе данных: %v", err)
+		return fmt.Errorf("ошибка настройки трассировки: %w", err)
+	}
+	app.OnClose("tracing", func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return shutdownTracing(ctx)
+	})
+
+	// PostgreSQL нужен только хранилищу postgres; без него вебхуки, избранное и плейлисты недоступны
+	var dbConnection, replicaConnection *sql.DB
+	var migrator *db.Migrator
+	if cfg.UsesPostgres() {
+		// Подключение к базе данных; при запуске ждём, пока база поднимется, не дольше DB_CONNECT_TIMEOUT
+		dbConnection, err = db.NewDB(context.Background(), cfg, logger)
+		if err != nil {
+			return fmt.Errorf("ошибка подключения к базе данных: %w", err)
+		}
+		app.OnClose("database", dbConnection.Close)
+
+		// Необязательная реплика: из неё читаются список песен и тексты
+		replicaConnection, err = db.NewReplicaDB(context.Background(), cfg, logger)
+		if err != nil {
+			return fmt.Errorf("ошибка подключения к реплике базы данных: %w", err)
+		}
+		if replicaConnection != nil {
+			app.OnClose("database replica", replicaConnection.Close)
+		}
+
+		// Миграции при запуске; при AUTO_MIGRATE=false схему обновляют заранее командой catalogctl migrate up
+		migrator = db.NewMigrator(dbConnection, cfg.MigrationLockTimeout, logger)
+		if cfg.AutoMigrate {
+			if err := migrator.Up(context.Background()); err != nil {
+				return fmt.Errorf("ошибка при выполнении миграции: %w", err)
+			}
+		}
 	}
-	defer dbConnection.Close()
 
-	// migrations
-	err = db.RunMigrations(dbConnection)
+	// Хранилища каталога, журнала событий, арендаторов, ключей и ответов на запросы с Idempotency-Key.
+	// Изменение песни и событие о нём записываются в одной транзакции transactor
+	var (
+		repository interface {
+			pg_repo.SongRepository
+			metrics.CatalogStatsSource
+		}
+		eventRepository interface {
+			events.Publisher
+			events.Store
+		}
+		transactor       pg_repo.Transactor
+		tenantRepository pg_repo.TenantRepository
+		apiKeyRepository pg_repo.APIKeyRepository
+		idempotencyStore idempotency.Store
+		// runBroker раздаёт события подписчикам по уведомлениям журнала
+		runBroker func(ctx context.Context, broker *events.Broker) error
+	)
+	var sqliteConnection *sql.DB
+	var sqliteMigrator *db.Migrator
+	switch cfg.StorageBackend {
+	case "memory":
+		// Всё живёт до перезапуска процесса; сервис работает одним экземпляром
+		memoryEvents := memory_repo.NewMemoryEventRepository()
+		memoryTenants := memory_repo.NewMemoryTenantRepository()
+		repository = memory_repo.NewMemorySongRepository()
+		eventRepository = memoryEvents
+		transactor = memory_repo.NewMemoryTransactor()
+		tenantRepository = memoryTenants
+		apiKeyRepository = memory_repo.NewMemoryAPIKeyRepository(memoryTenants)
+		idempotencyStore = idempotency.NewMemoryStore()
+		runBroker = func(ctx context.Context, broker *events.Broker) error {
+			return broker.RunLocal(ctx, memoryEvents.Changes())
+		}
+		logger.Info("Catalog, events, tenants and API keys are stored in memory (STORAGE_BACKEND=memory) and are lost on restart; " +
+			"webhooks, favorites and playlists need PostgreSQL and are disabled")
+	case "sqlite":
+		// Каталог, журнал событий, арендаторы и ключи в файле SQLITE_PATH со своими миграциями;
+		// сервис работает одним экземпляром на узле
+		sqliteConnection, err = db.NewSQLiteDB(context.Background(), cfg, logger)
+		if err != nil {
+			return fmt.Errorf("ошибка открытия базы SQLite: %w", err)
+		}
+		app.OnClose("sqlite database", sqliteConnection.Close)
+		sqliteMigrator = db.NewSQLiteMigrator(cfg.SQLitePath, logger)
+		if cfg.AutoMigrate {
+			if err := sqliteMigrator.Up(context.Background()); err != nil {
+				return fmt.Errorf("ошибка при выполнении миграции SQLite: %w", err)
+			}
+		}
+		sqliteEvents := sqlite_repo.NewSQLiteEventRepository(sqliteConnection)
+		sqliteTenants := sqlite_repo.NewSQLiteTenantRepository(sqliteConnection)
+		repository = sqlite_repo.NewSQLiteSongRepository(sqliteConnection).WithTenants(sqliteTenants)
+		eventRepository = sqliteEvents
+		transactor = sqlite_repo.NewSQLiteTransactor(sqliteConnection)
+		tenantRepository = sqliteTenants
+		apiKeyRepository = sqlite_repo.NewSQLiteAPIKeyRepository(sqliteConnection)
+		idempotencyStore = idempotency.NewMemoryStore()
+		runBroker = func(ctx context.Context, broker *events.Broker) error {
+			return broker.RunLocal(ctx, sqliteEvents.Changes())
+		}
+		logger.Info(fmt.Sprintf("Catalog, events, tenants and API keys are stored in %s (STORAGE_BACKEND=sqlite); "+
+			"webhooks, favorites and playlists need PostgreSQL and are disabled", cfg.SQLitePath))
+	default:
+		// Журнал изменений каталога и раздача событий подписчикам через LISTEN/NOTIFY
+		repository = pg_repo.NewPostgresSongRepository(dbConnection).WithReplica(replicaConnection)
+		eventRepository = pg_repo.NewPostgresEventRepository(dbConnection)
+		transactor = pg_repo.NewPostgresTransactor(dbConnection)
+		tenantRepository = pg_repo.NewPostgresTenantRepository(dbConnection)
+		apiKeyRepository = pg_repo.NewPostgresAPIKeyRepository(dbConnection)
+		idempotencyStore = pg_repo.NewPostgresIdempotencyStore(dbConnection)
+		runBroker = func(ctx context.Context, broker *events.Broker) error {
+			return broker.Run(ctx, db.DSN(cfg))
+		}
+	}
+	eventBroker := events.NewBroker(eventRepository, logger)
+	app.Go("Event broker", func(ctx context.Context) error {
+		return runBroker(ctx, eventBroker)
+	})
+
+	externalAPIClient := external_api.NewExternalAPIClient(cfg, logger)
+	watcher.OnReload(func(c *config.Config) { externalAPIClient.SetEndpoint(c.ExternalAPIURL, c.ExternalAPITimeout) })
+	musicService := service.NewMusicService(repository, externalAPIClient, eventRepository, transactor, logger)
+
+	// Аутентификация: API-ключи в базе и JWT, подписанные настроенными ключами
+	jwtPublicKeys, err := auth.LoadPublicKeys(cfg.JWTPublicKeyFiles)
 	if err != nil {
-		logger.Fatal("Ошибка при выполнении миграции: %v", err)
+		return fmt.Errorf("ошибка загрузки ключей JWT: %w", err)
+	}
+	authenticator := auth.NewAuthenticator(apiKeyRepository, auth.Config{
+		Disabled:       cfg.AuthDisabled,
+		AdminAPIKey:    cfg.AdminAPIKey,
+		JWTHMACSecret:  []byte(cfg.JWTHMACSecret),
+		JWTPublicKeys:  jwtPublicKeys,
+		JWTIssuer:      cfg.JWTIssuer,
+		JWTAudience:    cfg.JWTAudience,
+		JWTRoleClaim:   cfg.JWTRoleClaim,
+		JWTTenantClaim: cfg.JWTTenantClaim,
+	})
+	if cfg.AuthDisabled {
+		logger.Error("Authentication is disabled (AUTH_DISABLED=true): every request has admin rights")
 	}
 
-	// Подключаем репозиторий и хендлеры
-	repository := pg_repo.NewPostgresSongRepository(dbConnection)
-	musicService := service.NewMusicService(repository, external_api.NewExternalAPIClient(config), logger)
+	// Арендаторы: каталог каждого лейбла изолирован, арендатор определяется по клиенту или заголовку X-Tenant-ID
+	tenantResolver := tenant.NewResolver(tenantRepository)
+	tenantService := service.NewTenantService(tenantRepository, logger)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepository, tenantRepository, logger)
 
 	// Инициализация хендлеров
 	songHandler := api.NewSongHandler(musicService, logger)
+	songHandlerV2 := api.NewSongHandlerV2(musicService, logger)
+	eventsHandler := api.NewEventsHandler(eventBroker, logger)
+	apiKeysHandler := api.NewAPIKeysHandler(apiKeyService, logger)
+	tenantsHandler := api.NewTenantsHandler(tenantService, logger)
+
+	// Готовность: база и схема критичны; без внешнего API каталог продолжает отдавать и менять
+	// песни, поэтому его недоступность только понижает статус до degraded
+	readiness := health.NewChecker(cfg.HealthCheckTimeout).
+		Add("external_api", false, health.Cached(func() time.Duration { return watcher.Current().ExternalAPICheckTTL }, func(ctx context.Context) (any, error) {
+			return nil, externalAPIClient.CheckReachable(ctx)
+		}))
+	// Метрики Prometheus: пул соединений и размеры каталогов считаются при сборе
+	poolStats := func() sql.DBStats { return sql.DBStats{} }
+	if dbConnection != nil {
+		poolStats = dbConnection.Stats
+		metrics.RegisterDBStats(dbConnection, "postgres")
+		readiness.
+			Add("database", true, func(ctx context.Context) (any, error) {
+				return nil, dbConnection.PingContext(ctx)
+			}).
+			Add("migrations", true, migrationsCheck(migrator))
+	}
+	if replicaConnection != nil {
+		metrics.RegisterDBStats(replicaConnection, "postgres_replica")
+		// Без реплики не читаются списки и тексты, но изменения каталога продолжают работать
+		readiness.Add("database_replica", false, func(ctx context.Context) (any, error) {
+			return nil, replicaConnection.PingContext(ctx)
+		})
+	}
+	if sqliteConnection != nil {
+		poolStats = sqliteConnection.Stats
+		metrics.RegisterDBStats(sqliteConnection, "sqlite")
+		readiness.
+			Add("sqlite", true, func(ctx context.Context) (any, error) {
+				return nil, sqliteConnection.PingContext(ctx)
+			}).
+			Add("sqlite_migrations", true, migrationsCheck(sqliteMigrator))
+	}
+	metrics.RegisterCatalogStats(repository, 5*time.Second)
+
+	healthHandler := api.NewHealthHandler(readiness,
+		func() any { return watcher.Current().Redacted() },
+		func() any { return watcher.Status() },
+		poolStats, logger)
+
+	// Ограничение частоты запросов: квоты на чтение, изменение, добавление песен и попытки аутентификации
+	var rateLimiter *ratelimit.Limiter
+	if cfg.RateLimitEnabled {
+		var store ratelimit.Store = ratelimit.NewMemoryStore()
+		if cfg.RateLimitBackend == "postgres" { // только вместе с PostgreSQL, см. config.validateConfig
+			store = pg_repo.NewPostgresRateLimitStore(dbConnection)
+		}
+		rateLimiter = ratelimit.NewLimiter(store, ratelimit.Quotas{
+			ratelimit.ClassRead:   cfg.RateLimitRead,
+			ratelimit.ClassWrite:  cfg.RateLimitWrite,
+			ratelimit.ClassEnrich: cfg.RateLimitEnrich,
+			ratelimit.ClassAuth:   cfg.RateLimitAuth,
+		})
+		watcher.OnReload(func(c *config.Config) {
+			rateLimiter.SetQuotas(ratelimit.Quotas{
+				ratelimit.ClassRead:   c.RateLimitRead,
+				ratelimit.ClassWrite:  c.RateLimitWrite,
+				ratelimit.ClassEnrich: c.RateLimitEnrich,
+				ratelimit.ClassAuth:   c.RateLimitAuth,
+			})
+		})
+	}
+
+	// GraphQL-эндпоинт поверх того же сервиса
+	graphqlHandler, err := graphql_api.NewHandler(musicService, graphql_api.DefaultLimits, rateLimiter, logger)
+	if err != nil {
+		return fmt.Errorf("ошибка построения GraphQL-схемы: %w", err)
+	}
 
 	// Выбираем REST API реализацию
-	songAPI := api.NewRestSongAPI(songHandler)
+	// Адрес анонимного клиента берётся из X-Forwarded-For только за доверенными прокси
+	proxyHops := 0
+	if cfg.RateLimitTrustProxy {
+		proxyHops = cfg.RateLimitProxyHops
+	}
+	// Даты вывода API v1 уже проверены при загрузке конфигурации
+	v1DeprecatedSince, _ := config.ParseDate(cfg.APIV1DeprecatedSince)
+	v1Sunset, _ := config.ParseDate(cfg.APIV1Sunset)
+	songAPI := api.NewRestSongAPI(songHandler, songHandlerV2, authenticator, tenantResolver, logger).
+		WithV1Deprecation(v1DeprecatedSince, v1Sunset).
+		WithRateLimit(rateLimiter, proxyHops).
+		WithIdempotency(idempotencyStore, func() time.Duration { return watcher.Current().IdempotencyTTL }).
+		WithAccessLog(cfg.AccessLog).
+		WithCORS(cfg.CORSAllowedOrigins).
+		WithCompression(cfg.CompressionLevel).
+		WithLimits(cfg.MaxRequestBodyBytes, cfg.RequestTimeout).
+		Mount("/graphql", auth.RoleViewer, graphqlHandler). // мутации дополнительно требуют editor
+		MountStream("/events", auth.RoleViewer, http.HandlerFunc(eventsHandler.StreamEvents)).
+		MountStream("/events/ws", auth.RoleViewer, http.HandlerFunc(eventsHandler.StreamEventsWebSocket)).
+		Mount("/whoami", auth.RoleViewer, http.HandlerFunc(apiKeysHandler.WhoAmI)).
+		Mount("/debug/info", auth.RoleAdmin, http.HandlerFunc(healthHandler.DebugInfo)). // только администраторы платформы
+		Probe("/healthz", healthHandler.Healthz).
+		Probe("/readyz", healthHandler.Readyz).
+		Register(auth.RoleAdmin, apiKeysHandler.RegisterRoutes).
+		Register(auth.RoleAdmin, tenantsHandler.RegisterRoutes) // только администраторы платформы
+
+	// Вебхуки, избранное и плейлисты хранятся только в PostgreSQL
+	if dbConnection != nil {
+		// Вебхуки: журнал событий служит outbox, диспетчер доставляет события подписчикам
+		webhookRepository := pg_repo.NewPostgresWebhookRepository(dbConnection)
+		webhookConfig := webhooks.DefaultConfig
+		// Сети уже проверены при загрузке конфигурации
+		webhookConfig.AllowedNetworks, _ = webhooks.ParseNetworks(cfg.WebhookAllowedNetworks)
+		webhookDispatcher := webhooks.NewDispatcher(webhookRepository, webhookConfig, logger)
+		app.Go("Webhook dispatcher", func(ctx context.Context) error {
+			webhookDispatcher.Run(ctx)
+			return nil
+		})
+		webhooksHandler := api.NewWebhooksHandler(service.NewWebhookService(webhookRepository, logger), logger)
+
+		// Избранное и плейлисты пользователей
+		libraryService := service.NewLibraryService(pg_repo.NewPostgresLibraryRepository(dbConnection), transactor, logger)
+		libraryHandler := api.NewLibraryHandler(libraryService, logger)
+
+		songAPI.
+			Register(auth.RoleAdmin, webhooksHandler.RegisterRoutes).
+			Register(auth.RoleViewer, libraryHandler.RegisterRoutes).
+			Register("", libraryHandler.RegisterSharedRoutes)
+	}
+	watcher.OnReload(func(c *config.Config) { songAPI.SetCORSOrigins(c.CORSAllowedOrigins) })
+	app.Go("config watcher", watcher.Run)
+
+	// Запускаем сервер; порт занимается сразу, чтобы ошибка запуска не терялась в горутине
+	httpServer := &http.Server{
+		Handler:           songAPI.RegisterRoutes(),
+		ReadHeaderTimeout: cfg.HTTPReadHeaderTimeout,
+		ReadTimeout:       cfg.HTTPReadTimeout,
+		WriteTimeout:      cfg.HTTPWriteTimeout,
+		IdleTimeout:       cfg.HTTPIdleTimeout,
+	}
+	httpServer.RegisterOnShutdown(eventsHandler.Shutdown)
+
+	// Все порты открываются до регистрации серверов: если какой-то занят, уже открытые закрываются,
+	// а серверы, не получив Run, так и не запускаются
+	var listeners []net.Listener
+	listen := func(server string, port string) (net.Listener, error) {
+		if port == "" { // сервер выключен
+			return nil, nil
+		}
+		l, err := net.Listen("tcp", fmt.Sprintf(":%s", port))
+		if err != nil {
+			for _, opened := range listeners {
+				opened.Close()
+			}
+			return nil, fmt.Errorf("ошибка запуска %s: %w", server, err)
+		}
+		listeners = append(listeners, l)
+		return l, nil
+	}
+	listener, err := listen("HTTP сервера", cfg.ServerPort)
+	if err != nil {
+		return err
+	}
+	grpcListener, err := listen("gRPC сервера", cfg.GRPCPort)
+	if err != nil {
+		return err
+	}
+	metricsListener, err := listen("сервера метрик", cfg.MetricsPort)
+	if err != nil {
+		return err
+	}
+
+	app.Serve("HTTP server", lifecycle.NewHTTPServer(httpServer, listener))
+	logger.Info(fmt.Sprintf("Starting server on port %s...", cfg.ServerPort))
+	logger.Info(fmt.Sprintf("Swagger UI available at http://localhost:%s/swagger/v1/index.html (also /swagger/index.html) and http://localhost:%s/swagger/v2/index.html", cfg.ServerPort, cfg.ServerPort))
 
-	// Запускаем сервер
-	logger.Info(fmt.Sprintf("Starting server on port %s...", config.ServerPort))
-	logger.Info(fmt.Sprintf("Swagger UI available at http://localhost:%s/swagger/index.html", config.ServerPort))
-	http.ListenAndServe(fmt.Sprintf(":%s", config.ServerPort), songAPI.RegisterRoutes())
+	// gRPC запускается рядом с REST, если задан порт
+	if grpcListener != nil {
+		grpcServer := grpc_api.NewGRPCServer(grpc_api.NewCatalogServer(musicService, logger), authenticator, tenantResolver, rateLimiter)
+		app.Serve("gRPC server", lifecycle.NewGRPCServer(grpcServer, grpcListener))
+		logger.Info(fmt.Sprintf("Starting gRPC server on port %s...", cfg.GRPCPort))
+	}
+
+	// Метрики — на отдельном порту: в них slug арендаторов, а порт API открыт клиентам
+	if metricsListener != nil {
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("GET /metrics", metrics.Handler())
+		metricsServer := &http.Server{
+			Handler:           metricsMux,
+			ReadHeaderTimeout: cfg.HTTPReadHeaderTimeout,
+			ReadTimeout:       cfg.HTTPReadTimeout,
+			WriteTimeout:      cfg.HTTPWriteTimeout,
+			IdleTimeout:       cfg.HTTPIdleTimeout,
+		}
+		app.Serve("metrics server", lifecycle.NewHTTPServer(metricsServer, metricsListener))
+		logger.Info(fmt.Sprintf("Serving metrics on port %s...", cfg.MetricsPort))
+	}
 
+	// Блокируемся до SIGINT/SIGTERM: серверы дожидаются текущих запросов, затем
+	// останавливаются фоновые задачи и закрывается пул соединений
+	return app.Run(context.Background())
+}
+
+// migrationsCheck — проверка готовности: схема не dirty и все встроенные миграции применены
+func migrationsCheck(migrator *db.Migrator) func(ctx context.Context) (any, error) {
+	return func(ctx context.Context) (any, error) {
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if status.Dirty {
+			return status, fmt.Errorf("migration %d is dirty", status.Version)
+		}
+		// Без AUTO_MIGRATE схема может отставать от кода, пока не выполнен catalogctl migrate up
+		if len(status.Pending) > 0 {
+			return status, fmt.Errorf("%d migrations pending", len(status.Pending))
+		}
+		return status, nil
+	}
 }
@@ -0,0 +1,236 @@
+// catalogctl — административные команды каталога: миграции схемы, загрузка и выгрузка песен,
+// обслуживание базы. Конфигурация собирается так же, как у сервера: файл (--config), переменные
+// окружения и флаги настроек перед именем команды (см. config.Loader).
+//
+//	go run ./cmd/catalogctl migrate up
+//	go run ./cmd/catalogctl --tenant acme export --format csv > songs.csv
+//	go run ./cmd/catalogctl enrich --missing
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"music_catalog/config"
+	"music_catalog/internal/auth"
+	"music_catalog/internal/db"
+	"music_catalog/internal/logger"
+	"music_catalog/internal/models"
+	"music_catalog/internal/repository/external_api"
+	"music_catalog/internal/repository/pg_repo"
+	"music_catalog/internal/repository/sqlite_repo"
+	"music_catalog/internal/service"
+	"music_catalog/internal/tenant"
+)
+
+// command — подкоманда catalogctl
+type command struct {
+	name  string
+	args  string // синтаксис аргументов для справки
+	usage string
+	run   func(ctx context.Context, c *cli, args []string) error
+}
+
+var commands = []command{
+	{"migrate", "[--sqlite] up | down N | version | status | force V", "применить миграции, откатить N последних, показать версию схемы и ожидающие миграции, принудительно записать версию; --sqlite — схема базы SQLITE_PATH", runMigrate},
+	{"seed", "FILE", "добавить песни из файла фикстур (.json или .csv); существующие песни не меняются", runSeed},
+	{"import", "[--format json|csv] [--overwrite] FILE|-", "загрузить песни из файла или stdin", runImport},
+	{"export", "[--format json|csv] [--output FILE]", "выгрузить песни каталога", runExport},
+	{"enrich", "[--missing]", "дополнить песни данными внешнего API; --missing — только песни без текста или ссылки", runEnrich},
+	{"reindex", "[--concurrently]", "перестроить индексы таблиц каталога и обновить статистику", runReindex},
+	{"purge-trash", "", "очистить служебные таблицы: истёкшие ключи идемпотентности и неактивные корзины ограничения частоты (песни не затрагиваются)", runPurgeTrash},
+}
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "catalogctl:", err)
+		os.Exit(1)
+	}
+}
+
+// run разбирает общие флаги, загружает конфигурацию и выполняет подкоманду
+func run(args []string) error {
+	flags := flag.NewFlagSet("catalogctl", flag.ContinueOnError)
+	loader := config.NewLoader(flags)
+	tenantSlug := flags.String("tenant", tenant.DefaultSlug, "арендатор, с каталогом которого работают команды")
+	flags.Usage = func() { printUsage(flags) }
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if flags.NArg() == 0 {
+		printUsage(flags)
+		return errors.New("command is required")
+	}
+	cmd, ok := findCommand(flags.Arg(0))
+	if !ok {
+		return fmt.Errorf("unknown command %q, see catalogctl --help", flags.Arg(0))
+	}
+
+	cfg, err := loader.Load()
+	if err != nil {
+		return fmt.Errorf("ошибка при загрузке конфигурации: %w", err)
+	}
+	// Журнал — в stderr, чтобы не смешиваться с выгрузкой в stdout
+	log, err := logger.New(cfg.LogLevel, "text", os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	c := &cli{config: cfg, logger: log, tenant: *tenantSlug}
+	defer c.close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return cmd.run(ctx, c, flags.Args()[1:])
+}
+
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+func printUsage(flags *flag.FlagSet) {
+	out := flags.Output()
+	fmt.Fprintln(out, "Usage: catalogctl [flags] <command> [args]")
+	fmt.Fprintln(out, "\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %s %s\n    \t%s\n", cmd.name, cmd.args, cmd.usage)
+	}
+	fmt.Fprintln(out, "\nFlags:")
+	flags.PrintDefaults()
+}
+
+// commandFlags создаёт набор флагов подкоманды
+func commandFlags(name string) *flag.FlagSet {
+	return flag.NewFlagSet("catalogctl "+name, flag.ContinueOnError)
+}
+
+// cli — конфигурация и подключения, общие для подкоманд; база открывается при первом обращении
+type cli struct {
+	config *config.Config
+	logger *logger.SlogLogger
+	tenant string
+	db     *sql.DB
+	sqlite *sql.DB // база SQLITE_PATH при STORAGE_BACKEND=sqlite
+}
+
+// database возвращает пул соединений с основной базой; команды не читают из реплики,
+// чтобы видеть только что сделанные изменения
+func (c *cli) database(ctx context.Context) (*sql.DB, error) {
+	if c.db == nil {
+		conn, err := db.NewDB(ctx, c.config, c.logger)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка подключения к базе данных: %w", err)
+		}
+		c.db = conn
+	}
+	return c.db, nil
+}
+
+// sqliteDatabase возвращает пул соединений с файлом базы SQLITE_PATH
+func (c *cli) sqliteDatabase(ctx context.Context) (*sql.DB, error) {
+	if c.sqlite == nil {
+		conn, err := db.NewSQLiteDB(ctx, c.config, c.logger)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка открытия базы SQLite: %w", err)
+		}
+		c.sqlite = conn
+	}
+	return c.sqlite, nil
+}
+
+func (c *cli) close() {
+	if c.db != nil {
+		c.db.Close()
+	}
+	if c.sqlite != nil {
+		c.sqlite.Close()
+	}
+}
+
+// MusicService — операции сервиса каталога, которыми пользуются команды
+type MusicService interface {
+	GetArtists(ctx context.Context, name string, pagination models.Pagination) ([]string, error)
+	GetSongsByGroups(ctx context.Context, groups []string) ([]models.Song, error)
+	ImportSong(ctx context.Context, song models.Song, overwrite bool) (service.ImportResult, error)
+	EnrichSong(ctx context.Context, songID int, onlyMissing bool) (bool, error)
+}
+
+// musicService собирает сервис каталога так же, как сервер: изменения попадают в журнал событий,
+// и подписчики с вебхуками узнают о них, как об изменениях через API
+func (c *cli) musicService(ctx context.Context) (MusicService, error) {
+	externalAPI := external_api.NewExternalAPIClient(c.config, c.logger)
+	switch c.config.StorageBackend {
+	case "sqlite":
+		// Сервер находит события, записанные catalogctl, перечитывая журнал в файле
+		songsDB, err := c.sqliteDatabase(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return service.NewMusicService(
+			sqlite_repo.NewSQLiteSongRepository(songsDB),
+			externalAPI,
+			sqlite_repo.NewSQLiteEventRepository(songsDB),
+			sqlite_repo.NewSQLiteTransactor(songsDB),
+			c.logger,
+		), nil
+	case "memory":
+		return nil, errors.New("songs are stored in the server process (STORAGE_BACKEND=memory), catalogctl cannot reach them")
+	}
+	conn, err := c.database(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return service.NewMusicService(
+		pg_repo.NewPostgresSongRepository(conn),
+		externalAPI,
+		pg_repo.NewPostgresEventRepository(conn),
+		pg_repo.NewPostgresTransactor(conn),
+		c.logger,
+	), nil
+}
+
+// tenants возвращает справочник арендаторов хранилища STORAGE_BACKEND
+func (c *cli) tenants(ctx context.Context) (tenant.Store, error) {
+	if c.config.StorageBackend == "sqlite" {
+		songsDB, err := c.sqliteDatabase(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return sqlite_repo.NewSQLiteTenantRepository(songsDB), nil
+	}
+	conn, err := c.database(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return pg_repo.NewPostgresTenantRepository(conn), nil
+}
+
+// catalogContext возвращает контекст, в котором команды работают с каталогом арендатора --tenant
+// от имени администратора catalogctl
+func (c *cli) catalogContext(ctx context.Context) (context.Context, error) {
+	tenants, err := c.tenants(ctx)
+	if err != nil {
+		return nil, err
+	}
+	t, err := tenants.GetTenantBySlug(ctx, c.tenant)
+	if err != nil {
+		return nil, fmt.Errorf("tenant %q: %w", c.tenant, err)
+	}
+	ctx = tenant.WithTenant(ctx, t)
+	ctx = auth.WithPrincipal(ctx, auth.Principal{ID: "catalogctl", Name: "catalogctl", Role: auth.RoleAdmin, Method: auth.MethodNone})
+	return ctx, nil
+}
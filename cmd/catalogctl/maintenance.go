@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"music_catalog/internal/db"
+	"music_catalog/internal/repository/pg_repo"
+)
+
+// runReindex: reindex [--concurrently]
+func runReindex(ctx context.Context, c *cli, args []string) error {
+	flags := commandFlags("reindex")
+	concurrently := flags.Bool("concurrently", false, "перестраивать индексы без блокировки записи (PostgreSQL 12+)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	conn, err := c.database(ctx)
+	if err != nil {
+		return err
+	}
+	if err := db.Reindex(ctx, conn, *concurrently); err != nil {
+		return err
+	}
+	c.logger.Info("Catalog indexes rebuilt")
+	return nil
+}
+
+// runPurgeTrash: purge-trash — очищает служебные таблицы: истёкшие ключи идемпотентности
+// и неактивные корзины ограничения частоты. Сервер чистит их и сам по ходу работы, но только пока
+// к соответствующим таблицам есть запросы. Корзины удалённых песен нет: песни удаляются сразу.
+func runPurgeTrash(ctx context.Context, c *cli, args []string) error {
+	if len(args) != 0 {
+		return errors.New("purge-trash takes no arguments")
+	}
+	conn, err := c.database(ctx)
+	if err != nil {
+		return err
+	}
+
+	keys, err := pg_repo.NewPostgresIdempotencyStore(conn).PurgeExpired(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Корзина, не менявшаяся дольше наибольшего периода квоты, наполнена и не нужна.
+	// Без квот в конфигурации catalogctl периоды неизвестны (у сервера они могут быть заданы),
+	// и очистка с нулевым сроком удалила бы и используемые корзины
+	idle := max(c.config.RateLimitRead.Period, c.config.RateLimitWrite.Period, c.config.RateLimitEnrich.Period, c.config.RateLimitAuth.Period)
+	if idle == 0 {
+		fmt.Printf("purged %d idempotency keys; rate limit buckets skipped: no RATE_LIMIT_* quotas configured\n", keys)
+		return nil
+	}
+	buckets, err := pg_repo.NewPostgresRateLimitStore(conn).PurgeIdle(ctx, idle)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("purged %d idempotency keys, %d rate limit buckets\n", keys, buckets)
+	return nil
+}
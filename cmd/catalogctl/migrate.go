@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"music_catalog/internal/db"
+
+	"github.com/golang-migrate/migrate/v4"
+)
+
+// runMigrate: migrate [--sqlite] up | down N | version | status | force V
+func runMigrate(ctx context.Context, c *cli, args []string) error {
+	flags := commandFlags("migrate")
+	sqlite := flags.Bool("sqlite", false, "миграции базы SQLITE_PATH (STORAGE_BACKEND=sqlite) вместо PostgreSQL")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	args = flags.Args()
+	if len(args) == 0 {
+		return errors.New("usage: migrate [--sqlite] up | down N | version | status | force V")
+	}
+	var migrator *db.Migrator
+	if *sqlite {
+		migrator = db.NewSQLiteMigrator(c.config.SQLitePath, c.logger)
+	} else {
+		conn, err := c.database(ctx)
+		if err != nil {
+			return err
+		}
+		migrator = db.NewMigrator(conn, c.config.MigrationLockTimeout, c.logger)
+	}
+
+	switch action := args[0]; action {
+	case "up":
+		return migrator.Up(ctx)
+	case "down":
+		// Число шагов обязательно: откат без него удалил бы всю схему вместе с данными
+		steps, err := intArg(args, "down N")
+		if err != nil {
+			return err
+		}
+		if steps <= 0 {
+			return errors.New("down: N must be a positive number")
+		}
+		return migrator.Down(ctx, steps)
+	case "version":
+		version, dirty, err := migrator.Version(ctx)
+		if errors.Is(err, migrate.ErrNilVersion) {
+			fmt.Println("no migrations applied")
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if dirty {
+			fmt.Printf("%d (dirty)\n", version)
+		} else {
+			fmt.Println(version)
+		}
+		return nil
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("version %d, latest %d", status.Version, status.Latest)
+		if status.Dirty {
+			fmt.Print(", dirty")
+		}
+		fmt.Println()
+		for _, migration := range status.Pending {
+			fmt.Printf("pending %06d %s\n", migration.Version, migration.Name)
+		}
+		return nil
+	case "force":
+		version, err := intArg(args, "force V")
+		if err != nil {
+			return err
+		}
+		return migrator.Force(ctx, version)
+	default:
+		return fmt.Errorf("unknown migrate action %q, expected up, down, version, status or force", action)
+	}
+}
+
+// intArg разбирает единственный числовой аргумент действия
+func intArg(args []string, usage string) (int, error) {
+	if len(args) != 2 {
+		return 0, fmt.Errorf("usage: migrate %s", usage)
+	}
+	n, err := strconv.Atoi(args[1])
+	if err != nil {
+		return 0, fmt.Errorf("migrate %s: invalid number %q", usage, args[1])
+	}
+	return n, nil
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	catalog_errors "music_catalog/internal/errors"
+	"music_catalog/internal/models"
+	"music_catalog/internal/service"
+	"music_catalog/internal/validation"
+)
+
+// Форматы файлов песен
+const (
+	formatJSON = "json" // массив объектов models.Song
+	formatCSV  = "csv"  // заголовок с именами колонок из csvColumns в любом порядке
+)
+
+// csvColumns — колонки CSV в порядке выгрузки; id при загрузке не учитывается
+var csvColumns = []string{"id", "group", "title", "release_date", "link", "text"}
+
+// exportPageSize — сколько групп выгружается за один запрос
+const exportPageSize = 100
+
+// runSeed: seed FILE — загрузка фикстур; формат определяется по расширению файла
+func runSeed(ctx context.Context, c *cli, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: seed FILE")
+	}
+	format := strings.TrimPrefix(strings.ToLower(filepath.Ext(args[0])), ".")
+	return importSongs(ctx, c, args[0], format, false)
+}
+
+// runImport: import [--format json|csv] [--overwrite] FILE|-
+func runImport(ctx context.Context, c *cli, args []string) error {
+	flags := commandFlags("import")
+	format := flags.String("format", formatJSON, "формат файла: json или csv")
+	overwrite := flags.Bool("overwrite", false, "перезаписывать существующие песни с той же группой и названием")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return errors.New("usage: import [--format json|csv] [--overwrite] FILE|-")
+	}
+	return importSongs(ctx, c, flags.Arg(0), *format, *overwrite)
+}
+
+// importSongs проверяет все песни файла и только затем загружает их, чтобы ошибка
+// в середине файла не оставила каталог загруженным наполовину
+func importSongs(ctx context.Context, c *cli, path string, format string, overwrite bool) error {
+	songs, err := readSongs(path, format)
+	if err != nil {
+		return err
+	}
+	var invalid []error
+	for i := range songs {
+		if err := validateSong(&songs[i]); err != nil {
+			invalid = append(invalid, fmt.Errorf("song %d (%s - %s): %w", i+1, songs[i].Group, songs[i].Title, err))
+		}
+	}
+	if err := errors.Join(invalid...); err != nil {
+		return err
+	}
+
+	ctx, err = c.catalogContext(ctx)
+	if err != nil {
+		return err
+	}
+	musicService, err := c.musicService(ctx)
+	if err != nil {
+		return err
+	}
+	counts := make(map[service.ImportResult]int)
+	for i, song := range songs {
+		result, err := musicService.ImportSong(ctx, song, overwrite)
+		if err != nil {
+			return fmt.Errorf("song %d (%s - %s): %w", i+1, song.Group, song.Title, err)
+		}
+		counts[result]++
+	}
+	fmt.Printf("created %d, updated %d, skipped %d\n",
+		counts[service.ImportCreated], counts[service.ImportUpdated], counts[service.ImportSkipped])
+	return nil
+}
+
+// validateSong нормализует и проверяет песню по тем же правилам, что и API
+func validateSong(song *models.Song) error {
+	v := validation.New()
+	v.Check("group", &song.Group, validation.SongGroup)
+	v.Check("title", &song.Title, validation.SongTitle)
+	v.Check("text", &song.Text, validation.SongText)
+	v.Check("link", &song.Link, validation.SongLink)
+	v.Check("release_date", &song.ReleaseDate, validation.SongReleaseDate)
+	return v.Err()
+}
+
+// readSongs читает песни из файла или из stdin ("-")
+func readSongs(path string, format string) ([]models.Song, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	switch format {
+	case formatJSON:
+		var songs []models.Song
+		decoder := json.NewDecoder(r)
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&songs); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return songs, nil
+	case formatCSV:
+		return readCSV(r, path)
+	default:
+		return nil, fmt.Errorf("unsupported format %q, expected json or csv", format)
+	}
+}
+
+// readCSV читает песни из CSV с заголовком
+func readCSV(r io.Reader, path string) ([]models.Song, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%s: header: %w", path, err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if !contains(csvColumns, name) {
+			return nil, fmt.Errorf("%s: unknown column %q, expected %s", path, name, strings.Join(csvColumns, ", "))
+		}
+		columns[name] = i
+	}
+
+	var songs []models.Song
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return songs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		field := func(name string) string {
+			if i, ok := columns[name]; ok {
+				return record[i]
+			}
+			return ""
+		}
+		songs = append(songs, models.Song{
+			Group:       field("group"),
+			Title:       field("title"),
+			ReleaseDate: field("release_date"),
+			Link:        field("link"),
+			Text:        field("text"),
+		})
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// runExport: export [--format json|csv] [--output FILE]
+func runExport(ctx context.Context, c *cli, args []string) error {
+	flags := commandFlags("export")
+	format := flags.String("format", formatJSON, "формат выгрузки: json или csv")
+	output := flags.String("output", "-", "файл выгрузки; - — stdout")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *format != formatJSON && *format != formatCSV {
+		return fmt.Errorf("unsupported format %q, expected json or csv", *format)
+	}
+
+	ctx, err := c.catalogContext(ctx)
+	if err != nil {
+		return err
+	}
+	musicService, err := c.musicService(ctx)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "-" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	count := 0
+	switch *format {
+	case formatJSON:
+		// Выгрузка пишется потоком: каталог не собирается в памяти целиком
+		if _, err := io.WriteString(w, "["); err != nil {
+			return err
+		}
+		err = eachSong(ctx, musicService, func(song models.Song) error {
+			data, err := json.Marshal(song)
+			if err != nil {
+				return err
+			}
+			separator := "\n  "
+			if count > 0 {
+				separator = ",\n  "
+			}
+			count++
+			_, err = io.WriteString(w, separator+string(data))
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, "\n]\n"); err != nil {
+			return err
+		}
+	case formatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(csvColumns); err != nil {
+			return err
+		}
+		err = eachSong(ctx, musicService, func(song models.Song) error {
+			count++
+			return writer.Write([]string{fmt.Sprint(song.ID), song.Group, song.Title, song.ReleaseDate, song.Link, song.Text})
+		})
+		if err != nil {
+			return err
+		}
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return err
+		}
+	}
+	c.logger.Info(fmt.Sprintf("Exported %d songs", count))
+	return nil
+}
+
+// eachSong обходит песни каталога по группам в алфавитном порядке; дата выпуска приводится
+// к формату YYYY-MM-DD, в котором её принимают import и API
+func eachSong(ctx context.Context, musicService MusicService, fn func(song models.Song) error) error {
+	for offset := 0; ; offset += exportPageSize {
+		groups, err := musicService.GetArtists(ctx, "", models.Pagination{Limit: exportPageSize, Offset: offset})
+		if err != nil {
+			return err
+		}
+		if len(groups) == 0 {
+			return nil
+		}
+		songs, err := musicService.GetSongsByGroups(ctx, groups)
+		if err != nil {
+			return err
+		}
+		for _, song := range songs {
+			song.ReleaseDate = validation.NormalizeDate(song.ReleaseDate)
+			if err := fn(song); err != nil {
+				return err
+			}
+		}
+		if len(groups) < exportPageSize {
+			return nil
+		}
+	}
+}
+
+// runEnrich: enrich [--missing] — запрашивает детали песен во внешнем API. Ошибка по одной
+// песне не останавливает обход; команда завершается ошибкой, если не удалось дополнить хотя бы одну
+func runEnrich(ctx context.Context, c *cli, args []string) error {
+	flags := commandFlags("enrich")
+	missing := flags.Bool("missing", false, "только песни без текста или ссылки; заполняются лишь пустые поля")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	ctx, err := c.catalogContext(ctx)
+	if err != nil {
+		return err
+	}
+	musicService, err := c.musicService(ctx)
+	if err != nil {
+		return err
+	}
+
+	var updated, unchanged, failed int
+	err = eachSong(ctx, musicService, func(song models.Song) error {
+		if *missing && song.Text != "" && song.Link != "" {
+			return nil
+		}
+		changed, err := musicService.EnrichSong(ctx, song.ID, *missing)
+		switch {
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case err != nil && (errors.Is(err, catalog_errors.ErrUpstream) || errors.Is(err, catalog_errors.ErrSongNotFound)):
+			c.logger.Error(fmt.Sprintf("Song %d (%s - %s) not enriched:", song.ID, song.Group, song.Title), err)
+			failed++
+		case err != nil:
+			return err
+		case changed:
+			updated++
+		default:
+			unchanged++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("updated %d, unchanged %d, failed %d\n", updated, unchanged, failed)
+	if failed > 0 {
+		return fmt.Errorf("%d songs could not be enriched", failed)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+// webhook-receiver — локальный получатель вебхуков для разработки и отладки:
+// проверяет подпись каждой доставки и выводит событие в лог.
+//
+//	go run ./cmd/webhook-receiver -addr :9000 -secret whsec_...
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"music_catalog/internal/logger"
+	"music_catalog/internal/webhooks"
+)
+
+func main() {
+	addr := flag.String("addr", ":9000", "адрес, на котором принимать вебхуки")
+	secret := flag.String("secret", os.Getenv("WEBHOOK_SECRET"), "секрет подписи (по умолчанию из WEBHOOK_SECRET)")
+	tolerance := flag.Duration("tolerance", 5*time.Minute, "допустимое расхождение метки времени подписи")
+	failRate := flag.Int("fail-every", 0, "отвечать 500 на каждый N-й запрос, чтобы проверить повторные попытки")
+	flag.Parse()
+
+	logger := logger.NewLogger("debug")
+	if *secret == "" {
+		logger.Fatal("секрет подписи не задан: укажите -secret или WEBHOOK_SECRET")
+	}
+
+	var received atomic.Int64
+	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if err := webhooks.Verify(*secret, r.Header.Get(webhooks.SignatureHeader), body, *tolerance, time.Now()); err != nil {
+			logger.Error("Rejected delivery", r.Header.Get(webhooks.DeliveryHeader), err)
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		if n := received.Add(1); *failRate > 0 && n%int64(*failRate) == 0 {
+			logger.Info("Simulating failure for delivery", r.Header.Get(webhooks.DeliveryHeader))
+			http.Error(w, "simulated failure", http.StatusInternalServerError)
+			return
+		}
+
+		logger.Info(fmt.Sprintf("Delivery %s (%s): %s", r.Header.Get(webhooks.DeliveryHeader), r.Header.Get(webhooks.EventHeader), body))
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	logger.Info(fmt.Sprintf("Listening for webhooks on %s...", *addr))
+	if err := http.ListenAndServe(*addr, nil); err != nil {
+		logger.Fatal("ошибка сервера: %v", err)
+	}
+}
@@ -1,53 +1,222 @@
 package config
 
 import (
+	"errors"
 	"fmt"
-	"log"
-	"os"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
-	"github.com/joho/godotenv"
+	"music_catalog/internal/ratelimit"
+	"music_catalog/internal/webhooks"
 )
 
+// Config — настройки сервиса. Каждое поле с тегом env задаётся переменной окружения с этим именем,
+// ключом файла конфигурации и флагом командной строки (см. Loader); default — значение по умолчанию;
+// reload — настройка применяется к работающему сервису без перезапуска (см. Watcher).
 type Config struct {
-	ServerPort     string
-	DBHost         string
-	DBPort         string
-	DBUser         string
-	DBPassword     string
-	DBName         string
-	DBSSLMode      string
-	ExternalAPIURL string
-}
-
-func LoadConfig() (*Config, error) {
-	err := godotenv.Load()
-	if err != nil {
-		log.Printf("[ERROR] Unable to load .env file: %v", err)
-		return nil, err
+	LogLevel   string `env:"LOG_LEVEL" default:"info" reload:"true" usage:"уровень журнала: debug, info, warn, error"`
+	LogFormat  string `env:"LOG_FORMAT" default:"json" usage:"формат журнала: json, text"`
+	ServerPort string `env:"SERVER_PORT" default:"8080" usage:"порт HTTP API"`
+	GRPCPort   string `env:"GRPC_PORT" usage:"порт gRPC; пустое значение отключает gRPC-сервер"`
+	// Метрики содержат slug арендаторов и размеры их каталогов, поэтому отдаются на отдельном порту,
+	// закрытом от внешнего трафика
+	MetricsPort string `env:"METRICS_PORT" default:"9091" usage:"порт /metrics для Prometheus; пустое значение отключает метрики"`
+
+	// Хранилище каталога песен. При memory и sqlite журнал событий, арендаторы и ключи хранятся там же,
+	// а PostgreSQL не нужен; вебхуки, избранное и плейлисты без него отключены
+	StorageBackend string `env:"STORAGE_BACKEND" default:"postgres" usage:"хранилище песен: postgres, sqlite (файл SQLITE_PATH без PostgreSQL) или memory (в памяти процесса без PostgreSQL, для демонстраций и тестов)"`
+	SQLitePath     string `env:"SQLITE_PATH" default:"music_catalog.db" usage:"файл базы SQLite с каталогом песен при STORAGE_BACKEND=sqlite; создаётся при первом запуске"`
+
+	// База данных: DB_DSN целиком или по отдельным полям
+	DBDSN      string `env:"DB_DSN" usage:"строка подключения PostgreSQL (postgres://... или key=value); заменяет DB_HOST..DB_SSLMODE"`
+	DBHost     string `env:"DB_HOST" usage:"адрес PostgreSQL"`
+	DBPort     string `env:"DB_PORT" default:"5432" usage:"порт PostgreSQL"`
+	DBUser     string `env:"DB_USER" usage:"пользователь PostgreSQL"`
+	DBPassword string `env:"DB_PASSWORD" usage:"пароль PostgreSQL"`
+	DBName     string `env:"DB_NAME" usage:"имя базы данных"`
+	DBSSLMode  string `env:"DB_SSLMODE" default:"require" usage:"режим SSL: disable, require, verify-ca, verify-full"`
+
+	// Необязательная реплика для чтения списка песен и текстов; изменения идут в основную базу
+	DBReplicaDSN string `env:"DB_REPLICA_DSN" usage:"строка подключения к реплике PostgreSQL для чтения; пусто — всё читается из основной базы"`
+
+	AutoMigrate          bool          `env:"AUTO_MIGRATE" default:"true" usage:"применять миграции при запуске; false — схему обновляет catalogctl migrate up"`
+	MigrationLockTimeout time.Duration `env:"MIGRATION_LOCK_TIMEOUT" default:"1m" usage:"сколько ждать, пока миграции применяет другой экземпляр"`
+
+	// Пул соединений с базой (и с репликой, если она задана)
+	DBConnectTimeout  time.Duration `env:"DB_CONNECT_TIMEOUT" default:"30s" usage:"сколько при запуске ждать доступности базы, повторяя попытки"`
+	DBMaxOpenConns    int           `env:"DB_MAX_OPEN_CONNS" default:"25" usage:"наибольшее число открытых соединений; 0 — без ограничения"`
+	DBMaxIdleConns    int           `env:"DB_MAX_IDLE_CONNS" default:"10" usage:"наибольшее число простаивающих соединений"`
+	DBConnMaxLifetime time.Duration `env:"DB_CONN_MAX_LIFETIME" default:"30m" usage:"время жизни соединения"`
+	DBConnMaxIdleTime time.Duration `env:"DB_CONN_MAX_IDLE_TIME" default:"5m" usage:"время простоя, после которого соединение закрывается"`
+
+	// Внешний API с деталями песен
+	ExternalAPIURL     string        `env:"EXTERNAL_API_URL" reload:"true" usage:"адрес внешнего API с деталями песен"`
+	ExternalAPITimeout time.Duration `env:"EXTERNAL_API_TIMEOUT" default:"10s" reload:"true" usage:"время ожидания ответа внешнего API"`
+
+	// Таймауты HTTP-сервера; потоки /events снимают их для себя
+	HTTPReadHeaderTimeout time.Duration `env:"HTTP_READ_HEADER_TIMEOUT" default:"5s" usage:"время чтения заголовков запроса"`
+	HTTPReadTimeout       time.Duration `env:"HTTP_READ_TIMEOUT" default:"30s" usage:"время чтения запроса"`
+	HTTPWriteTimeout      time.Duration `env:"HTTP_WRITE_TIMEOUT" default:"60s" usage:"время записи ответа; с запасом на обращение к внешнему API"`
+	HTTPIdleTimeout       time.Duration `env:"HTTP_IDLE_TIMEOUT" default:"120s" usage:"время простоя keep-alive соединения"`
+	ShutdownTimeout       time.Duration `env:"SHUTDOWN_TIMEOUT" default:"30s" usage:"сколько ждать текущих запросов и фоновых задач при остановке"`
+
+	// Общая цепочка middleware HTTP API
+	AccessLog           bool          `env:"ACCESS_LOG" default:"true" usage:"запись журнала на каждый запрос"`
+	CORSAllowedOrigins  []string      `env:"CORS_ALLOWED_ORIGINS" reload:"true" usage:"источники браузерных клиентов через запятую (* — любые); пусто — CORS выключен"`
+	CompressionLevel    int           `env:"COMPRESSION_LEVEL" default:"5" usage:"степень сжатия ответов gzip/br от 1 до 9; 0 — без сжатия"`
+	MaxRequestBodyBytes int64         `env:"MAX_REQUEST_BODY_BYTES" default:"1048576" usage:"наибольший размер тела запроса в байтах"`
+	RequestTimeout      time.Duration `env:"REQUEST_TIMEOUT" default:"30s" usage:"время обработки запроса; потоки /events не ограничиваются"`
+
+	// Вывод API v1 из обращения: даты в заголовках Deprecation и Sunset ответов v1 и маршрутов без префикса;
+	// Deprecation выводится всегда, по умолчанию — с датой выпуска API v2
+	APIV1DeprecatedSince string `env:"API_V1_DEPRECATED_SINCE" default:"2026-10-18" usage:"дата объявления API v1 устаревшим, YYYY-MM-DD"`
+	APIV1Sunset          string `env:"API_V1_SUNSET" usage:"дата, после которой API v1 может быть отключено, YYYY-MM-DD; пусто — без заголовка Sunset"`
+
+	// Проверки готовности (/readyz)
+	HealthCheckTimeout  time.Duration `env:"HEALTH_CHECK_TIMEOUT" default:"2s" usage:"общий срок проверок готовности"`
+	ExternalAPICheckTTL time.Duration `env:"EXTERNAL_API_CHECK_TTL" default:"30s" reload:"true" usage:"сколько кэшируется результат проверки внешнего API"`
+
+	// Аутентификация
+	AuthDisabled      bool     `env:"AUTH_DISABLED" usage:"только для локальной разработки: все запросы выполняются от имени admin"`
+	AdminAPIKey       string   `env:"ADMIN_API_KEY" usage:"статический ключ администратора для первичной настройки"`
+	JWTHMACSecret     string   `env:"JWT_HMAC_SECRET" usage:"секрет для токенов HS256/HS384/HS512"`
+	JWTPublicKeyFiles []string `env:"JWT_PUBLIC_KEY_FILES" usage:"PEM-файлы открытых ключей для токенов RS*/ES*/EdDSA через запятую"`
+	JWTIssuer         string   `env:"JWT_ISSUER" usage:"ожидаемый iss токенов"`
+	JWTAudience       string   `env:"JWT_AUDIENCE" usage:"ожидаемый aud токенов"`
+	JWTRoleClaim      string   `env:"JWT_ROLE_CLAIM" default:"role" usage:"claim с ролью клиента"`
+	JWTTenantClaim    string   `env:"JWT_TENANT_CLAIM" default:"tenant" usage:"claim с арендатором клиента"`
+
+	// Ограничение частоты запросов
+	RateLimitEnabled    bool            `env:"RATE_LIMIT_ENABLED" usage:"включить ограничение частоты запросов"`
+	RateLimitBackend    string          `env:"RATE_LIMIT_BACKEND" default:"memory" usage:"хранилище квот: memory (на экземпляр) или postgres (общее)"`
+	RateLimitRead       ratelimit.Quota `env:"RATE_LIMIT_READ" reload:"true" usage:"квота на чтение каталога, например 600/1m; 0 — без ограничения"`
+	RateLimitWrite      ratelimit.Quota `env:"RATE_LIMIT_WRITE" reload:"true" usage:"квота на изменение каталога и настроек"`
+	RateLimitEnrich     ratelimit.Quota `env:"RATE_LIMIT_ENRICH" reload:"true" usage:"квота на добавление песен (запросы к внешнему API)"`
+	RateLimitAuth       ratelimit.Quota `env:"RATE_LIMIT_AUTH" reload:"true" usage:"квота на запросы с учётными данными с одного IP-адреса (до их проверки)"`
+	RateLimitTrustProxy bool            `env:"RATE_LIMIT_TRUST_PROXY" usage:"брать адрес анонимного клиента из X-Forwarded-For"`
+	RateLimitProxyHops  int             `env:"RATE_LIMIT_PROXY_HOPS" default:"1" usage:"число доверенных прокси перед сервисом: адрес клиента — N-й справа в X-Forwarded-For"`
+
+	// Вебхуки не доставляются на внутренние адреса (loopback, частные сети, metadata облаков)
+	WebhookAllowedNetworks []string `env:"WEBHOOK_ALLOWED_NETWORKS" usage:"сети CIDR или адреса через запятую, куда разрешена доставка вебхуков несмотря на запрет внутренних адресов (локальный получатель при тестировании)"`
+
+	IdempotencyTTL time.Duration `env:"IDEMPOTENCY_TTL" default:"24h" reload:"true" usage:"сколько хранятся ответы на запросы с Idempotency-Key"`
+
+	// Трассировка OpenTelemetry
+	TracingExporter    string  `env:"TRACING_EXPORTER" default:"none" usage:"экспортёр спанов: none, stdout, file, otlp"`
+	TracingFile        string  `env:"TRACING_FILE" default:"traces.json" usage:"файл для экспортёра file"`
+	TracingSampleRatio float64 `env:"TRACING_SAMPLE_RATIO" default:"1" usage:"доля трассировок, начатых сервисом, от 0 до 1"`
+}
+
+// redacted заменяет значения секретов в выводе конфигурации
+const redacted = "[REDACTED]"
+
+// dsnPassword находит пароль в строке подключения вида key=value
+var dsnPassword = regexp.MustCompile(`password=('(?:[^'\\]|\\.)*'|\S*)`)
+
+// Redacted returns a copy of the configuration with secrets masked, safe for logs and diagnostics
+func (c Config) Redacted() Config {
+	for _, secret := range []*string{&c.DBPassword, &c.AdminAPIKey, &c.JWTHMACSecret} {
+		if *secret != "" {
+			*secret = redacted
+		}
+	}
+	c.DBDSN = redactDSN(c.DBDSN)
+	c.DBReplicaDSN = redactDSN(c.DBReplicaDSN)
+	return c
+}
+
+// redactDSN скрывает пароль в строке подключения вида URL или key=value
+func redactDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.Scheme != "" {
+		return u.Redacted()
+	}
+	return dsnPassword.ReplaceAllString(dsn, "password="+redacted)
+}
+
+// minHMACSecretLength — наименьшая длина JWT_HMAC_SECRET в байтах: 256 бит, как у подписи HS256
+const minHMACSecretLength = 32
+
+// validateConfig проверяет итоговую конфигурацию и сообщает обо всех ошибках сразу
+func validateConfig(config *Config) error {
+	var errs []error
+	check := func(ok bool, message string) {
+		if !ok {
+			errs = append(errs, errors.New(message))
+		}
 	}
 
-	config := &Config{
-		ServerPort:     os.Getenv("SERVER_PORT"),
-		DBHost:         os.Getenv("DB_HOST"),
-		DBPort:         os.Getenv("DB_PORT"),
-		DBUser:         os.Getenv("DB_USER"),
-		DBPassword:     os.Getenv("DB_PASSWORD"),
-		DBName:         os.Getenv("DB_NAME"),
-		DBSSLMode:      os.Getenv("DB_SSLMODE"),
-		ExternalAPIURL: os.Getenv("EXTERNAL_API_URL"),
+	if config.UsesPostgres() {
+		check(config.DBDSN != "" || (config.DBHost != "" && config.DBUser != "" && config.DBName != ""),
+			"DB_DSN or DB_HOST, DB_USER and DB_NAME must be set")
+		check(config.DBDSN != "" || validPort(config.DBPort), "DB_PORT must be a port number")
 	}
+	check(validPort(config.ServerPort), "SERVER_PORT must be a port number")
+	check(config.GRPCPort == "" || validPort(config.GRPCPort), "GRPC_PORT must be a port number or empty")
+	check(config.MetricsPort == "" || validPort(config.MetricsPort), "METRICS_PORT must be a port number or empty")
+	check(config.MetricsPort != config.ServerPort && config.MetricsPort != config.GRPCPort,
+		"METRICS_PORT must differ from SERVER_PORT and GRPC_PORT")
+	check(config.DBMaxOpenConns >= 0, "DB_MAX_OPEN_CONNS must not be negative")
+	check(config.DBMaxIdleConns >= 0, "DB_MAX_IDLE_CONNS must not be negative")
+	if config.ExternalAPIURL != "" {
+		u, err := url.Parse(config.ExternalAPIURL)
+		check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "",
+			"EXTERNAL_API_URL must be an absolute http(s) URL")
+	}
+	check(config.AdminAPIKey == "" || len(config.AdminAPIKey) >= 16, "ADMIN_API_KEY must be at least 16 characters long")
+	// Пустой или короткий секрет HMAC позволил бы подобрать или подделать подпись токена
+	check(config.JWTHMACSecret == "" || len(strings.TrimSpace(config.JWTHMACSecret)) >= minHMACSecretLength,
+		fmt.Sprintf("JWT_HMAC_SECRET must be at least %d bytes long", minHMACSecretLength))
+	check(oneOf(config.LogLevel, "debug", "info", "warn", "error"), "LOG_LEVEL must be debug, info, warn or error")
+	check(oneOf(config.LogFormat, "json", "text"), "LOG_FORMAT must be json or text")
+	check(config.CompressionLevel >= 0 && config.CompressionLevel <= 9, "COMPRESSION_LEVEL must be a number between 0 and 9")
+	check(config.MaxRequestBodyBytes > 0, "MAX_REQUEST_BODY_BYTES must be a positive number")
+	check(config.RequestTimeout < config.HTTPWriteTimeout, "REQUEST_TIMEOUT must be shorter than HTTP_WRITE_TIMEOUT")
+	deprecatedSince, sinceErr := ParseDate(config.APIV1DeprecatedSince)
+	sunset, sunsetErr := ParseDate(config.APIV1Sunset)
+	check(sinceErr == nil && !deprecatedSince.IsZero(), "API_V1_DEPRECATED_SINCE must be a date in YYYY-MM-DD format")
+	check(sunsetErr == nil, "API_V1_SUNSET must be a date in YYYY-MM-DD format")
+	check(sunset.IsZero() || sunset.After(deprecatedSince), "API_V1_SUNSET must be later than API_V1_DEPRECATED_SINCE")
+	check(oneOf(config.StorageBackend, "postgres", "sqlite", "memory"), "STORAGE_BACKEND must be postgres, sqlite or memory")
+	check(config.StorageBackend != "sqlite" || config.SQLitePath != "", "SQLITE_PATH is required when STORAGE_BACKEND=sqlite")
+	check(oneOf(config.RateLimitBackend, "memory", "postgres"), "RATE_LIMIT_BACKEND must be memory or postgres")
+	check(config.RateLimitBackend != "postgres" || config.UsesPostgres(), "RATE_LIMIT_BACKEND=postgres requires STORAGE_BACKEND=postgres")
+	check(config.RateLimitProxyHops >= 1, "RATE_LIMIT_PROXY_HOPS must be a positive number")
+	_, networksErr := webhooks.ParseNetworks(config.WebhookAllowedNetworks)
+	check(networksErr == nil, "WEBHOOK_ALLOWED_NETWORKS must be a comma-separated list of CIDR networks or IP addresses")
+	check(oneOf(config.TracingExporter, "none", "stdout", "file", "otlp"), "TRACING_EXPORTER must be none, stdout, file or otlp")
+	check(config.TracingSampleRatio >= 0 && config.TracingSampleRatio <= 1, "TRACING_SAMPLE_RATIO must be a number between 0 and 1")
+	return errors.Join(errs...)
+}
 
-	if err := validateConfig(config); err != nil {
-		return nil, err
+// UsesPostgres сообщает, нужна ли сервису база PostgreSQL: при STORAGE_BACKEND=memory и sqlite всё
+// хранится в памяти процесса или в файле SQLITE_PATH, а настройки DB_* не используются
+func (c Config) UsesPostgres() bool {
+	return c.StorageBackend == "postgres"
+}
+
+// ParseDate разбирает дату настройки в формате YYYY-MM-DD; пустое значение — нулевое время
+func ParseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
 	}
+	return time.Parse(time.DateOnly, value)
+}
 
-	return config, nil
+// validPort проверяет номер TCP-порта
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n > 0 && n <= 65535
 }
 
-// Validate the configuration to ensure all required fields are set
-func validateConfig(config *Config) error {
-	if config.DBHost == "" || config.DBUser == "" || config.DBPassword == "" || config.DBName == "" {
-		return fmt.Errorf("missing required configuration")
+// oneOf проверяет, что значение — одно из допустимых
+func oneOf(value string, allowed ...string) bool {
+	for _, a := range allowed {
+		if value == a {
+			return true
+		}
 	}
-	return nil
+	return false
 }
@@ -0,0 +1,329 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"music_catalog/internal/ratelimit"
+
+	"github.com/BurntSushi/toml"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv — переменная окружения с путём к файлу конфигурации (флаг --config)
+const FileEnv = "CONFIG_FILE"
+
+// Loader собирает конфигурацию из слоёв по возрастанию приоритета:
+//
+//  1. значения по умолчанию (тег default);
+//  2. файл конфигурации YAML или TOML (--config или CONFIG_FILE): ключи — имена переменных окружения
+//     в нижнем регистре (server_port), вложенные таблицы склеиваются через "_" (db: {host: ...} — db_host);
+//  3. переменные окружения, в том числе из файла .env, если он есть; пустые значения не учитываются;
+//  4. флаги командной строки: имя переменной в нижнем регистре через дефис (--server-port).
+//
+// Loader можно вызывать повторно: каждый вызов заново читает файл и окружение.
+type Loader struct {
+	flags *flag.FlagSet
+	file  *string
+}
+
+// NewLoader регистрирует в flags флаг --config и по флагу на каждую настройку.
+// Разбирает флаги вызывающий, до первого вызова Load.
+func NewLoader(flags *flag.FlagSet) *Loader {
+	l := &Loader{flags: flags}
+	l.file = flags.String("config", "", "файл конфигурации YAML или TOML (по умолчанию из "+FileEnv+")")
+	for _, s := range settings(&Config{}) {
+		value := &flagValue{value: s.fallback}
+		if s.field.Kind() == reflect.Bool {
+			flags.Var(&boolFlagValue{*value}, flagName(s.env), s.usage)
+		} else {
+			flags.Var(value, flagName(s.env), s.usage)
+		}
+	}
+	return l
+}
+
+// File возвращает путь к файлу конфигурации или пустую строку, если файл не задан
+func (l *Loader) File() string {
+	if *l.file != "" {
+		return *l.file
+	}
+	return os.Getenv(FileEnv)
+}
+
+// Load собирает и проверяет конфигурацию
+func (l *Loader) Load() (*Config, error) {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("unable to load .env file: %w", err)
+	}
+
+	config := &Config{}
+	all := settings(config)
+	byEnv := make(map[string]setting, len(all))
+	for _, s := range all {
+		byEnv[s.env] = s
+		if s.fallback != "" {
+			if err := s.set(s.fallback); err != nil {
+				return nil, fmt.Errorf("default %s: %w", s.env, err)
+			}
+		}
+	}
+
+	if path := l.File(); path != "" {
+		values, err := readFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range values {
+			s, ok := byEnv[strings.ToUpper(key)]
+			if !ok {
+				return nil, fmt.Errorf("%s: unknown setting %q", path, key)
+			}
+			if err := s.set(value); err != nil {
+				return nil, fmt.Errorf("%s: %s: %w", path, key, err)
+			}
+		}
+	}
+
+	for _, s := range all {
+		if value := os.Getenv(s.env); value != "" {
+			if err := s.set(value); err != nil {
+				return nil, fmt.Errorf("%s: %w", s.env, err)
+			}
+		}
+	}
+
+	var flagErr error
+	l.flags.Visit(func(f *flag.Flag) {
+		s, ok := byEnv[strings.ToUpper(strings.ReplaceAll(f.Name, "-", "_"))]
+		if !ok || flagErr != nil {
+			return
+		}
+		if err := s.set(f.Value.String()); err != nil {
+			flagErr = fmt.Errorf("--%s: %w", f.Name, err)
+		}
+	})
+	if flagErr != nil {
+		return nil, flagErr
+	}
+
+	if err := validateConfig(config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// Print выводит конфигурацию в формате YAML, пригодном для файла конфигурации;
+// секреты нужно скрыть заранее (Redacted)
+func (c Config) Print(w io.Writer) error {
+	doc := &yaml.Node{Kind: yaml.MappingNode}
+	for _, s := range settings(&c) {
+		comment := s.usage
+		if s.reload {
+			comment += " (применяется без перезапуска)"
+		}
+		key := &yaml.Node{Kind: yaml.ScalarNode, Value: strings.ToLower(s.env), HeadComment: comment}
+		value := &yaml.Node{}
+		value.SetString(s.get())
+		doc.Content = append(doc.Content, key, value)
+	}
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// setting — настраиваемое поле Config
+type setting struct {
+	env      string
+	fallback string
+	usage    string
+	reload   bool
+	field    reflect.Value
+}
+
+// settings перечисляет поля config с тегом env в порядке объявления
+func settings(config *Config) []setting {
+	v := reflect.ValueOf(config).Elem()
+	var result []setting
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		env, ok := field.Tag.Lookup("env")
+		if !ok {
+			continue
+		}
+		result = append(result, setting{
+			env:      env,
+			fallback: field.Tag.Get("default"),
+			usage:    field.Tag.Get("usage"),
+			reload:   field.Tag.Get("reload") == "true",
+			field:    v.Field(i),
+		})
+	}
+	return result
+}
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	quotaType    = reflect.TypeOf(ratelimit.Quota{})
+)
+
+// set разбирает значение в поле настройки
+func (s setting) set(value string) error {
+	switch s.field.Type() {
+	case durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid duration %q", value)
+		}
+		s.field.SetInt(int64(d))
+		return nil
+	case quotaType:
+		q, err := ratelimit.ParseQuota(value)
+		if err != nil {
+			return err
+		}
+		s.field.Set(reflect.ValueOf(q))
+		return nil
+	}
+
+	switch s.field.Kind() {
+	case reflect.String:
+		s.field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", value)
+		}
+		s.field.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+		s.field.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", value)
+		}
+		s.field.SetFloat(f)
+	case reflect.Slice:
+		s.field.Set(reflect.ValueOf(splitList(value)))
+	default:
+		return fmt.Errorf("unsupported setting type %s", s.field.Type())
+	}
+	return nil
+}
+
+// get возвращает значение поля в том виде, в котором его принимает set
+func (s setting) get() string {
+	switch value := s.field.Interface().(type) {
+	case []string:
+		return strings.Join(value, ",")
+	case fmt.Stringer:
+		return value.String()
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// flagValue хранит значение флага настройки строкой: разбирается оно вместе с остальными слоями в Load
+type flagValue struct {
+	value string
+}
+
+// String implements flag.Value
+func (f *flagValue) String() string {
+	return f.value
+}
+
+// Set implements flag.Value
+func (f *flagValue) Set(value string) error {
+	f.value = value
+	return nil
+}
+
+// boolFlagValue — флаг логической настройки: --auth-disabled без значения означает true
+type boolFlagValue struct {
+	flagValue
+}
+
+// IsBoolFlag implements the optional flag interface for boolean flags
+func (*boolFlagValue) IsBoolFlag() bool {
+	return true
+}
+
+// flagName возвращает имя флага настройки: DB_HOST — db-host
+func flagName(env string) string {
+	return strings.ToLower(strings.ReplaceAll(env, "_", "-"))
+}
+
+// readFile читает файл конфигурации и возвращает плоский набор значений по ключам
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read config file: %w", err)
+	}
+	var tree map[string]any
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &tree)
+	case ".toml":
+		err = toml.Unmarshal(data, &tree)
+	default:
+		return nil, fmt.Errorf("%s: unsupported config file format, use .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	values := make(map[string]string)
+	flatten("", tree, values)
+	return values, nil
+}
+
+// flatten склеивает ключи вложенных таблиц через "_"; списки превращаются в значения через запятую
+func flatten(prefix string, tree map[string]any, values map[string]string) {
+	for key, value := range tree {
+		if prefix != "" {
+			key = prefix + "_" + key
+		}
+		switch value := value.(type) {
+		case map[string]any:
+			flatten(key, value, values)
+		case []any:
+			items := make([]string, len(value))
+			for i, item := range value {
+				items[i] = fmt.Sprint(item)
+			}
+			values[key] = strings.Join(items, ",")
+		case nil:
+			values[key] = ""
+		default:
+			values[key] = fmt.Sprint(value)
+		}
+	}
+}
+
+// splitList разбирает список значений через запятую, пропуская пустые
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
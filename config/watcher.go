@@ -0,0 +1,175 @@
+package config
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"music_catalog/internal/logger"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDebounce — пауза после изменения файла перед перезагрузкой: редакторы сохраняют файл
+// несколькими операциями подряд
+const reloadDebounce = 200 * time.Millisecond
+
+// Status — состояние перезагрузок конфигурации для диагностики
+type Status struct {
+	Generation     int64     `json:"generation"`                // 1 — конфигурация при запуске, далее +1 на каждую применённую перезагрузку
+	LoadedAt       time.Time `json:"loaded_at"`                 // когда применена текущая версия
+	PendingRestart []string  `json:"pending_restart,omitempty"` // изменённые настройки, которые вступят в силу после перезапуска
+	LastError      string    `json:"last_error,omitempty"`      // ошибка последней перезагрузки; текущая версия при этом не меняется
+}
+
+// Watcher перечитывает конфигурацию при изменении файла конфигурации и по сигналу SIGHUP.
+// Новая конфигурация проверяется целиком; настройки с тегом reload применяются к работающему
+// сервису обработчиками OnReload, остальные изменения только перечисляются в Status.PendingRestart.
+type Watcher struct {
+	loader   *Loader
+	logger   logger.Logger
+	current  atomic.Pointer[Config]
+	appliers []func(config *Config)
+
+	mu     sync.Mutex // перезагрузки выполняются по одной
+	status Status
+}
+
+// NewWatcher creates a Watcher starting from the configuration loaded at startup
+func NewWatcher(loader *Loader, config *Config, logger logger.Logger) *Watcher {
+	w := &Watcher{loader: loader, logger: logger, status: Status{Generation: 1, LoadedAt: time.Now()}}
+	w.current.Store(config)
+	return w
+}
+
+// OnReload регистрирует обработчик, применяющий новую конфигурацию к компоненту; вызывается
+// после каждой перезагрузки, изменившей настройки с тегом reload. Регистрируется до Run
+func (w *Watcher) OnReload(apply func(config *Config)) *Watcher {
+	w.appliers = append(w.appliers, apply)
+	return w
+}
+
+// Current возвращает действующую конфигурацию; её нельзя изменять
+func (w *Watcher) Current() *Config {
+	return w.current.Load()
+}
+
+// Status возвращает номер версии конфигурации и результат последней перезагрузки
+func (w *Watcher) Status() Status {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.status
+}
+
+// Reload перечитывает конфигурацию и применяет изменившиеся настройки с тегом reload.
+// При ошибке загрузки или проверки действующая конфигурация не меняется.
+func (w *Watcher) Reload() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	loaded, err := w.loader.Load()
+	if err != nil {
+		w.status.LastError = err.Error()
+		return err
+	}
+
+	current := w.Current()
+	next := *current
+	var applied, pending []string
+	nextSettings := settings(&next)
+	loadedSettings := settings(loaded)
+	for i, s := range settings(current) {
+		if s.get() == loadedSettings[i].get() {
+			continue
+		}
+		if !s.reload {
+			pending = append(pending, s.env)
+			continue
+		}
+		nextSettings[i].field.Set(loadedSettings[i].field)
+		applied = append(applied, s.env)
+	}
+	// Применяемые настройки проверяются вместе с прежними значениями остальных
+	if err := validateConfig(&next); err != nil {
+		w.status.LastError = err.Error()
+		return err
+	}
+
+	w.status.LastError = ""
+	w.status.PendingRestart = pending
+	if len(pending) > 0 {
+		w.logger.Info("Configuration changes require a restart:", pending)
+	}
+	if len(applied) == 0 {
+		w.logger.Debug("Configuration reloaded without changes to apply")
+		return nil
+	}
+
+	w.current.Store(&next)
+	for _, apply := range w.appliers {
+		apply(&next)
+	}
+	w.status.Generation++
+	w.status.LoadedAt = time.Now()
+	w.logger.Info("Configuration reloaded, generation", w.status.Generation, "applied:", applied)
+	return nil
+}
+
+// Run перезагружает конфигурацию по SIGHUP и при изменении файла конфигурации, пока не отменён ctx
+func (w *Watcher) Run(ctx context.Context) error {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	var (
+		events <-chan fsnotify.Event
+		errs   <-chan error
+		path   = w.loader.File()
+	)
+	if path != "" {
+		files, err := fsnotify.NewWatcher()
+		if err != nil {
+			return err
+		}
+		defer files.Close()
+		// Следим за каталогом, а не за файлом: редакторы и ConfigMap в Kubernetes
+		// заменяют файл переименованием, и наблюдение за самим файлом потерялось бы
+		if err := files.Add(filepath.Dir(path)); err != nil {
+			return err
+		}
+		events, errs = files.Events, files.Errors
+	}
+
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hangup:
+			w.reload("SIGHUP")
+		case event := <-events:
+			// ..data — символическая ссылка, которую Kubernetes переключает при обновлении ConfigMap
+			if name := filepath.Base(event.Name); name == filepath.Base(path) || name == "..data" {
+				debounce = time.After(reloadDebounce)
+			}
+		case <-debounce:
+			debounce = nil
+			w.reload("config file change")
+		case err := <-errs:
+			w.logger.Error("Config file watcher error:", err)
+		}
+	}
+}
+
+// reload перезагружает конфигурацию и записывает ошибку в журнал
+func (w *Watcher) reload(reason string) {
+	w.logger.Info("Reloading configuration on", reason)
+	if err := w.Reload(); err != nil {
+		w.logger.Error("Configuration reload failed, keeping the current configuration:", err)
+	}
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"music_catalog/internal/auth"
+	"music_catalog/internal/logger"
+	"music_catalog/internal/models"
+	"music_catalog/internal/validation"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// APIKeyService интерфейс для выпуска и отзыва API-ключей
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, name string, role string, tenantSlug string) (models.APIKey, error)
+	GetAPIKeys(ctx context.Context, pagination models.Pagination) ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id int) error
+}
+
+// APIKeysHandler обрабатывает запросы управления API-ключами; ответы в формате конвертов v2
+type APIKeysHandler struct {
+	apiKeyService APIKeyService
+	logger        logger.Logger
+}
+
+// NewAPIKeysHandler creates a new APIKeysHandler with the provided api key service
+func NewAPIKeysHandler(apiKeyService APIKeyService, logger logger.Logger) *APIKeysHandler {
+	return &APIKeysHandler{apiKeyService: apiKeyService, logger: logger}
+}
+
+// APIKeyRequest — тело запроса на выпуск ключа
+type APIKeyRequest struct {
+	Name string `json:"name" example:"partner-sync"`
+	Role string `json:"role" example:"viewer"`
+	// Арендатор ключа; пусто — ключ уровня платформы. Ключи администратора арендатора всегда привязаны к нему
+	Tenant string `json:"tenant,omitempty" example:"acme-records"`
+}
+
+// APIKeyEnvelope — ответ с одним ключом
+type APIKeyEnvelope struct {
+	Data models.APIKey `json:"data"`
+}
+
+// APIKeyListEnvelope — ответ со списком ключей
+type APIKeyListEnvelope struct {
+	Data []models.APIKey `json:"data"`
+	Meta ListMeta        `json:"meta"`
+}
+
+// PrincipalEnvelope — ответ с описанием текущего клиента
+type PrincipalEnvelope struct {
+	Data auth.Principal `json:"data"`
+}
+
+// RegisterRoutes регистрирует маршруты управления API-ключами
+func (h *APIKeysHandler) RegisterRoutes(r chi.Router) {
+	r.Post("/api-keys", h.CreateAPIKey)
+	r.Get("/api-keys", h.GetAPIKeys)
+	r.Delete("/api-keys/{id}", h.RevokeAPIKey)
+}
+
+// CreateAPIKey issues a new key (POST /api-keys). The plaintext key is returned only in this response.
+func (h *APIKeysHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	var requestBody APIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		respondMalformedBody(h.logger, w, r, err)
+		return
+	}
+
+	key, err := h.apiKeyService.CreateAPIKey(r.Context(), requestBody.Name, requestBody.Role, requestBody.Tenant)
+	if err != nil {
+		respondProblem(h.logger, w, r, "Error creating api key:", err)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("%s/%d", r.URL.Path, key.ID))
+	writeJSON(w, http.StatusCreated, APIKeyEnvelope{Data: key})
+}
+
+// GetAPIKeys lists issued keys without their values (GET /api-keys)
+func (h *APIKeysHandler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	v := validation.New()
+	pagination := parsePagination(r.URL.Query(), v)
+	if err := v.Err(); err != nil {
+		respondProblem(h.logger, w, r, "Error parsing request parameters:", err)
+		return
+	}
+	if pagination.Limit == 0 {
+		pagination.Limit = 10
+	}
+
+	keys, err := h.apiKeyService.GetAPIKeys(r.Context(), pagination)
+	if err != nil {
+		respondProblem(h.logger, w, r, "Error getting api keys:", err)
+		return
+	}
+	if keys == nil {
+		keys = []models.APIKey{}
+	}
+
+	writeJSON(w, http.StatusOK, APIKeyListEnvelope{
+		Data: keys,
+		Meta: ListMeta{Limit: pagination.Limit, Offset: pagination.Offset, Count: len(keys)},
+	})
+}
+
+// RevokeAPIKey revokes a key (DELETE /api-keys/{id})
+func (h *APIKeysHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		respondProblem(h.logger, w, r, "Invalid api key ID:", err)
+		return
+	}
+
+	if err := h.apiKeyService.RevokeAPIKey(r.Context(), id); err != nil {
+		respondProblem(h.logger, w, r, "Error revoking api key:", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// WhoAmI returns the authenticated principal (GET /whoami)
+func (h *APIKeysHandler) WhoAmI(w http.ResponseWriter, r *http.Request) {
+	principal, _ := auth.FromContext(r.Context())
+	writeJSON(w, http.StatusOK, PrincipalEnvelope{Data: principal})
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"music_catalog/internal/auth"
+	"music_catalog/internal/logger"
+)
+
+// APIKeyHeader — заголовок с API-ключом; альтернатива "Authorization: Bearer <key>"
+const APIKeyHeader = "X-API-Key"
+
+// authenticate определяет клиента по заголовкам запроса и кладёт его в контекст.
+// Запросы без учётных данных проходят дальше анонимно — отказ выдаёт requireRole,
+// поэтому публичные маршруты (Swagger UI) не требуют ключа. Неверные учётные данные отклоняются сразу.
+func authenticate(authenticator *auth.Authenticator, logger logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			credential := credentialFromRequest(r)
+			if credential == "" && !authenticator.Disabled() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			principal, err := authenticator.Authenticate(r.Context(), credential)
+			if err != nil {
+				respondProblem(logger, w, r, "Authentication failed:", err)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+		})
+	}
+}
+
+// requireRole пропускает только клиентов с ролью не ниже role
+func requireRole(role auth.Role, logger logger.Logger) func(http.Handler) http.Handler {
+	return requireRoleByMethod(role, role, logger)
+}
+
+// requireRoleByMethod требует роль read для безопасных методов (GET, HEAD, OPTIONS) и роль write для остальных
+func requireRoleByMethod(read auth.Role, write auth.Role, logger logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			required := write
+			switch r.Method {
+			case http.MethodGet, http.MethodHead, http.MethodOptions:
+				required = read
+			}
+			if err := auth.Authorize(r.Context(), required); err != nil {
+				respondProblem(logger, w, r, "Access denied for "+auth.Actor(r.Context())+":", err)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// credentialFromRequest извлекает API-ключ или JWT из заголовков Authorization / X-API-Key.
+// Браузерные EventSource и WebSocket не умеют передавать заголовки, поэтому для потоков
+// событий учётные данные принимаются и в параметре access_token.
+func credentialFromRequest(r *http.Request) string {
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, credential, _ := strings.Cut(header, " ")
+		if strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(credential)
+		}
+	}
+	if key := r.Header.Get(APIKeyHeader); key != "" {
+		return key
+	}
+	if r.Method == http.MethodGet && (strings.Contains(r.Header.Get("Accept"), "text/event-stream") ||
+		strings.EqualFold(r.Header.Get("Upgrade"), "websocket")) {
+		return r.URL.Query().Get("access_token")
+	}
+	return ""
+}
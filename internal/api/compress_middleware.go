@@ -0,0 +1,33 @@
+package api
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/andybalholm/brotli"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// compressibleTypes — типы ответов, которые сжимаются; потоки событий (text/event-stream)
+// не сжимаются, чтобы события доходили до клиента сразу
+var compressibleTypes = []string{
+	"application/json",
+	problemContentType,
+	"text/html",
+	"text/css",
+	"text/plain",
+	"text/javascript",
+	"application/javascript",
+	"image/svg+xml",
+}
+
+// compress сжимает ответы br или gzip (deflate — для старых клиентов) в зависимости
+// от Accept-Encoding клиента; level — степень сжатия от 1 до 9, общая для всех алгоритмов
+func compress(level int) func(http.Handler) http.Handler {
+	compressor := middleware.NewCompressor(level, compressibleTypes...)
+	// Кодировщик, добавленный последним, имеет наивысший приоритет
+	compressor.SetEncoder("br", func(w io.Writer, level int) io.Writer {
+		return brotli.NewWriterLevel(w, level)
+	})
+	return compressor.Handler
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"net/http"
+
+	"music_catalog/internal/idempotency"
+	"music_catalog/internal/tenant"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/cors"
+)
+
+// corsPolicy применяет текущую политику CORS (см. SetCORSOrigins); при выключенном CORS
+// запрос проходит без изменений
+func (api *RestSongAPI) corsPolicy(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if policy := api.cors.Load(); policy != nil {
+			policy.Handler(next).ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// newCORS разрешает браузерному клиенту с перечисленных источников (* — с любых) обращаться
+// к API; для пустого списка возвращает nil. Учётные данные передаются заголовками Authorization
+// и X-API-Key, а не cookie, поэтому Access-Control-Allow-Credentials не нужен.
+func newCORS(origins []string) *cors.Cors {
+	if len(origins) == 0 {
+		return nil
+	}
+	return cors.New(cors.Options{
+		AllowedOrigins: origins,
+		AllowedMethods: []string{
+			http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+		},
+		AllowedHeaders: []string{
+			"Accept", "Authorization", "Content-Type", "Last-Event-ID", "traceparent", "tracestate",
+			APIKeyHeader, tenant.Header, idempotency.Header, middleware.RequestIDHeader,
+		},
+		ExposedHeaders: []string{
+			"Location", "Link", "Deprecation", "Sunset", "Retry-After", "WWW-Authenticate",
+			"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy",
+			idempotency.ReplayedHeader, middleware.RequestIDHeader,
+		},
+		MaxAge: 600,
+	})
+}
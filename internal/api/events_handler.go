@@ -0,0 +1,385 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"music_catalog/internal/events"
+	"music_catalog/internal/logger"
+	"music_catalog/internal/tenant"
+	"music_catalog/internal/validation"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	sseHeartbeatInterval = 15 * time.Second
+	sseRetry             = 3 * time.Second
+	wsPingInterval       = 30 * time.Second
+	wsPongWait           = 2 * wsPingInterval
+	wsWriteWait          = 10 * time.Second
+)
+
+// EventBroker — источник потока изменений каталога
+type EventBroker interface {
+	Subscribe(ctx context.Context, filter events.Filter) (*events.Subscription, error)
+	Unsubscribe(sub *events.Subscription)
+	Replay(ctx context.Context, afterID int64, filter events.Filter) (matched []events.Event, lastID int64, done bool, err error)
+}
+
+// EventsHandler отдаёт поток изменений каталога через Server-Sent Events и WebSocket
+type EventsHandler struct {
+	broker   EventBroker
+	logger   logger.Logger
+	upgrader websocket.Upgrader
+
+	// closing закрывается при остановке сервера: бесконечные потоки не дают ему дождаться запросов
+	closing   chan struct{}
+	closeOnce sync.Once
+}
+
+// NewEventsHandler creates a new EventsHandler reading events from the broker
+func NewEventsHandler(broker EventBroker, logger logger.Logger) *EventsHandler {
+	return &EventsHandler{
+		broker:  broker,
+		logger:  logger,
+		closing: make(chan struct{}),
+	}
+}
+
+// Shutdown завершает открытые потоки SSE и WebSocket; клиенты переподключатся
+// к другому экземпляру с Last-Event-ID. Регистрируется через http.Server.RegisterOnShutdown.
+func (h *EventsHandler) Shutdown() {
+	h.closeOnce.Do(func() { close(h.closing) })
+}
+
+// StreamEvents streams catalog changes as Server-Sent Events (GET /events).
+// Query parameters types, group and song_id filter the stream; reconnecting clients
+// resume after the Last-Event-ID header (or the last_event_id query parameter).
+func (h *EventsHandler) StreamEvents(w http.ResponseWriter, r *http.Request) {
+	filter, lastEventID, err := parseEventsRequest(r)
+	if err != nil {
+		h.logger.WithContext(r.Context()).Info("Invalid events request:", err)
+		writeProblem(w, r, problemFromError(err))
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeProblem(w, r, Problem{Type: ProblemTypeInternal, Title: "Streaming unsupported", Status: http.StatusInternalServerError})
+		return
+	}
+
+	// Поток не ограничен по времени: снимаем таймауты чтения и записи сервера для этого запроса
+	controller := http.NewResponseController(w)
+	controller.SetReadDeadline(time.Time{})
+	controller.SetWriteDeadline(time.Time{})
+
+	// Подписываемся до чтения журнала, чтобы не потерять события между ними
+	sub, err := h.broker.Subscribe(r.Context(), filter)
+	if err != nil {
+		h.logger.WithContext(r.Context()).Error("Error subscribing to events:", err)
+		writeProblem(w, r, problemFromError(err))
+		return
+	}
+	defer h.broker.Unsubscribe(sub)
+
+	// Хвост журнала читается постранично; первая страница — до ответа, чтобы сбой журнала вернулся ошибкой
+	var backlog []events.Event
+	replayedID, replayed := lastEventID, lastEventID == 0
+	if !replayed {
+		backlog, replayedID, replayed, err = h.broker.Replay(r.Context(), replayedID, filter)
+		if err != nil {
+			h.logger.WithContext(r.Context()).Error("Error replaying events:", err)
+			writeProblem(w, r, problemFromError(err))
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", sseRetry.Milliseconds())
+
+	sent := lastEventID
+	for {
+		for _, event := range backlog {
+			writeSSEEvent(w, event)
+			sent = event.ID
+		}
+		flusher.Flush()
+		if replayed {
+			break
+		}
+		backlog, replayedID, replayed, err = h.broker.Replay(r.Context(), replayedID, filter)
+		if err != nil {
+			// Ответ уже начат: клиент переподключится с Last-Event-ID последнего отправленного события
+			h.logger.WithContext(r.Context()).Error("Error replaying events:", err)
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-h.closing:
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if event.ID <= sent {
+				continue
+			}
+			writeSSEEvent(w, event)
+			sent = event.ID
+			flusher.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+func writeSSEEvent(w http.ResponseWriter, event events.Event) {
+	data, _ := json.Marshal(event)
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+}
+
+// wsClientMessage — управляющее сообщение клиента WebSocket
+type wsClientMessage struct {
+	Action      string        `json:"action"` // subscribe | unsubscribe
+	Filter      events.Filter `json:"filter"`
+	LastEventID int64         `json:"last_event_id"`
+}
+
+// wsServerMessage — сообщение сервера WebSocket
+type wsServerMessage struct {
+	Kind    string         `json:"kind"` // event | subscribed | unsubscribed | error
+	Event   *events.Event  `json:"event,omitempty"`
+	Filter  *events.Filter `json:"filter,omitempty"`
+	Message string         `json:"message,omitempty"`
+}
+
+// StreamEventsWebSocket streams catalog changes over a WebSocket connection (GET /events/ws).
+// The initial subscription uses the same query filter as StreamEvents; the client changes it with
+// {"action":"subscribe","filter":{"group":"Muse","types":["song.created"]},"last_event_id":0}
+// and pauses it with {"action":"unsubscribe"}.
+func (h *EventsHandler) StreamEventsWebSocket(w http.ResponseWriter, r *http.Request) {
+	filter, lastEventID, err := parseEventsRequest(r)
+	if err != nil {
+		h.logger.WithContext(r.Context()).Info("Invalid events request:", err)
+		writeProblem(w, r, problemFromError(err))
+		return
+	}
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		h.logger.WithContext(r.Context()).Info("WebSocket upgrade failed:", err)
+		return
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	// Чтение управляющих сообщений в отдельной горутине; запись — только из основного цикла
+	commands := make(chan wsClientMessage)
+	go func() {
+		defer cancel()
+		conn.SetReadLimit(4096)
+		conn.SetReadDeadline(time.Now().Add(wsPongWait))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+		})
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			var msg wsClientMessage
+			if err := json.Unmarshal(data, &msg); err != nil {
+				msg = wsClientMessage{Action: "invalid"}
+			}
+			select {
+			case commands <- msg:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	write := func(msg wsServerMessage) bool {
+		conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+		return conn.WriteJSON(msg) == nil
+	}
+
+	var sub *events.Subscription
+	var sent int64
+	subscribe := func(filter events.Filter, afterID int64) bool {
+		if sub != nil {
+			h.broker.Unsubscribe(sub)
+		}
+		filter.TenantID = tenant.IDFromContext(ctx)
+		var err error
+		if sub, err = h.broker.Subscribe(ctx, filter); err != nil {
+			h.logger.WithContext(r.Context()).Error("Error subscribing to events:", err)
+			return write(wsServerMessage{Kind: "error", Message: "failed to subscribe"})
+		}
+		if !write(wsServerMessage{Kind: "subscribed", Filter: &filter}) {
+			return false
+		}
+		sent = afterID
+		for replayed := afterID == 0; !replayed; {
+			var backlog []events.Event
+			backlog, afterID, replayed, err = h.broker.Replay(ctx, afterID, filter)
+			if err != nil {
+				h.logger.WithContext(r.Context()).Error("Error replaying events:", err)
+				return write(wsServerMessage{Kind: "error", Message: "failed to replay events"})
+			}
+			for i := range backlog {
+				if !write(wsServerMessage{Kind: "event", Event: &backlog[i]}) {
+					return false
+				}
+				sent = backlog[i].ID
+			}
+		}
+		return true
+	}
+	defer func() {
+		if sub != nil {
+			h.broker.Unsubscribe(sub)
+		}
+	}()
+
+	if !subscribe(filter, lastEventID) {
+		return
+	}
+
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+
+	for {
+		var eventsC <-chan events.Event
+		if sub != nil {
+			eventsC = sub.C
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-h.closing:
+			conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down"))
+			return
+		case msg := <-commands:
+			switch msg.Action {
+			case "subscribe":
+				if err := validateEventFilter(&msg.Filter); err != nil {
+					if !write(wsServerMessage{Kind: "error", Message: err.Error()}) {
+						return
+					}
+					continue
+				}
+				if !subscribe(msg.Filter, msg.LastEventID) {
+					return
+				}
+			case "unsubscribe":
+				if sub != nil {
+					h.broker.Unsubscribe(sub)
+					sub = nil
+				}
+				if !write(wsServerMessage{Kind: "unsubscribed"}) {
+					return
+				}
+			default:
+				if !write(wsServerMessage{Kind: "error", Message: "unknown action, expected subscribe or unsubscribe"}) {
+					return
+				}
+			}
+		case event, ok := <-eventsC:
+			if !ok {
+				// Подписчик отключён брокером из-за переполнения — клиент переподключится с last_event_id
+				write(wsServerMessage{Kind: "error", Message: "subscriber lagged behind, resubscribe with last_event_id"})
+				return
+			}
+			if event.ID <= sent {
+				continue
+			}
+			if !write(wsServerMessage{Kind: "event", Event: &event}) {
+				return
+			}
+			sent = event.ID
+		case <-ping.C:
+			conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// parseEventsRequest извлекает фильтр и точку возобновления из запроса
+func parseEventsRequest(r *http.Request) (events.Filter, int64, error) {
+	query := r.URL.Query()
+	filter := events.Filter{Group: query.Get("group"), TenantID: tenant.IDFromContext(r.Context())}
+	v := validation.New()
+	v.Check("group", &filter.Group, validation.FilterText)
+
+	if types := query.Get("types"); types != "" {
+		filter.Types = strings.Split(types, ",")
+	}
+	if songID := query.Get("song_id"); songID != "" {
+		id, err := strconv.Atoi(songID)
+		if err != nil || id < 1 {
+			v.AddError("song_id", "must be a positive integer")
+		}
+		filter.SongID = id
+	}
+
+	var lastEventID int64
+	lastEventIDStr := r.Header.Get("Last-Event-ID")
+	if lastEventIDStr == "" {
+		lastEventIDStr = query.Get("last_event_id")
+	}
+	if lastEventIDStr != "" {
+		id, err := strconv.ParseInt(lastEventIDStr, 10, 64)
+		if err != nil || id < 0 {
+			v.AddError("last_event_id", "must be a non-negative integer")
+		}
+		lastEventID = id
+	}
+
+	checkEventTypes(v, filter.Types)
+	return filter, lastEventID, v.Err()
+}
+
+// validateEventFilter проверяет фильтр из управляющего сообщения WebSocket
+func validateEventFilter(filter *events.Filter) error {
+	v := validation.New()
+	v.Check("group", &filter.Group, validation.FilterText)
+	checkEventTypes(v, filter.Types)
+	return v.Err()
+}
+
+// checkEventTypes проверяет, что фильтр ссылается только на известные типы событий
+func checkEventTypes(v *validation.Validator, types []string) {
+	for _, t := range types {
+		switch t {
+		case events.SongCreated, events.SongUpdated, events.SongDeleted:
+		default:
+			v.AddError("types", fmt.Sprintf("unknown event type %q", t))
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package graphql_api
+
+import (
+	"errors"
+
+	catalog_errors "music_catalog/internal/errors"
+)
+
+// Error — ошибка резолвера с кодом в extensions, по которому клиент может её распознать
+type Error struct {
+	message    string
+	extensions map[string]interface{}
+}
+
+func (e *Error) Error() string {
+	return e.message
+}
+
+// Extensions реализует gqlerrors.ExtendedError
+func (e *Error) Extensions() map[string]interface{} {
+	return e.extensions
+}
+
+// toGraphQLError сопоставляет ошибку сервисного слоя с кодом GraphQL-ошибки
+func toGraphQLError(err error) *Error {
+	var validationErr *catalog_errors.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return &Error{
+			message:    "request validation failed",
+			extensions: map[string]interface{}{"code": "BAD_USER_INPUT", "errors": validationErr.Errors},
+		}
+	case errors.Is(err, catalog_errors.ErrSongNotFound):
+		return &Error{message: "song not found", extensions: map[string]interface{}{"code": "NOT_FOUND"}}
+	case errors.Is(err, catalog_errors.ErrSongExists):
+		return &Error{message: "song already exists", extensions: map[string]interface{}{"code": "CONFLICT"}}
+	case errors.Is(err, catalog_errors.ErrInvalidPage):
+		return &Error{message: "invalid page number", extensions: map[string]interface{}{"code": "BAD_USER_INPUT"}}
+	case errors.Is(err, catalog_errors.ErrUnauthenticated):
+		return &Error{message: "authentication required", extensions: map[string]interface{}{"code": "UNAUTHENTICATED"}}
+	case errors.Is(err, catalog_errors.ErrForbidden):
+		return &Error{message: "permission denied", extensions: map[string]interface{}{"code": "FORBIDDEN"}}
+	case errors.Is(err, catalog_errors.ErrRateLimited):
+		return &Error{message: err.Error(), extensions: map[string]interface{}{"code": "RATE_LIMITED"}}
+	case errors.Is(err, catalog_errors.ErrUpstream):
+		return &Error{message: "external api failure", extensions: map[string]interface{}{"code": "UPSTREAM_FAILURE"}}
+	default:
+		return &Error{message: "internal server error", extensions: map[string]interface{}{"code": "INTERNAL"}}
+	}
+}
@@ -0,0 +1,117 @@
+// Package graphql_api implements the GraphQL endpoint of the music catalog
+package graphql_api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"music_catalog/internal/logger"
+	"music_catalog/internal/ratelimit"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// Handler обрабатывает GraphQL-запросы (POST с JSON-телом или GET с параметром query; мутации — только POST)
+type Handler struct {
+	schema       graphql.Schema
+	musicService MusicService
+	limits       Limits
+	logger       logger.Logger
+}
+
+// NewHandler creates a new GraphQL Handler over the provided music service.
+// The limiter charges addSong to the enrich quota; nil disables the check.
+func NewHandler(musicService MusicService, limits Limits, limiter *ratelimit.Limiter, logger logger.Logger) (*Handler, error) {
+	schema, err := newSchema(musicService, limiter, logger)
+	if err != nil {
+		return nil, err
+	}
+	return &Handler{
+		schema:       schema,
+		musicService: musicService,
+		limits:       limits,
+		logger:       logger,
+	}, nil
+}
+
+// request — тело GraphQL-запроса
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req request
+	switch r.Method {
+	case http.MethodGet:
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+		if variables := r.URL.Query().Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				h.writeErrors(w, http.StatusBadRequest, gqlerrors.NewFormattedError("invalid variables: "+err.Error()))
+				return
+			}
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.writeErrors(w, http.StatusBadRequest, gqlerrors.NewFormattedError("malformed request body: "+err.Error()))
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		h.writeErrors(w, http.StatusMethodNotAllowed, gqlerrors.NewFormattedError("method not allowed"))
+		return
+	}
+
+	h.logger.WithContext(r.Context()).Debug("GraphQL request:", req.OperationName)
+
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"})})
+	if err != nil {
+		h.writeErrors(w, http.StatusBadRequest, gqlerrors.FormatError(err))
+		return
+	}
+
+	validation := graphql.ValidateDocument(&h.schema, doc, nil)
+	if !validation.IsValid {
+		h.writeErrors(w, http.StatusBadRequest, validation.Errors...)
+		return
+	}
+
+	// Мутация через GET обошла бы квоты записи и могла бы быть закэширована или выполнена при предзагрузке
+	if r.Method == http.MethodGet {
+		if operation := selectOperation(doc, req.OperationName); operation != nil && operation.Operation == ast.OperationTypeMutation {
+			w.Header().Set("Allow", "POST")
+			h.writeErrors(w, http.StatusMethodNotAllowed, gqlerrors.NewFormattedError("mutations must be sent with POST"))
+			return
+		}
+	}
+
+	if err := checkLimits(doc, req.OperationName, req.Variables, h.limits); err != nil {
+		h.writeErrors(w, http.StatusBadRequest, gqlerrors.NewFormattedError(err.Error()))
+		return
+	}
+
+	// Загрузчики создаются на каждый запрос, чтобы кэш не переживал его
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema:        h.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       withLoaders(r.Context(), newLoaders(h.musicService)),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// writeErrors отправляет ответ, состоящий только из ошибок
+func (h *Handler) writeErrors(w http.ResponseWriter, status int, errs ...gqlerrors.FormattedError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(graphql.Result{Errors: errs})
+}
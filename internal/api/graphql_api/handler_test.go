@@ -0,0 +1,125 @@
+package graphql_api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"music_catalog/internal/auth"
+	catalog_errors "music_catalog/internal/errors"
+	"music_catalog/internal/logger"
+	"music_catalog/internal/models"
+	"music_catalog/internal/ratelimit"
+)
+
+// emptyCatalog — каталог без песен: удалять в нём нечего
+type emptyCatalog struct {
+	MusicService
+}
+
+func (emptyCatalog) DeleteSong(ctx context.Context, id int) error {
+	return catalog_errors.ErrSongNotFound
+}
+
+func (emptyCatalog) AddSong(ctx context.Context, group string, title string) (models.Song, error) {
+	return models.Song{ID: 1, Group: group, Title: title}, nil
+}
+
+func TestHandler(t *testing.T) {
+	handler, err := NewHandler(emptyCatalog{}, DefaultLimits, nil, logger.NewLogger("error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const mutation = `mutation { deleteSong(id: 42) }`
+
+	tests := []struct {
+		name       string
+		method     string
+		query      string
+		operation  string
+		wantStatus int
+		wantAllow  string
+		wantCode   string
+	}{
+		{"query over GET", http.MethodGet, `{ __typename }`, "", http.StatusOK, "", ""},
+		{"mutation over GET", http.MethodGet, mutation, "", http.StatusMethodNotAllowed, "POST", ""},
+		{"named mutation over GET", http.MethodGet, `query Q { __typename } mutation M { deleteSong(id: 42) }`, "M", http.StatusMethodNotAllowed, "POST", ""},
+		{"missing song over POST", http.MethodPost, mutation, "", http.StatusOK, "", "NOT_FOUND"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var r *http.Request
+			if tt.method == http.MethodGet {
+				params := url.Values{"query": {tt.query}, "operationName": {tt.operation}}
+				r = httptest.NewRequest(http.MethodGet, "/graphql?"+params.Encode(), nil)
+			} else {
+				body, _ := json.Marshal(request{Query: tt.query, OperationName: tt.operation})
+				r = httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+			}
+			r = r.WithContext(auth.WithPrincipal(r.Context(), auth.Principal{ID: "test", Role: auth.RoleEditor}))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if got := w.Header().Get("Allow"); got != tt.wantAllow {
+				t.Errorf("Allow = %q, want %q", got, tt.wantAllow)
+			}
+			if tt.wantCode == "" {
+				return
+			}
+			var result struct {
+				Errors []struct {
+					Extensions map[string]interface{} `json:"extensions"`
+				} `json:"errors"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Errors) != 1 || result.Errors[0].Extensions["code"] != tt.wantCode {
+				t.Errorf("errors = %+v, want one with code %s", result.Errors, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestAddSongEnrichQuota(t *testing.T) {
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), ratelimit.Quotas{
+		ratelimit.ClassEnrich: {Limit: 1, Period: time.Minute},
+	})
+	handler, err := NewHandler(emptyCatalog{}, DefaultLimits, limiter, logger.NewLogger("error"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, want := range []string{"", "RATE_LIMITED"} {
+		body, _ := json.Marshal(request{Query: `mutation { addSong(group: "Muse", title: "Uprising") { id } }`})
+		r := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+		ctx := auth.WithPrincipal(r.Context(), auth.Principal{ID: "test", Role: auth.RoleEditor})
+		r = r.WithContext(ratelimit.WithClient(ctx, ratelimit.Client(ctx, "")))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		var result struct {
+			Errors []struct {
+				Extensions map[string]interface{} `json:"extensions"`
+			} `json:"errors"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+			t.Fatal(err)
+		}
+		var got string
+		if len(result.Errors) > 0 {
+			got, _ = result.Errors[0].Extensions["code"].(string)
+		}
+		if got != want {
+			t.Errorf("addSong #%d: error code = %q, want %q", i+1, got, want)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package graphql_api
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// Limits ограничивает глубину и стоимость запроса до его выполнения
+type Limits struct {
+	MaxDepth      int // максимальная вложенность полей
+	MaxComplexity int // максимальная оценка стоимости запроса
+}
+
+// DefaultLimits — ограничения по умолчанию
+var DefaultLimits = Limits{MaxDepth: 8, MaxComplexity: 2000}
+
+// queryAnalyzer обходит выбранную операцию, раскрывая фрагменты. Каждое поле стоит 1,
+// а стоимость вложенных полей списочного поля умножается на его аргумент limit
+// (или defaultLimit, если он не передан). Служебные поля интроспекции (__schema, __type) не учитываются.
+type queryAnalyzer struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+}
+
+// checkLimits проверяет операцию документа на соответствие ограничениям
+func checkLimits(doc *ast.Document, operationName string, variables map[string]interface{}, limits Limits) error {
+	analyzer := &queryAnalyzer{fragments: map[string]*ast.FragmentDefinition{}, variables: variables}
+	for _, def := range doc.Definitions {
+		if def, ok := def.(*ast.FragmentDefinition); ok {
+			analyzer.fragments[def.Name.Value] = def
+		}
+	}
+	operation := selectOperation(doc, operationName)
+	if operation == nil {
+		return nil
+	}
+
+	depth, complexity := analyzer.measure(operation.SelectionSet, map[string]bool{})
+	if limits.MaxDepth > 0 && depth > limits.MaxDepth {
+		return fmt.Errorf("query depth %d exceeds the maximum of %d", depth, limits.MaxDepth)
+	}
+	if limits.MaxComplexity > 0 && complexity > limits.MaxComplexity {
+		return fmt.Errorf("query complexity %d exceeds the maximum of %d", complexity, limits.MaxComplexity)
+	}
+	return nil
+}
+
+// selectOperation возвращает операцию документа, которая будет выполнена: с именем operationName
+// или первую, если имя не передано
+func selectOperation(doc *ast.Document, operationName string) *ast.OperationDefinition {
+	for _, def := range doc.Definitions {
+		def, ok := def.(*ast.OperationDefinition)
+		if ok && (operationName == "" || (def.Name != nil && def.Name.Value == operationName)) {
+			return def
+		}
+	}
+	return nil
+}
+
+// measure возвращает глубину и стоимость набора полей
+func (a *queryAnalyzer) measure(set *ast.SelectionSet, visiting map[string]bool) (int, int) {
+	if set == nil {
+		return 0, 0
+	}
+	maxDepth, total := 0, 0
+	for _, selection := range set.Selections {
+		var depth, cost int
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(selection.Name.Value, "__") {
+				continue
+			}
+			childDepth, childCost := a.measure(selection.SelectionSet, visiting)
+			depth = childDepth + 1
+			cost = 1 + a.multiplier(selection)*childCost
+		case *ast.InlineFragment:
+			depth, cost = a.measure(selection.SelectionSet, visiting)
+		case *ast.FragmentSpread:
+			name := selection.Name.Value
+			fragment, ok := a.fragments[name]
+			if !ok || visiting[name] {
+				continue
+			}
+			visiting[name] = true
+			depth, cost = a.measure(fragment.SelectionSet, visiting)
+			delete(visiting, name)
+		}
+		if depth > maxDepth {
+			maxDepth = depth
+		}
+		total += cost
+	}
+	return maxDepth, total
+}
+
+// multiplier возвращает значение аргумента limit списочного поля
+func (a *queryAnalyzer) multiplier(field *ast.Field) int {
+	for _, arg := range field.Arguments {
+		if arg.Name.Value != "limit" {
+			continue
+		}
+		switch value := arg.Value.(type) {
+		case *ast.IntValue:
+			if n, err := strconv.Atoi(value.Value); err == nil && n > 0 {
+				return n
+			}
+		case *ast.Variable:
+			if n, ok := toInt(a.variables[value.Name.Value]); ok && n > 0 {
+				return n
+			}
+		}
+		return defaultLimit
+	}
+	if _, isList := listFields[field.Name.Value]; isList {
+		return defaultLimit
+	}
+	return 1
+}
+
+// listFields — списочные поля схемы, принимающие limit
+var listFields = map[string]struct{}{"songs": {}, "artists": {}, "lyrics": {}}
+
+func toInt(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case float64:
+		return int(n), true
+	}
+	return 0, false
+}
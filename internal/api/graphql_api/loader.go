@@ -0,0 +1,103 @@
+package graphql_api
+
+import (
+	"context"
+	"sync"
+
+	"music_catalog/internal/models"
+)
+
+// batchFunc загружает значения сразу для всех накопленных ключей
+type batchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// loader — dataloader на время одного запроса: ключи, запрошенные резолверами одного уровня,
+// копятся до первого обращения к результату и загружаются одним вызовом batchFunc
+type loader[K comparable, V any] struct {
+	mu      sync.Mutex
+	fetch   batchFunc[K, V]
+	pending []K
+	queued  map[K]bool
+	cache   map[K]V
+	errs    map[K]error
+}
+
+func newLoader[K comparable, V any](fetch batchFunc[K, V]) *loader[K, V] {
+	return &loader[K, V]{
+		fetch:  fetch,
+		queued: map[K]bool{},
+		cache:  map[K]V{},
+		errs:   map[K]error{},
+	}
+}
+
+// Load ставит ключ в очередь и возвращает thunk, который graphql-go вызовет после
+// обхода всех полей текущего уровня
+func (l *loader[K, V]) Load(ctx context.Context, key K) func() (V, error) {
+	l.mu.Lock()
+	if !l.queued[key] {
+		l.queued[key] = true
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (V, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if len(l.pending) > 0 {
+			keys := l.pending
+			l.pending = nil
+			values, err := l.fetch(ctx, keys)
+			for _, k := range keys {
+				if err != nil {
+					l.errs[k] = err
+					continue
+				}
+				l.cache[k] = values[k]
+			}
+		}
+		return l.cache[key], l.errs[key]
+	}
+}
+
+// loaders — набор загрузчиков одного GraphQL-запроса
+type loaders struct {
+	songsByID    *loader[int, *models.Song]
+	songsByGroup *loader[string, []models.Song]
+}
+
+func newLoaders(musicService MusicService) *loaders {
+	return &loaders{
+		songsByID: newLoader(func(ctx context.Context, ids []int) (map[int]*models.Song, error) {
+			songs, err := musicService.GetSongsByIDs(ctx, ids)
+			if err != nil {
+				return nil, err
+			}
+			result := make(map[int]*models.Song, len(songs))
+			for i := range songs {
+				result[songs[i].ID] = &songs[i]
+			}
+			return result, nil
+		}),
+		songsByGroup: newLoader(func(ctx context.Context, groups []string) (map[string][]models.Song, error) {
+			songs, err := musicService.GetSongsByGroups(ctx, groups)
+			if err != nil {
+				return nil, err
+			}
+			result := make(map[string][]models.Song, len(groups))
+			for _, song := range songs {
+				result[song.Group] = append(result[song.Group], song)
+			}
+			return result, nil
+		}),
+	}
+}
+
+type loadersKey struct{}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
@@ -0,0 +1,461 @@
+package graphql_api
+
+import (
+	"context"
+	"errors"
+
+	"music_catalog/internal/auth"
+	catalog_errors "music_catalog/internal/errors"
+	"music_catalog/internal/logger"
+	"music_catalog/internal/models"
+	"music_catalog/internal/ratelimit"
+	"music_catalog/internal/validation"
+
+	"github.com/graphql-go/graphql"
+)
+
+const defaultLimit = 10
+
+// MusicService интерфейс для работы с музыкой
+type MusicService interface {
+	GetSongs(ctx context.Context, filters models.SongFilters, pagination models.Pagination) ([]models.Song, error)
+	GetSongsByIDs(ctx context.Context, ids []int) ([]models.Song, error)
+	GetSongsByGroups(ctx context.Context, groups []string) ([]models.Song, error)
+	GetArtists(ctx context.Context, name string, pagination models.Pagination) ([]string, error)
+	GetSongText(ctx context.Context, songID int, page int) (string, error)
+	SearchLyrics(ctx context.Context, query string, pagination models.Pagination) ([]models.Song, error)
+	AddSong(ctx context.Context, group string, title string) (models.Song, error)
+	PatchSong(ctx context.Context, id int, patch models.SongPatch) error
+	DeleteSong(ctx context.Context, id int) error
+}
+
+// artist — исполнитель в графе; песни подгружаются пачкой через loaders.songsByGroup
+type artist struct {
+	Name string
+}
+
+// lyricsPage — один куплет песни
+type lyricsPage struct {
+	SongID int
+	Page   int
+	Text   string
+}
+
+// lyricsConnection — постраничный список куплетов песни
+type lyricsConnection struct {
+	SongID     int
+	TotalPages int
+	Pages      []lyricsPage
+}
+
+// paginationArgs — аргументы limit/offset, общие для всех списочных полей
+var paginationArgs = graphql.FieldConfigArgument{
+	"limit":  &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultLimit},
+	"offset": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0},
+}
+
+// newSchema строит GraphQL-схему каталога поверх MusicService; limiter (может быть nil) ограничивает addSong
+func newSchema(musicService MusicService, limiter *ratelimit.Limiter, logger logger.Logger) (graphql.Schema, error) {
+	// fail логирует ошибку сервисного слоя и переводит её в GraphQL-ошибку
+	fail := func(ctx context.Context, err error) error {
+		gqlErr := toGraphQLError(err)
+		if code := gqlErr.extensions["code"]; code == "INTERNAL" || code == "UPSTREAM_FAILURE" {
+			logger.WithContext(ctx).Error("GraphQL resolver error:", err)
+		} else {
+			logger.WithContext(ctx).Debug("GraphQL resolver error:", err)
+		}
+		return gqlErr
+	}
+
+	// Мутации доступны только клиентам с ролью editor и выше
+	editor := func(resolve graphql.FieldResolveFn) graphql.FieldResolveFn {
+		return func(p graphql.ResolveParams) (interface{}, error) {
+			if err := auth.Authorize(p.Context, auth.RoleEditor); err != nil {
+				return nil, fail(p.Context, err)
+			}
+			return resolve(p)
+		}
+	}
+
+	// addSong обращается к внешнему API, поэтому, как и POST /songs, расходует квоту enrich.
+	// Ключ клиента кладёт в контекст HTTP-middleware; без него ограничение частоты выключено.
+	allowEnrich := func(ctx context.Context) error {
+		client, ok := ratelimit.ClientFromContext(ctx)
+		if limiter == nil || !ok {
+			return nil
+		}
+		err := limiter.Check(ctx, ratelimit.ClassEnrich, client)
+		if err != nil && !errors.Is(err, catalog_errors.ErrRateLimited) {
+			logger.WithContext(ctx).Error("Rate limiter unavailable, request allowed:", err)
+			return nil
+		}
+		return err
+	}
+
+	lyricsPageType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "LyricsPage",
+		Fields: graphql.Fields{
+			"songId": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"page":   &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"text":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	lyricsConnectionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "LyricsConnection",
+		Fields: graphql.Fields{
+			"songId":     &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"totalPages": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"pages":      &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(lyricsPageType)))},
+		},
+	})
+
+	artistType := graphql.NewObject(graphql.ObjectConfig{
+		Name:   "Artist",
+		Fields: graphql.Fields{"name": &graphql.Field{Type: graphql.NewNonNull(graphql.String)}},
+	})
+
+	songType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Song",
+		Fields: graphql.Fields{
+			"id":          &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"group":       &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"title":       &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"text":        &graphql.Field{Type: graphql.String},
+			"link":        &graphql.Field{Type: graphql.String},
+			"releaseDate": &graphql.Field{Type: graphql.String, Resolve: resolveReleaseDate},
+			"artist": &graphql.Field{
+				Type: graphql.NewNonNull(artistType),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return artist{Name: p.Source.(models.Song).Group}, nil
+				},
+			},
+			// Куплеты считаются из уже загруженного текста, без дополнительных запросов
+			"lyrics": &graphql.Field{
+				Type: graphql.NewNonNull(lyricsConnectionType),
+				Args: paginationArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					song := p.Source.(models.Song)
+					limit, offset, err := paginationFrom(p.Args)
+					if err != nil {
+						return nil, err
+					}
+					return paginateLyrics(song, limit, offset), nil
+				},
+			},
+		},
+	})
+
+	// Поле songs у Artist объявляется после songType из-за взаимной ссылки типов
+	artistType.AddFieldConfig("songs", &graphql.Field{
+		Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(songType))),
+		Args: paginationArgs,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			limit, offset, err := paginationFrom(p.Args)
+			if err != nil {
+				return nil, err
+			}
+			load := loadersFrom(p.Context).songsByGroup.Load(p.Context, p.Source.(artist).Name)
+			return func() (interface{}, error) {
+				songs, err := load()
+				if err != nil {
+					return nil, err
+				}
+				return pageOf(songs, limit, offset), nil
+			}, nil
+		},
+	})
+
+	songInputType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name:        "SongInput",
+		Description: "Fields to update; omitted fields keep their current values",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"group":       &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"title":       &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"text":        &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"link":        &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"releaseDate": &graphql.InputObjectFieldConfig{Type: graphql.String},
+		},
+	})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"songs": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(songType))),
+				Args: graphql.FieldConfigArgument{
+					"group":       &graphql.ArgumentConfig{Type: graphql.String},
+					"title":       &graphql.ArgumentConfig{Type: graphql.String},
+					"releaseDate": &graphql.ArgumentConfig{Type: graphql.String},
+					"limit":       paginationArgs["limit"],
+					"offset":      paginationArgs["offset"],
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					filters := models.SongFilters{
+						Group:       stringArg(p.Args, "group"),
+						Title:       stringArg(p.Args, "title"),
+						ReleaseDate: stringArg(p.Args, "releaseDate"),
+					}
+					v := validation.New()
+					v.Check("group", &filters.Group, validation.FilterText)
+					v.Check("title", &filters.Title, validation.FilterText)
+					v.Check("releaseDate", &filters.ReleaseDate, validation.FilterDate)
+					if err := v.Err(); err != nil {
+						return nil, fail(p.Context, err)
+					}
+					limit, offset, err := paginationFrom(p.Args)
+					if err != nil {
+						return nil, err
+					}
+					songs, err := musicService.GetSongs(p.Context, filters, models.Pagination{Limit: limit, Offset: offset})
+					if err != nil {
+						return nil, fail(p.Context, err)
+					}
+					if songs == nil {
+						songs = []models.Song{}
+					}
+					return songs, nil
+				},
+			},
+			"searchLyrics": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(songType))),
+				Description: "Songs whose lyrics contain every word of the query, most relevant first",
+				Args: graphql.FieldConfigArgument{
+					"query":  &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"limit":  paginationArgs["limit"],
+					"offset": paginationArgs["offset"],
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					query := stringArg(p.Args, "query")
+					v := validation.New()
+					v.Check("query", &query, validation.LyricsQuery)
+					if err := v.Err(); err != nil {
+						return nil, fail(p.Context, err)
+					}
+					limit, offset, err := paginationFrom(p.Args)
+					if err != nil {
+						return nil, err
+					}
+					songs, err := musicService.SearchLyrics(p.Context, query, models.Pagination{Limit: limit, Offset: offset})
+					if err != nil {
+						return nil, fail(p.Context, err)
+					}
+					if songs == nil {
+						songs = []models.Song{}
+					}
+					return songs, nil
+				},
+			},
+			"song": &graphql.Field{
+				Type: songType,
+				Args: graphql.FieldConfigArgument{"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					load := loadersFrom(p.Context).songsByID.Load(p.Context, p.Args["id"].(int))
+					return func() (interface{}, error) {
+						song, err := load()
+						if err != nil {
+							return nil, fail(p.Context, err)
+						}
+						if song == nil {
+							return nil, nil
+						}
+						return *song, nil
+					}, nil
+				},
+			},
+			"lyrics": &graphql.Field{
+				Type: graphql.NewNonNull(lyricsPageType),
+				Args: graphql.FieldConfigArgument{
+					"songId": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+					"page":   &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					songID, page := p.Args["songId"].(int), p.Args["page"].(int)
+					if page < 0 {
+						v := validation.New()
+						v.AddError("page", "must be a non-negative integer")
+						return nil, fail(p.Context, v.Err())
+					}
+					text, err := musicService.GetSongText(p.Context, songID, page)
+					if err != nil {
+						return nil, fail(p.Context, err)
+					}
+					return lyricsPage{SongID: songID, Page: page, Text: text}, nil
+				},
+			},
+			"artists": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(artistType))),
+				Args: graphql.FieldConfigArgument{
+					"name":   &graphql.ArgumentConfig{Type: graphql.String},
+					"limit":  paginationArgs["limit"],
+					"offset": paginationArgs["offset"],
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					name := stringArg(p.Args, "name")
+					v := validation.New()
+					v.Check("name", &name, validation.FilterText)
+					if err := v.Err(); err != nil {
+						return nil, fail(p.Context, err)
+					}
+					limit, offset, err := paginationFrom(p.Args)
+					if err != nil {
+						return nil, err
+					}
+					names, err := musicService.GetArtists(p.Context, name, models.Pagination{Limit: limit, Offset: offset})
+					if err != nil {
+						return nil, fail(p.Context, err)
+					}
+					artists := make([]artist, 0, len(names))
+					for _, n := range names {
+						artists = append(artists, artist{Name: n})
+					}
+					return artists, nil
+				},
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"addSong": &graphql.Field{
+				Type: graphql.NewNonNull(songType),
+				Args: graphql.FieldConfigArgument{
+					"group": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"title": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: editor(func(p graphql.ResolveParams) (interface{}, error) {
+					group, title := p.Args["group"].(string), p.Args["title"].(string)
+					v := validation.New()
+					v.Check("group", &group, validation.SongGroup)
+					v.Check("title", &title, validation.SongTitle)
+					if err := v.Err(); err != nil {
+						return nil, fail(p.Context, err)
+					}
+					if err := allowEnrich(p.Context); err != nil {
+						return nil, fail(p.Context, err)
+					}
+					song, err := musicService.AddSong(p.Context, group, title)
+					if err != nil {
+						return nil, fail(p.Context, err)
+					}
+					return song, nil
+				}),
+			},
+			"updateSong": &graphql.Field{
+				Type: graphql.NewNonNull(songType),
+				Args: graphql.FieldConfigArgument{
+					"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)},
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(songInputType)},
+				},
+				Resolve: editor(func(p graphql.ResolveParams) (interface{}, error) {
+					id := p.Args["id"].(int)
+					input := p.Args["input"].(map[string]interface{})
+					patch := models.SongPatch{
+						Group:       optionalString(input, "group"),
+						Title:       optionalString(input, "title"),
+						Text:        optionalString(input, "text"),
+						Link:        optionalString(input, "link"),
+						ReleaseDate: optionalString(input, "releaseDate"),
+					}
+					v := validation.New()
+					v.CheckOptional("group", patch.Group, validation.SongGroup)
+					v.CheckOptional("title", patch.Title, validation.SongTitle)
+					v.CheckOptional("text", patch.Text, validation.SongText)
+					v.CheckOptional("link", patch.Link, validation.SongLink)
+					v.CheckOptional("releaseDate", patch.ReleaseDate, validation.SongReleaseDate)
+					if err := v.Err(); err != nil {
+						return nil, fail(p.Context, err)
+					}
+					if err := musicService.PatchSong(p.Context, id, patch); err != nil {
+						return nil, fail(p.Context, err)
+					}
+					songs, err := musicService.GetSongsByIDs(p.Context, []int{id})
+					if err != nil {
+						return nil, fail(p.Context, err)
+					}
+					if len(songs) == 0 {
+						return nil, fail(p.Context, catalog_errors.ErrSongNotFound)
+					}
+					return songs[0], nil
+				}),
+			},
+			"deleteSong": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Boolean),
+				Args: graphql.FieldConfigArgument{"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.Int)}},
+				Resolve: editor(func(p graphql.ResolveParams) (interface{}, error) {
+					if err := musicService.DeleteSong(p.Context, p.Args["id"].(int)); err != nil {
+						return nil, fail(p.Context, err)
+					}
+					return true, nil
+				}),
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+}
+
+// resolveReleaseDate отдаёт дату выпуска в формате YYYY-MM-DD
+func resolveReleaseDate(p graphql.ResolveParams) (interface{}, error) {
+	song := p.Source.(models.Song)
+	if song.ReleaseDate == "" {
+		return nil, nil
+	}
+	return validation.NormalizeDate(song.ReleaseDate), nil
+}
+
+// paginateLyrics разбивает текст песни на куплеты и возвращает запрошенную страницу
+func paginateLyrics(song models.Song, limit int, offset int) lyricsConnection {
+	verses := models.SplitVerses(song.Text)
+	if song.Text == "" {
+		verses = nil
+	}
+	pages := make([]lyricsPage, 0, limit)
+	for i, verse := range pageOf(verses, limit, offset) {
+		pages = append(pages, lyricsPage{SongID: song.ID, Page: offset + i + 1, Text: verse})
+	}
+	return lyricsConnection{SongID: song.ID, TotalPages: len(verses), Pages: pages}
+}
+
+// pageOf возвращает срез элементов [offset, offset+limit)
+func pageOf[T any](items []T, limit int, offset int) []T {
+	if offset >= len(items) {
+		return []T{}
+	}
+	end := offset + limit
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[offset:end]
+}
+
+// paginationFrom проверяет аргументы limit/offset
+func paginationFrom(args map[string]interface{}) (int, int, error) {
+	limit, _ := args["limit"].(int)
+	offset, _ := args["offset"].(int)
+	v := validation.New()
+	if limit < 1 {
+		v.AddError("limit", "must be a positive integer")
+	}
+	if offset < 0 {
+		v.AddError("offset", "must be a non-negative integer")
+	}
+	if err := v.Err(); err != nil {
+		return 0, 0, toGraphQLError(err)
+	}
+	return limit, offset, nil
+}
+
+func stringArg(args map[string]interface{}, name string) string {
+	value, _ := args[name].(string)
+	return value
+}
+
+func optionalString(input map[string]interface{}, name string) *string {
+	value, ok := input[name].(string)
+	if !ok {
+		return nil
+	}
+	return &value
+}
@@ -0,0 +1,113 @@
+package grpc_api
+
+import (
+	"context"
+	"strings"
+
+	"music_catalog/internal/api/grpc_api/catalogpb"
+	"music_catalog/internal/auth"
+	"music_catalog/internal/logger"
+	"music_catalog/internal/models"
+	"music_catalog/internal/tenant"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// methodRoles — роль, необходимая для вызова метода; методы, которых нет в списке, доступны только admin
+var methodRoles = map[string]auth.Role{
+	catalogpb.CatalogService_ListSongs_FullMethodName:   auth.RoleViewer,
+	catalogpb.CatalogService_ExportSongs_FullMethodName: auth.RoleViewer,
+	catalogpb.CatalogService_GetSong_FullMethodName:     auth.RoleViewer,
+	catalogpb.CatalogService_GetSongText_FullMethodName: auth.RoleViewer,
+	catalogpb.CatalogService_AddSong_FullMethodName:     auth.RoleEditor,
+	catalogpb.CatalogService_UpdateSong_FullMethodName:  auth.RoleEditor,
+	catalogpb.CatalogService_PatchSong_FullMethodName:   auth.RoleEditor,
+	catalogpb.CatalogService_DeleteSong_FullMethodName:  auth.RoleEditor,
+
+	"/grpc.reflection.v1.ServerReflection/ServerReflectionInfo":      auth.RoleViewer,
+	"/grpc.reflection.v1alpha.ServerReflection/ServerReflectionInfo": auth.RoleViewer,
+}
+
+// authorizer проверяет учётные данные из метаданных "authorization: Bearer <token>" или "x-api-key"
+// и кладёт клиента и арендатора (по привязке клиента или метаданным "x-tenant-id") в контекст вызова
+type authorizer struct {
+	authenticator *auth.Authenticator
+	tenants       *tenant.Resolver
+	logger        logger.Logger
+}
+
+// authorize аутентифицирует вызов и проверяет роль клиента для метода
+func (a *authorizer) authorize(ctx context.Context, fullMethod string) (context.Context, error) {
+	principal, err := a.authenticator.Authenticate(ctx, credentialFromMetadata(ctx))
+	if err == nil {
+		ctx = auth.WithPrincipal(ctx, principal)
+		required, ok := methodRoles[fullMethod]
+		if !ok {
+			required = auth.RoleAdmin
+		}
+		err = auth.Authorize(ctx, required)
+	}
+	if err == nil {
+		var t models.Tenant
+		if t, err = a.tenants.Resolve(ctx, firstMetadata(ctx, tenantMetadataKey)); err == nil {
+			ctx = tenant.WithTenant(ctx, t)
+		}
+	}
+	if err != nil {
+		st := statusFromError(err)
+		a.logger.WithContext(ctx).Info("gRPC access denied:", fullMethod, auth.Actor(ctx), err)
+		return ctx, st.Err()
+	}
+	return ctx, nil
+}
+
+func (a *authorizer) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := a.authorize(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (a *authorizer) stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authorize(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authorizedStream{ServerStream: ss, ctx: ctx})
+}
+
+// authorizedStream подменяет контекст потока контекстом с клиентом
+type authorizedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authorizedStream) Context() context.Context {
+	return s.ctx
+}
+
+// credentialFromMetadata извлекает API-ключ или JWT из метаданных вызова
+func credentialFromMetadata(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get("authorization") {
+		scheme, credential, _ := strings.Cut(value, " ")
+		if strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(credential)
+		}
+	}
+	return firstMetadata(ctx, "x-api-key")
+}
+
+// tenantMetadataKey — ключ метаданных с арендатором, аналог заголовка X-Tenant-ID
+var tenantMetadataKey = strings.ToLower(tenant.Header)
+
+// firstMetadata возвращает первое значение ключа метаданных вызова
+func firstMetadata(ctx context.Context, key string) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
@@ -0,0 +1,59 @@
+package grpc_api
+
+import (
+	"context"
+	"errors"
+
+	catalog_errors "music_catalog/internal/errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// toStatus логирует ошибку и сопоставляет ошибку сервисного слоя с кодом gRPC
+func (s *CatalogServer) toStatus(ctx context.Context, message string, err error) error {
+	st := statusFromError(err)
+	if st.Code() == codes.Internal || st.Code() == codes.Unavailable {
+		s.logger.WithContext(ctx).Error(message, err)
+	} else {
+		s.logger.WithContext(ctx).Info(message, err)
+	}
+	return st.Err()
+}
+
+// statusFromError переводит sentinel-ошибки catalog_errors в статусы gRPC;
+// ошибки валидации дополняются деталями BadRequest по каждому полю
+func statusFromError(err error) *status.Status {
+	var validationErr *catalog_errors.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		st := status.New(codes.InvalidArgument, "request validation failed")
+		violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(validationErr.Errors))
+		for _, fe := range validationErr.Errors {
+			violations = append(violations, &errdetails.BadRequest_FieldViolation{Field: fe.Field, Description: fe.Message})
+		}
+		if detailed, detailsErr := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations}); detailsErr == nil {
+			return detailed
+		}
+		return st
+	case errors.Is(err, catalog_errors.ErrSongNotFound):
+		return status.New(codes.NotFound, "song not found")
+	case errors.Is(err, catalog_errors.ErrSongExists):
+		return status.New(codes.AlreadyExists, "song already exists")
+	case errors.Is(err, catalog_errors.ErrInvalidPage):
+		return status.New(codes.OutOfRange, "invalid page number")
+	case errors.Is(err, catalog_errors.ErrTenantNotFound):
+		return status.New(codes.NotFound, "tenant not found")
+	case errors.Is(err, catalog_errors.ErrUnauthenticated):
+		return status.New(codes.Unauthenticated, "authentication required")
+	case errors.Is(err, catalog_errors.ErrForbidden):
+		return status.New(codes.PermissionDenied, err.Error())
+	case errors.Is(err, catalog_errors.ErrRateLimited):
+		return status.New(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, catalog_errors.ErrUpstream):
+		return status.New(codes.Unavailable, "external api failure")
+	default:
+		return status.New(codes.Internal, "internal server error")
+	}
+}
@@ -0,0 +1,59 @@
+package grpc_api
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"music_catalog/internal/api/grpc_api/catalogpb"
+	"music_catalog/internal/auth"
+	catalog_errors "music_catalog/internal/errors"
+	"music_catalog/internal/logger"
+	"music_catalog/internal/ratelimit"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+)
+
+// methodClasses — класс квоты метода; методы, которых нет в списке, не ограничиваются.
+// AddSong обращается к внешнему API и расходует ту же квоту enrich, что POST /songs в REST.
+var methodClasses = map[string]ratelimit.Class{
+	catalogpb.CatalogService_AddSong_FullMethodName: ratelimit.ClassEnrich,
+}
+
+// rateLimiter ограничивает частоту вызовов квотами классов методов (см. methodClasses).
+// Клиент — principal, положенный в контекст authorizer, поэтому перехватчик стоит после него.
+// Сбой хранилища корзин не блокирует вызовы.
+type rateLimiter struct {
+	limiter *ratelimit.Limiter
+	logger  logger.Logger
+}
+
+func (l *rateLimiter) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	class, ok := methodClasses[info.FullMethod]
+	if !ok {
+		return handler(ctx, req)
+	}
+	err := l.limiter.Check(ctx, class, ratelimit.Client(ctx, peerIP(ctx)))
+	switch {
+	case errors.Is(err, catalog_errors.ErrRateLimited):
+		l.logger.WithContext(ctx).Info("Rate limit exceeded:", class, auth.Actor(ctx), info.FullMethod)
+		return nil, statusFromError(err).Err()
+	case err != nil:
+		l.logger.WithContext(ctx).Error("Rate limiter unavailable, request allowed:", err)
+	}
+	return handler(ctx, req)
+}
+
+// peerIP возвращает адрес клиента вызова
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
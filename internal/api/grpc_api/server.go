@@ -0,0 +1,269 @@
+// Package grpc_api implements the gRPC transport of the music catalog
+package grpc_api
+
+import (
+	"context"
+
+	"music_catalog/internal/api/grpc_api/catalogpb"
+	"music_catalog/internal/auth"
+	"music_catalog/internal/logger"
+	"music_catalog/internal/models"
+	"music_catalog/internal/ratelimit"
+	"music_catalog/internal/tenant"
+	"music_catalog/internal/validation"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+const (
+	defaultLimit           = 10
+	defaultExportBatchSize = 100
+)
+
+// MusicService интерфейс для работы с музыкой
+type MusicService interface {
+	GetSongs(ctx context.Context, filters models.SongFilters, pagination models.Pagination) ([]models.Song, error)
+	GetSong(ctx context.Context, id int) (models.Song, error)
+	AddSong(ctx context.Context, group string, title string) (models.Song, error)
+	UpdateSong(ctx context.Context, song models.Song) error
+	PatchSong(ctx context.Context, id int, patch models.SongPatch) error
+	DeleteSong(ctx context.Context, id int) error
+	GetSongText(ctx context.Context, songID int, page int) (string, error)
+}
+
+// CatalogServer реализует catalogpb.CatalogServiceServer поверх MusicService
+type CatalogServer struct {
+	catalogpb.UnimplementedCatalogServiceServer
+	musicService MusicService
+	logger       logger.Logger
+}
+
+// NewCatalogServer creates a new CatalogServer with the provided music service
+func NewCatalogServer(musicService MusicService, logger logger.Logger) *CatalogServer {
+	return &CatalogServer{
+		musicService: musicService,
+		logger:       logger,
+	}
+}
+
+// NewGRPCServer creates a grpc.Server with the catalog service and server reflection registered.
+// Every call is authenticated, authorized by the role required for its method and scoped to a tenant;
+// with a limiter AddSong is charged to the enrich quota.
+func NewGRPCServer(catalogServer *CatalogServer, authenticator *auth.Authenticator, tenants *tenant.Resolver, limiter *ratelimit.Limiter) *grpc.Server {
+	authorizer := &authorizer{authenticator: authenticator, tenants: tenants, logger: catalogServer.logger}
+	unary := []grpc.UnaryServerInterceptor{authorizer.unary}
+	if limiter != nil {
+		unary = append(unary, (&rateLimiter{limiter: limiter, logger: catalogServer.logger}).unary)
+	}
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(authorizer.stream),
+	)
+	catalogpb.RegisterCatalogServiceServer(server, catalogServer)
+	reflection.Register(server)
+	return server
+}
+
+// ListSongs streams one page of songs
+func (s *CatalogServer) ListSongs(req *catalogpb.ListSongsRequest, stream grpc.ServerStreamingServer[catalogpb.Song]) error {
+	filters, err := toFilters(req.GetFilters())
+	if err != nil {
+		return s.toStatus(stream.Context(), "Invalid list songs request:", err)
+	}
+
+	v := validation.New()
+	pagination := models.Pagination{
+		Limit:  int(req.GetPagination().GetLimit()),
+		Offset: int(req.GetPagination().GetOffset()),
+	}
+	if pagination.Limit < 0 {
+		v.AddError("pagination.limit", "must be a positive integer")
+	}
+	if pagination.Offset < 0 {
+		v.AddError("pagination.offset", "must be a non-negative integer")
+	}
+	if err := v.Err(); err != nil {
+		return s.toStatus(stream.Context(), "Invalid list songs request:", err)
+	}
+	if pagination.Limit == 0 {
+		pagination.Limit = defaultLimit
+	}
+
+	songs, err := s.musicService.GetSongs(stream.Context(), filters, pagination)
+	if err != nil {
+		return s.toStatus(stream.Context(), "Error getting songs:", err)
+	}
+
+	for _, song := range songs {
+		if err := stream.Send(toProtoSong(song)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExportSongs streams every song matching the filters, reading the catalog in batches
+func (s *CatalogServer) ExportSongs(req *catalogpb.ExportSongsRequest, stream grpc.ServerStreamingServer[catalogpb.Song]) error {
+	filters, err := toFilters(req.GetFilters())
+	if err != nil {
+		return s.toStatus(stream.Context(), "Invalid export songs request:", err)
+	}
+
+	batchSize := int(req.GetBatchSize())
+	if batchSize < 0 {
+		v := validation.New()
+		v.AddError("batch_size", "must be a positive integer")
+		return s.toStatus(stream.Context(), "Invalid export songs request:", v.Err())
+	}
+	if batchSize == 0 {
+		batchSize = defaultExportBatchSize
+	}
+
+	for offset := 0; ; offset += batchSize {
+		songs, err := s.musicService.GetSongs(stream.Context(), filters, models.Pagination{Limit: batchSize, Offset: offset})
+		if err != nil {
+			return s.toStatus(stream.Context(), "Error exporting songs:", err)
+		}
+		for _, song := range songs {
+			if err := stream.Send(toProtoSong(song)); err != nil {
+				return err
+			}
+		}
+		if len(songs) < batchSize {
+			return nil
+		}
+	}
+}
+
+// GetSong returns a single song
+func (s *CatalogServer) GetSong(ctx context.Context, req *catalogpb.GetSongRequest) (*catalogpb.Song, error) {
+	song, err := s.musicService.GetSong(ctx, int(req.GetId()))
+	if err != nil {
+		return nil, s.toStatus(ctx, "Error getting song:", err)
+	}
+	return toProtoSong(song), nil
+}
+
+// GetSongText returns the full lyrics or a single verse
+func (s *CatalogServer) GetSongText(ctx context.Context, req *catalogpb.GetSongTextRequest) (*catalogpb.LyricsPage, error) {
+	if req.GetPage() < 0 {
+		v := validation.New()
+		v.AddError("page", "must be a non-negative integer")
+		return nil, s.toStatus(ctx, "Invalid get song text request:", v.Err())
+	}
+
+	text, err := s.musicService.GetSongText(ctx, int(req.GetSongId()), int(req.GetPage()))
+	if err != nil {
+		return nil, s.toStatus(ctx, "Error getting song text:", err)
+	}
+	return &catalogpb.LyricsPage{SongId: req.GetSongId(), Page: req.GetPage(), Text: text}, nil
+}
+
+// AddSong adds a new song to the catalog
+func (s *CatalogServer) AddSong(ctx context.Context, req *catalogpb.AddSongRequest) (*catalogpb.Song, error) {
+	group, title := req.GetGroup(), req.GetTitle()
+	v := validation.New()
+	v.Check("group", &group, validation.SongGroup)
+	v.Check("title", &title, validation.SongTitle)
+	if err := v.Err(); err != nil {
+		return nil, s.toStatus(ctx, "Invalid add song request:", err)
+	}
+
+	song, err := s.musicService.AddSong(ctx, group, title)
+	if err != nil {
+		return nil, s.toStatus(ctx, "Error adding song:", err)
+	}
+	s.logger.WithContext(ctx).Info("Song added successfully: ", song.ID, song.Group, song.Title)
+	return toProtoSong(song), nil
+}
+
+// UpdateSong replaces all fields of a song
+func (s *CatalogServer) UpdateSong(ctx context.Context, req *catalogpb.UpdateSongRequest) (*catalogpb.Song, error) {
+	song := models.Song{
+		ID:          int(req.GetId()),
+		Group:       req.GetGroup(),
+		Title:       req.GetTitle(),
+		Text:        req.GetText(),
+		Link:        req.GetLink(),
+		ReleaseDate: req.GetReleaseDate(),
+	}
+	v := validation.New()
+	v.Check("group", &song.Group, validation.SongGroup)
+	v.Check("title", &song.Title, validation.SongTitle)
+	v.Check("text", &song.Text, validation.SongText)
+	v.Check("link", &song.Link, validation.SongLink)
+	v.Check("release_date", &song.ReleaseDate, validation.SongReleaseDate)
+	if err := v.Err(); err != nil {
+		return nil, s.toStatus(ctx, "Invalid update song request:", err)
+	}
+
+	if err := s.musicService.UpdateSong(ctx, song); err != nil {
+		return nil, s.toStatus(ctx, "Failed to update song:", err)
+	}
+	return s.GetSong(ctx, &catalogpb.GetSongRequest{Id: req.GetId()})
+}
+
+// PatchSong updates only the provided fields of a song
+func (s *CatalogServer) PatchSong(ctx context.Context, req *catalogpb.PatchSongRequest) (*catalogpb.Song, error) {
+	patch := models.SongPatch{
+		Group:       req.Group,
+		Title:       req.Title,
+		Text:        req.Text,
+		Link:        req.Link,
+		ReleaseDate: req.ReleaseDate,
+	}
+	v := validation.New()
+	v.CheckOptional("group", patch.Group, validation.SongGroup)
+	v.CheckOptional("title", patch.Title, validation.SongTitle)
+	v.CheckOptional("text", patch.Text, validation.SongText)
+	v.CheckOptional("link", patch.Link, validation.SongLink)
+	v.CheckOptional("release_date", patch.ReleaseDate, validation.SongReleaseDate)
+	// Как и в REST, пустое изменение — ошибка клиента, а не пустая операция
+	if patch.Group == nil && patch.Title == nil && patch.Text == nil && patch.Link == nil && patch.ReleaseDate == nil {
+		v.AddError("patch", "at least one field must be provided")
+	}
+	if err := v.Err(); err != nil {
+		return nil, s.toStatus(ctx, "Invalid patch song request:", err)
+	}
+
+	if err := s.musicService.PatchSong(ctx, int(req.GetId()), patch); err != nil {
+		return nil, s.toStatus(ctx, "Failed to patch song:", err)
+	}
+	return s.GetSong(ctx, &catalogpb.GetSongRequest{Id: req.GetId()})
+}
+
+// DeleteSong removes a song by ID
+func (s *CatalogServer) DeleteSong(ctx context.Context, req *catalogpb.DeleteSongRequest) (*emptypb.Empty, error) {
+	if err := s.musicService.DeleteSong(ctx, int(req.GetId())); err != nil {
+		return nil, s.toStatus(ctx, "Error deleting song:", err)
+	}
+	s.logger.WithContext(ctx).Info("Song deleted successfully")
+	return &emptypb.Empty{}, nil
+}
+
+// toFilters нормализует и проверяет фильтры списка песен
+func toFilters(f *catalogpb.SongFilters) (models.SongFilters, error) {
+	filters := models.SongFilters{
+		Group:       f.GetGroup(),
+		Title:       f.GetTitle(),
+		ReleaseDate: f.GetReleaseDate(),
+	}
+	v := validation.New()
+	v.Check("filters.group", &filters.Group, validation.FilterText)
+	v.Check("filters.title", &filters.Title, validation.FilterText)
+	v.Check("filters.release_date", &filters.ReleaseDate, validation.FilterDate)
+	return filters, v.Err()
+}
+
+func toProtoSong(song models.Song) *catalogpb.Song {
+	return &catalogpb.Song{
+		Id:          int64(song.ID),
+		Group:       song.Group,
+		Title:       song.Title,
+		Text:        song.Text,
+		Link:        song.Link,
+		ReleaseDate: song.ReleaseDate,
+	}
+}
@@ -5,14 +5,15 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"time"
 
 	"net/http"
+	"net/url"
 	"strconv"
 
 	catalog_errors "music_catalog/internal/errors"
 	"music_catalog/internal/logger"
 	"music_catalog/internal/models"
+	"music_catalog/internal/validation"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -20,10 +21,13 @@ import (
 // MusicService интерфейс для работы с музыкой
 type MusicService interface {
 	GetSongs(ctx context.Context, filters models.SongFilters, pagination models.Pagination) ([]models.Song, error)
-	AddSong(ctx context.Context, group string, title string) error
+	AddSong(ctx context.Context, group string, title string) (models.Song, error)
+	GetSong(ctx context.Context, id int) (models.Song, error)
 	UpdateSong(ctx context.Context, song models.Song) error
 	DeleteSong(ctx context.Context, id int) error
 	GetSongText(ctx context.Context, songID int, page int) (string, error)
+	PatchSong(ctx context.Context, id int, patch models.SongPatch) error
+	SearchLyrics(ctx context.Context, query string, pagination models.Pagination) ([]models.Song, error)
 }
 
 // SongHandler handles HTTP requests for songs
@@ -47,41 +51,41 @@ func NewSongHandler(musicService MusicService, logger logger.Logger) *SongHandle
 // @Accept  json
 // @Produce  json
 // @Param song body AddSongRequest true "Song request"
+// @Param Idempotency-Key header string false "Client-generated key; retries with the same key replay the first response"
 // @Success 201 {string} string "Song added successfully"
-// @Failure 400 {string} string "Invalid input"
-// @Failure 500 {string} string "Error adding the song"
+// @Failure 400 {object} Problem "Invalid input"
+// @Failure 409 {object} Problem "Song already exists or a request with the same Idempotency-Key is in progress"
+// @Failure 422 {object} Problem "Idempotency-Key reused with a different request"
+// @Failure 500 {object} Problem "Error adding the song"
+// @Failure 502 {object} Problem "External API failure"
+// @Failure 401 {object} Problem "Authentication required"
+// @Failure 403 {object} Problem "Insufficient role"
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /songs [post]
 func (h *SongHandler) AddSong(w http.ResponseWriter, r *http.Request) {
 
 	var requestBody AddSongRequest
 	err := json.NewDecoder(r.Body).Decode(&requestBody)
 	if err != nil {
-		h.logger.Error("Error decoding JSON:", err)
-		http.Error(w, "Invalid input", http.StatusBadRequest)
+		respondMalformedBody(h.logger, w, r, err)
 		return
 	}
 
-	h.logger.Debug("Request to add song: ", requestBody.Group, requestBody.Title)
+	h.logger.WithContext(r.Context()).Debug("Request to add song: ", requestBody.Group, requestBody.Title)
 
-	if requestBody.Group == "" || requestBody.Title == "" {
-		h.logger.Error("Group or song title is missing")
-		http.Error(w, "Group or song title is missing", http.StatusBadRequest)
+	if err := requestBody.Validate(); err != nil {
+		respondProblem(h.logger, w, r, "Invalid add song request:", err)
 		return
 	}
 
-	err = h.musicService.AddSong(r.Context(), requestBody.Group, requestBody.Title)
+	_, err = h.musicService.AddSong(r.Context(), requestBody.Group, requestBody.Title)
 	if err != nil {
-		if err == catalog_errors.ErrSongExists {
-			h.logger.Info("Song already exists: ", requestBody.Group, requestBody.Title)
-			w.WriteHeader(http.StatusConflict)
-			return
-		}
-		h.logger.Error("Error adding song:", err)
-		http.Error(w, "Error adding the song", http.StatusInternalServerError)
+		respondProblem(h.logger, w, r, "Error adding song:", err)
 		return
 	}
 
-	h.logger.Info("Song added successfully: ", requestBody.Group, requestBody.Title)
+	h.logger.WithContext(r.Context()).Info("Song added successfully: ", requestBody.Group, requestBody.Title)
 	w.WriteHeader(http.StatusCreated)
 }
 
@@ -96,19 +100,23 @@ func (h *SongHandler) AddSong(w http.ResponseWriter, r *http.Request) {
 // @Param limit query int false "Number of items per page"
 // @Param offset query int false "Pagination offset"
 // @Success 200 {array} models.Song "List of songs"
-// @Failure 500 {string} string "Error retrieving the data"
+// @Failure 400 {object} Problem "Invalid request parameters"
+// @Failure 404 {object} Problem "No songs found"
+// @Failure 500 {object} Problem "Error retrieving the data"
+// @Failure 401 {object} Problem "Authentication required"
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /songs [get]
 func (h *SongHandler) GetSongs(w http.ResponseWriter, r *http.Request) {
 
 	// Получаем параметры фильтров и пагинации
 	filters, pagination, err := parseRequestParams(r)
 	if err != nil {
-		h.logger.Error("Error parsing request parameters:", err)
-		http.Error(w, "Invalid request parameters: "+err.Error(), http.StatusBadRequest)
+		respondProblem(h.logger, w, r, "Error parsing request parameters:", err)
 		return
 	}
 
-	h.logger.Debug("Request to get songs:", filters.Group, filters.Title, filters.ReleaseDate)
+	h.logger.WithContext(r.Context()).Debug("Request to get songs:", filters.Group, filters.Title, filters.ReleaseDate)
 
 	if pagination.Limit == 0 {
 		pagination.Limit = 10
@@ -117,15 +125,14 @@ func (h *SongHandler) GetSongs(w http.ResponseWriter, r *http.Request) {
 	// Вызов сервиса для получения песен
 	songs, err := h.musicService.GetSongs(r.Context(), filters, pagination)
 	if err != nil {
-		h.logger.Error("Error getting songs:", err)
-		http.Error(w, "Failed to fetch songs", http.StatusInternalServerError)
+		respondProblem(h.logger, w, r, "Error getting songs:", err)
 		return
 	}
 
 	// Проверка на наличие найденных песен
 	if len(songs) == 0 {
-		h.logger.Debug("No songs found")
-		http.Error(w, "No songs found", http.StatusNotFound)
+		h.logger.WithContext(r.Context()).Debug("No songs found")
+		writeProblem(w, r, Problem{Type: ProblemTypeSongsNotFound, Title: "No songs found", Status: http.StatusNotFound})
 		return
 	}
 
@@ -142,16 +149,19 @@ func (h *SongHandler) GetSongs(w http.ResponseWriter, r *http.Request) {
 // @Param id path int true "Song ID"
 // @Param page query int false "Page number (default: 0 - full text)"
 // @Success 200 {string} string "Paginated verses"
-// @Failure 404 {string} string "Song not found"
-// @Failure 500 {string} string "Error retrieving lyrics"
+// @Failure 400 {object} Problem "Invalid song ID or page"
+// @Failure 404 {object} Problem "Song not found"
+// @Failure 500 {object} Problem "Error retrieving lyrics"
+// @Failure 401 {object} Problem "Authentication required"
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /songs/{id}/text [get]
 func (h *SongHandler) GetSongText(w http.ResponseWriter, r *http.Request) {
 
 	// Получаем ID песни
-	songID, err := strconv.Atoi(chi.URLParam(r, "id"))
-
+	songID, err := parseSongID(r)
 	if err != nil {
-		http.Error(w, "Invalid song ID", http.StatusBadRequest)
+		respondProblem(h.logger, w, r, "Invalid song ID:", err)
 		return
 	}
 
@@ -164,17 +174,12 @@ func (h *SongHandler) GetSongText(w http.ResponseWriter, r *http.Request) {
 		page = 0 // По умолчанию - полный текст
 	}
 
-	h.logger.Debug("Request to get songs", songID, page)
+	h.logger.WithContext(r.Context()).Debug("Request to get songs", songID, page)
 
 	// Получам полный текст песни
 	text, err := h.musicService.GetSongText(r.Context(), songID, page) // 0 обозначает полный текст песни
 	if err != nil {
-		h.logger.Error("Error getting song text:", err)
-		if err == catalog_errors.ErrSongNotFound {
-			http.Error(w, "Song not found", http.StatusNotFound)
-		} else {
-			http.Error(w, "Error retrieving lyrics", http.StatusInternalServerError)
-		}
+		respondProblem(h.logger, w, r, "Error getting song text:", err)
 		return
 	}
 
@@ -192,100 +197,151 @@ func (h *SongHandler) GetSongText(w http.ResponseWriter, r *http.Request) {
 // @Tags Songs
 // @Param id path int true "Song ID"
 // @Success 204 {string} string "Song deleted successfully"
-// @Failure 404 {string} string "Song not found"
-// @Failure 500 {string} string "Error deleting the song"
+// @Failure 400 {object} Problem "Invalid song ID"
+// @Failure 404 {object} Problem "Song not found"
+// @Failure 500 {object} Problem "Error deleting the song"
+// @Failure 401 {object} Problem "Authentication required"
+// @Failure 403 {object} Problem "Insufficient role"
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /songs/{id} [delete]
 func (h *SongHandler) DeleteSong(w http.ResponseWriter, r *http.Request) {
-	songID, err := strconv.Atoi(chi.URLParam(r, "id"))
-
+	songID, err := parseSongID(r)
 	if err != nil {
-		http.Error(w, "Invalid song ID", http.StatusBadRequest)
+		respondProblem(h.logger, w, r, "Invalid song ID:", err)
 		return
 	}
 
-	h.logger.Debug("Request to delete song", songID)
+	h.logger.WithContext(r.Context()).Debug("Request to delete song", songID)
 
 	err = h.musicService.DeleteSong(r.Context(), songID)
-	if err != nil {
-		h.logger.Error("Error deleting song:", err)
-		if err == catalog_errors.ErrSongNotFound {
-			http.Error(w, "Song not found", http.StatusNotFound)
-		} else {
-			http.Error(w, "Error deleting the song", http.StatusInternalServerError)
-		}
+	// v1 сохраняет прежнее поведение: удаление несуществующей песни — успех
+	if err != nil && !errors.Is(err, catalog_errors.ErrSongNotFound) {
+		respondProblem(h.logger, w, r, "Error deleting song:", err)
 		return
 	}
 
-	h.logger.Info("Song deleted successfully")
+	h.logger.WithContext(r.Context()).Info("Song deleted successfully")
 	w.WriteHeader(http.StatusNoContent)
 }
 
 // @Summary Update a song by its ID
 // @Description Update an existing song with the provided data.
-// @Tags songs
+// @Tags Songs
 // @Accept  json
 // @Produce plain
 // @Param id path int true "Song ID"
 // @Param song body UpdateSongRequest true "Song data"
 // @Success 200 {string} string "Song updated successfully"
-// @Failure 400 {string} string "Invalid request"
-// @Failure 404 {string} string "Song not found"
-// @Failure 500 {string} string "Internal server error"
+// @Failure 400 {object} Problem "Invalid request"
+// @Failure 404 {object} Problem "Song not found"
+// @Failure 500 {object} Problem "Internal server error"
+// @Failure 401 {object} Problem "Authentication required"
+// @Failure 403 {object} Problem "Insufficient role"
+// @Security ApiKeyAuth
+// @Security BearerAuth
 // @Router /songs/{id} [put]
 func (h *SongHandler) UpdateSong(w http.ResponseWriter, r *http.Request) {
 	// Получение ID песни из URL параметров
-	songID, err := strconv.Atoi(chi.URLParam(r, "id"))
-
+	songID, err := parseSongID(r)
 	if err != nil {
-		h.logger.Error("Invalid song ID:", err)
-		http.Error(w, "Invalid song ID", http.StatusBadRequest)
+		respondProblem(h.logger, w, r, "Invalid song ID:", err)
 		return
 	}
 
 	// Чтение и декодирование данных из тела запроса
-	var updatedSong models.Song
+	var updatedSong UpdateSongRequest
 	if err := json.NewDecoder(r.Body).Decode(&updatedSong); err != nil {
-		h.logger.Error("Invalid request payload:", err)
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		respondMalformedBody(h.logger, w, r, err)
 		return
 	}
 
-	if updatedSong.Group == "" || updatedSong.Title == "" || updatedSong.Text == "" ||
-		updatedSong.Link == "" || updatedSong.ReleaseDate == "" {
-		h.logger.Error(fmt.Sprintf("some of required fields is missing: %+v", updatedSong))
-		http.Error(w, "some of required fields is missing", http.StatusBadRequest)
+	if err := updatedSong.Validate(); err != nil {
+		respondProblem(h.logger, w, r, fmt.Sprintf("Invalid update song request %+v:", updatedSong), err)
 		return
 	}
 
 	// Добавляем songID в объект песни, чтобы обновить правильную запись
-	updatedSong.ID = songID
+	song := updatedSong.toModel()
+	song.ID = songID
 
-	h.logger.Debug("Request to update song", updatedSong.ID)
+	h.logger.WithContext(r.Context()).Debug("Request to update song", song.ID)
 
 	// Вызов сервиса для обновления песни
-	err = h.musicService.UpdateSong(r.Context(), updatedSong)
-	if err != nil {
-		h.logger.Error("Failed to update song:", err)
-		if err == catalog_errors.ErrSongNotFound {
-			http.Error(w, "Song not found", http.StatusNotFound)
-		} else {
-			http.Error(w, "Failed to update song", http.StatusInternalServerError)
-		}
+	err = h.musicService.UpdateSong(r.Context(), song)
+	// v1 сохраняет прежнее поведение: обновление несуществующей песни — успех
+	if err != nil && !errors.Is(err, catalog_errors.ErrSongNotFound) {
+		respondProblem(h.logger, w, r, "Failed to update song:", err)
 		return
 	}
 
-	h.logger.Info("Song updated successfully")
+	h.logger.WithContext(r.Context()).Info("Song updated successfully")
 	// Ответ на успешное обновление
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("Song updated successfully"))
 }
 
+// @Summary Partially update a song by its ID
+// @Description Update only the provided fields of an existing song.
+// @Tags Songs
+// @Accept  json
+// @Produce plain
+// @Param id path int true "Song ID"
+// @Param song body PatchSongRequest true "Fields to update"
+// @Success 200 {string} string "Song updated successfully"
+// @Failure 400 {object} Problem "Invalid request"
+// @Failure 404 {object} Problem "Song not found"
+// @Failure 500 {object} Problem "Internal server error"
+// @Failure 401 {object} Problem "Authentication required"
+// @Failure 403 {object} Problem "Insufficient role"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id} [patch]
+func (h *SongHandler) PatchSong(w http.ResponseWriter, r *http.Request) {
+	songID, err := parseSongID(r)
+	if err != nil {
+		respondProblem(h.logger, w, r, "Invalid song ID:", err)
+		return
+	}
+
+	var patch PatchSongRequest
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		respondMalformedBody(h.logger, w, r, err)
+		return
+	}
+
+	if err := patch.Validate(); err != nil {
+		respondProblem(h.logger, w, r, "Invalid patch song request:", err)
+		return
+	}
+
+	h.logger.WithContext(r.Context()).Debug("Request to patch song", songID)
+
+	err = h.musicService.PatchSong(r.Context(), songID, patch.toModel())
+	if err != nil {
+		respondProblem(h.logger, w, r, "Failed to patch song:", err)
+		return
+	}
+
+	h.logger.WithContext(r.Context()).Info("Song patched successfully")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Song updated successfully"))
+}
+
 // AddSongRequest данные из запроса для добавления песни в каталог
 type AddSongRequest struct {
 	Group string `json:"group" example:"Muse"`                   // Artist group
 	Title string `json:"song" example:"Supermassive Black Hole"` // Song title
 }
 
+// Validate нормализует поля запроса и проверяет их, возвращая все нарушения сразу
+func (req *AddSongRequest) Validate() error {
+	v := validation.New()
+	v.Check("group", &req.Group, validation.SongGroup)
+	v.Check("song", &req.Title, validation.SongTitle)
+	return v.Err()
+}
+
 // UpdateSongRequest - модель для обновления песни
 type UpdateSongRequest struct {
 	Group       string `json:"group" example:"Muse"`
@@ -295,75 +351,109 @@ type UpdateSongRequest struct {
 	ReleaseDate string `json:"release_date" example:"16.07.2006"`
 }
 
-// parseRequestParams извлекает параметры из запроса и возвращает их в виде структур.
-func parseRequestParams(r *http.Request) (models.SongFilters, models.Pagination, error) {
-	filters := models.SongFilters{}
-	pagination := models.Pagination{}
+// Validate нормализует поля запроса и проверяет их, возвращая все нарушения сразу
+func (req *UpdateSongRequest) Validate() error {
+	v := validation.New()
+	v.Check("group", &req.Group, validation.SongGroup)
+	v.Check("title", &req.Title, validation.SongTitle)
+	v.Check("text", &req.Text, validation.SongText)
+	v.Check("link", &req.Link, validation.SongLink)
+	v.Check("release_date", &req.ReleaseDate, validation.SongReleaseDate)
+	return v.Err()
+}
 
-	// Извлечение фильтров
-	group := r.URL.Query().Get("group")
-	if group != "" {
-		filters.Group = group
+func (req *UpdateSongRequest) toModel() models.Song {
+	return models.Song{
+		Group:       req.Group,
+		Title:       req.Title,
+		Text:        req.Text,
+		Link:        req.Link,
+		ReleaseDate: req.ReleaseDate,
 	}
+}
+
+// PatchSongRequest - модель для частичного обновления песни: отсутствующие поля не изменяются
+type PatchSongRequest struct {
+	Group       *string `json:"group,omitempty" example:"Muse"`
+	Title       *string `json:"title,omitempty" example:"Supermassive Black Hole"`
+	Text        *string `json:"text,omitempty" example:"Ooh baby, don't you know I suffer..."`
+	Link        *string `json:"link,omitempty" example:"https://www.youtube.com/watch?v=Xsp3_a-PMTw"`
+	ReleaseDate *string `json:"release_date,omitempty" example:"16.07.2006"`
+}
 
-	title := r.URL.Query().Get("title")
-	if title != "" {
-		filters.Title = title
+// Validate нормализует переданные поля и проверяет их, возвращая все нарушения сразу
+func (req *PatchSongRequest) Validate() error {
+	v := validation.New()
+	v.CheckOptional("group", req.Group, validation.SongGroup)
+	v.CheckOptional("title", req.Title, validation.SongTitle)
+	v.CheckOptional("text", req.Text, validation.SongText)
+	v.CheckOptional("link", req.Link, validation.SongLink)
+	v.CheckOptional("release_date", req.ReleaseDate, validation.SongReleaseDate)
+	if req.Group == nil && req.Title == nil && req.Text == nil && req.Link == nil && req.ReleaseDate == nil {
+		v.AddError("body", "at least one field must be provided")
 	}
+	return v.Err()
+}
 
-	releaseDate := r.URL.Query().Get("release_date")
-	if releaseDate != "" {
-		parseDate, err := ParseDate(releaseDate)
-		if err != nil {
-			return filters, pagination, err
-		}
-		filters.ReleaseDate = parseDate
+func (req *PatchSongRequest) toModel() models.SongPatch {
+	return models.SongPatch{
+		Group:       req.Group,
+		Title:       req.Title,
+		Text:        req.Text,
+		Link:        req.Link,
+		ReleaseDate: req.ReleaseDate,
 	}
+}
+
+// parseRequestParams извлекает параметры из запроса и возвращает их в виде структур.
+func parseRequestParams(r *http.Request) (models.SongFilters, models.Pagination, error) {
+	query := r.URL.Query()
+	filters := models.SongFilters{
+		Group:       query.Get("group"),
+		Title:       query.Get("title"),
+		ReleaseDate: query.Get("release_date"),
+	}
+
+	// Извлечение фильтров
+	v := validation.New()
+	v.Check("group", &filters.Group, validation.FilterText)
+	v.Check("title", &filters.Title, validation.FilterText)
+	v.Check("release_date", &filters.ReleaseDate, validation.FilterDate)
 
 	// Извлечение пагинации
-	limitStr := r.URL.Query().Get("limit")
+	pagination := parsePagination(query, v)
+
+	return filters, pagination, v.Err()
+}
+
+// parsePagination извлекает параметры limit и offset, добавляя ошибки в валидатор
+func parsePagination(query url.Values, v *validation.Validator) models.Pagination {
+	pagination := models.Pagination{}
+	limitStr := query.Get("limit")
 	if limitStr != "" {
 		limit, err := strconv.Atoi(limitStr)
 		if err != nil || limit < 1 {
-			return filters, pagination, errors.New("invalid limit parameter")
+			v.AddError("limit", "must be a positive integer")
 		}
 		pagination.Limit = limit
 	}
 
-	offsetStr := r.URL.Query().Get("offset")
+	offsetStr := query.Get("offset")
 	if offsetStr != "" {
 		offset, err := strconv.Atoi(offsetStr)
 		if err != nil || offset < 0 {
-			return filters, pagination, errors.New("invalid offset parameter")
+			v.AddError("offset", "must be a non-negative integer")
 		}
 		pagination.Offset = offset
 	}
-
-	return filters, pagination, nil
+	return pagination
 }
 
-// ParseDate пытается разобрать дату в одном из поддерживаемых форматов
-func ParseDate(dateStr string) (string, error) {
-	var parsedDate time.Time
-	var err error
-
-	// Перебор всех возможных форматов
-	for _, layout := range dateFormats {
-		parsedDate, err = time.Parse(layout, dateStr)
-		if err == nil {
-			// Если дата успешно разобрана в одном из форматов — возвращаем её
-			return parsedDate.Format("2006-01-02"), nil
-		}
+// parseSongID извлекает ID песни из параметров URL
+func parseSongID(r *http.Request) (int, error) {
+	songID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		return 0, catalog_errors.NewValidationError(catalog_errors.FieldError{Field: "id", Message: "must be an integer"})
 	}
-
-	// Если ни один формат не подошел, возвращаем ошибку
-	return "", fmt.Errorf("не удалось распознать формат даты: %s", dateStr)
-}
-
-// Список возможных форматов дат
-var dateFormats = []string{
-	"02.01.2006",      // Формат "DD.MM.YYYY"
-	"2006-01-02",      // Формат "YYYY-MM-DD"
-	"January 2, 2006", // Формат "January 2, 2006"
-	time.RFC3339,      // ISO 8601 формат (например, "2006-01-02T15:04:05Z07:00")
+	return songID, nil
 }
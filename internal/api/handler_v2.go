@@ -0,0 +1,391 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"music_catalog/internal/logger"
+	"music_catalog/internal/models"
+	"music_catalog/internal/validation"
+)
+
+// SongHandlerV2 обрабатывает запросы API v2: ответы оборачиваются в конверт {"data", "meta"},
+// а изменяющие операции возвращают актуальное состояние песни вместе с её ID
+type SongHandlerV2 struct {
+	*SongHandler
+}
+
+// NewSongHandlerV2 creates a new SongHandlerV2 with the provided music service
+func NewSongHandlerV2(musicService MusicService, logger logger.Logger) *SongHandlerV2 {
+	return &SongHandlerV2{SongHandler: NewSongHandler(musicService, logger)}
+}
+
+// SongEnvelope — ответ v2 с одной песней
+type SongEnvelope struct {
+	Data models.Song `json:"data"`
+}
+
+// SongListEnvelope — ответ v2 со списком песен и параметрами пагинации
+type SongListEnvelope struct {
+	Data []models.Song `json:"data"`
+	Meta ListMeta      `json:"meta"`
+}
+
+// ListMeta — метаданные страницы списка
+type ListMeta struct {
+	Limit  int `json:"limit" example:"10"`
+	Offset int `json:"offset" example:"0"`
+	Count  int `json:"count" example:"1"`
+}
+
+// SongTextEnvelope — ответ v2 с текстом песни или одним куплетом
+type SongTextEnvelope struct {
+	Data SongText `json:"data"`
+}
+
+// SongText — текст песни; page = 0 означает полный текст
+type SongText struct {
+	SongID int    `json:"song_id" example:"1"`
+	Page   int    `json:"page" example:"1"`
+	Text   string `json:"text" example:"Ooh baby, don't you know I suffer..."`
+}
+
+// GetSongs fetches the list of songs with filtering and pagination
+// @Summary Get list of songs
+// @Description Fetches a list of songs with filtering by all fields and pagination. An empty page is returned as an empty list.
+// @Tags SongsV2
+// @Produce  json
+// @Param group query string false "Group name"
+// @Param title query string false "Song title"
+// @Param release_date query string false "Release date"
+// @Param limit query int false "Number of items per page"
+// @Param offset query int false "Pagination offset"
+// @Success 200 {object} SongListEnvelope "List of songs"
+// @Failure 400 {object} Problem "Invalid request parameters"
+// @Failure 500 {object} Problem "Error retrieving the data"
+// @Failure 401 {object} Problem "Authentication required"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs [get]
+func (h *SongHandlerV2) GetSongs(w http.ResponseWriter, r *http.Request) {
+	filters, pagination, err := parseRequestParams(r)
+	if err != nil {
+		respondProblem(h.logger, w, r, "Error parsing request parameters:", err)
+		return
+	}
+
+	if pagination.Limit == 0 {
+		pagination.Limit = 10
+	}
+
+	songs, err := h.musicService.GetSongs(r.Context(), filters, pagination)
+	if err != nil {
+		respondProblem(h.logger, w, r, "Error getting songs:", err)
+		return
+	}
+	if songs == nil {
+		songs = []models.Song{}
+	}
+
+	writeJSON(w, http.StatusOK, SongListEnvelope{
+		Data: songs,
+		Meta: ListMeta{Limit: pagination.Limit, Offset: pagination.Offset, Count: len(songs)},
+	})
+}
+
+// SearchSongs finds songs by words of their lyrics
+// @Summary Search songs by lyrics
+// @Description Full-text search over song lyrics: returns songs whose lyrics contain every word of the query (whole words, case-insensitive), most relevant first. Search operators are not supported; punctuation in the query is ignored.
+// @Tags SongsV2
+// @Produce  json
+// @Param q query string true "Words to search for"
+// @Param limit query int false "Number of items per page"
+// @Param offset query int false "Pagination offset"
+// @Success 200 {object} SongListEnvelope "Matching songs"
+// @Failure 400 {object} Problem "Invalid request parameters"
+// @Failure 500 {object} Problem "Error searching lyrics"
+// @Failure 401 {object} Problem "Authentication required"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/search [get]
+func (h *SongHandlerV2) SearchSongs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	text := query.Get("q")
+	v := validation.New()
+	v.Check("q", &text, validation.LyricsQuery)
+	pagination := parsePagination(query, v)
+	if err := v.Err(); err != nil {
+		respondProblem(h.logger, w, r, "Error parsing request parameters:", err)
+		return
+	}
+
+	if pagination.Limit == 0 {
+		pagination.Limit = 10
+	}
+
+	songs, err := h.musicService.SearchLyrics(r.Context(), text, pagination)
+	if err != nil {
+		respondProblem(h.logger, w, r, "Error searching lyrics:", err)
+		return
+	}
+	if songs == nil {
+		songs = []models.Song{}
+	}
+
+	writeJSON(w, http.StatusOK, SongListEnvelope{
+		Data: songs,
+		Meta: ListMeta{Limit: pagination.Limit, Offset: pagination.Offset, Count: len(songs)},
+	})
+}
+
+// GetSong fetches a single song by ID
+// @Summary Get a song
+// @Description Fetches a single song by its ID
+// @Tags SongsV2
+// @Produce  json
+// @Param id path int true "Song ID"
+// @Success 200 {object} SongEnvelope "Song"
+// @Failure 400 {object} Problem "Invalid song ID"
+// @Failure 404 {object} Problem "Song not found"
+// @Failure 500 {object} Problem "Error retrieving the song"
+// @Failure 401 {object} Problem "Authentication required"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id} [get]
+func (h *SongHandlerV2) GetSong(w http.ResponseWriter, r *http.Request) {
+	songID, err := parseSongID(r)
+	if err != nil {
+		respondProblem(h.logger, w, r, "Invalid song ID:", err)
+		return
+	}
+
+	song, err := h.musicService.GetSong(r.Context(), songID)
+	if err != nil {
+		respondProblem(h.logger, w, r, "Error getting song:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, SongEnvelope{Data: song})
+}
+
+// GetSongText fetches the song lyrics with pagination over verses
+// @Summary Get song lyrics with pagination
+// @Description Fetches the song lyrics with pagination over verses
+// @Tags SongsV2
+// @Produce  json
+// @Param id path int true "Song ID"
+// @Param page query int false "Page number (default: 0 - full text)"
+// @Success 200 {object} SongTextEnvelope "Lyrics page"
+// @Failure 400 {object} Problem "Invalid song ID or page"
+// @Failure 404 {object} Problem "Song not found"
+// @Failure 500 {object} Problem "Error retrieving lyrics"
+// @Failure 401 {object} Problem "Authentication required"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id}/text [get]
+func (h *SongHandlerV2) GetSongText(w http.ResponseWriter, r *http.Request) {
+	songID, err := parseSongID(r)
+	if err != nil {
+		respondProblem(h.logger, w, r, "Invalid song ID:", err)
+		return
+	}
+
+	page := 0
+	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
+		page, err = strconv.Atoi(pageStr)
+		if err != nil || page < 0 {
+			v := validation.New()
+			v.AddError("page", "must be a non-negative integer")
+			respondProblem(h.logger, w, r, "Invalid page:", v.Err())
+			return
+		}
+	}
+
+	text, err := h.musicService.GetSongText(r.Context(), songID, page)
+	if err != nil {
+		respondProblem(h.logger, w, r, "Error getting song text:", err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, SongTextEnvelope{Data: SongText{SongID: songID, Page: page, Text: text}})
+}
+
+// AddSong adds a new song to the catalog
+// @Summary Add a new song
+// @Description Adds a new song to the catalog, fetches additional details from an external API and returns the stored song
+// @Tags SongsV2
+// @Accept  json
+// @Produce  json
+// @Param song body AddSongRequest true "Song request"
+// @Param Idempotency-Key header string false "Client-generated key; retries with the same key replay the first response"
+// @Success 201 {object} SongEnvelope "Created song"
+// @Failure 400 {object} Problem "Invalid input"
+// @Failure 409 {object} Problem "Song already exists or a request with the same Idempotency-Key is in progress"
+// @Failure 422 {object} Problem "Idempotency-Key reused with a different request"
+// @Failure 500 {object} Problem "Error adding the song"
+// @Failure 502 {object} Problem "External API failure"
+// @Failure 401 {object} Problem "Authentication required"
+// @Failure 403 {object} Problem "Insufficient role"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs [post]
+func (h *SongHandlerV2) AddSong(w http.ResponseWriter, r *http.Request) {
+	var requestBody AddSongRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		respondMalformedBody(h.logger, w, r, err)
+		return
+	}
+
+	if err := requestBody.Validate(); err != nil {
+		respondProblem(h.logger, w, r, "Invalid add song request:", err)
+		return
+	}
+
+	song, err := h.musicService.AddSong(r.Context(), requestBody.Group, requestBody.Title)
+	if err != nil {
+		respondProblem(h.logger, w, r, "Error adding song:", err)
+		return
+	}
+
+	h.logger.WithContext(r.Context()).Info("Song added successfully: ", song.ID, song.Group, song.Title)
+	w.Header().Set("Location", fmt.Sprintf("%s/%d", r.URL.Path, song.ID))
+	writeJSON(w, http.StatusCreated, SongEnvelope{Data: song})
+}
+
+// UpdateSong replaces all fields of a song
+// @Summary Update a song by its ID
+// @Description Update an existing song with the provided data and return the updated song.
+// @Tags SongsV2
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Song ID"
+// @Param song body UpdateSongRequest true "Song data"
+// @Success 200 {object} SongEnvelope "Updated song"
+// @Failure 400 {object} Problem "Invalid request"
+// @Failure 404 {object} Problem "Song not found"
+// @Failure 500 {object} Problem "Internal server error"
+// @Failure 401 {object} Problem "Authentication required"
+// @Failure 403 {object} Problem "Insufficient role"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id} [put]
+func (h *SongHandlerV2) UpdateSong(w http.ResponseWriter, r *http.Request) {
+	songID, err := parseSongID(r)
+	if err != nil {
+		respondProblem(h.logger, w, r, "Invalid song ID:", err)
+		return
+	}
+
+	var requestBody UpdateSongRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		respondMalformedBody(h.logger, w, r, err)
+		return
+	}
+
+	if err := requestBody.Validate(); err != nil {
+		respondProblem(h.logger, w, r, "Invalid update song request:", err)
+		return
+	}
+
+	song := requestBody.toModel()
+	song.ID = songID
+	if err := h.musicService.UpdateSong(r.Context(), song); err != nil {
+		respondProblem(h.logger, w, r, "Failed to update song:", err)
+		return
+	}
+
+	h.writeSong(w, r, songID)
+}
+
+// PatchSong updates only the provided fields of a song
+// @Summary Partially update a song by its ID
+// @Description Update only the provided fields of an existing song and return the updated song.
+// @Tags SongsV2
+// @Accept  json
+// @Produce  json
+// @Param id path int true "Song ID"
+// @Param song body PatchSongRequest true "Fields to update"
+// @Success 200 {object} SongEnvelope "Updated song"
+// @Failure 400 {object} Problem "Invalid request"
+// @Failure 404 {object} Problem "Song not found"
+// @Failure 500 {object} Problem "Internal server error"
+// @Failure 401 {object} Problem "Authentication required"
+// @Failure 403 {object} Problem "Insufficient role"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id} [patch]
+func (h *SongHandlerV2) PatchSong(w http.ResponseWriter, r *http.Request) {
+	songID, err := parseSongID(r)
+	if err != nil {
+		respondProblem(h.logger, w, r, "Invalid song ID:", err)
+		return
+	}
+
+	var patch PatchSongRequest
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		respondMalformedBody(h.logger, w, r, err)
+		return
+	}
+
+	if err := patch.Validate(); err != nil {
+		respondProblem(h.logger, w, r, "Invalid patch song request:", err)
+		return
+	}
+
+	if err := h.musicService.PatchSong(r.Context(), songID, patch.toModel()); err != nil {
+		respondProblem(h.logger, w, r, "Failed to patch song:", err)
+		return
+	}
+
+	h.writeSong(w, r, songID)
+}
+
+// DeleteSong removes a song by ID
+// @Summary Delete a song
+// @Description Deletes a song by its ID
+// @Tags SongsV2
+// @Param id path int true "Song ID"
+// @Success 204 "Song deleted successfully"
+// @Failure 400 {object} Problem "Invalid song ID"
+// @Failure 404 {object} Problem "Song not found"
+// @Failure 500 {object} Problem "Error deleting the song"
+// @Failure 401 {object} Problem "Authentication required"
+// @Failure 403 {object} Problem "Insufficient role"
+// @Security ApiKeyAuth
+// @Security BearerAuth
+// @Router /songs/{id} [delete]
+func (h *SongHandlerV2) DeleteSong(w http.ResponseWriter, r *http.Request) {
+	songID, err := parseSongID(r)
+	if err != nil {
+		respondProblem(h.logger, w, r, "Invalid song ID:", err)
+		return
+	}
+
+	// В v2 несуществующая песня — это 404, а не тихий успех
+	if err := h.musicService.DeleteSong(r.Context(), songID); err != nil {
+		respondProblem(h.logger, w, r, "Error deleting song:", err)
+		return
+	}
+
+	h.logger.WithContext(r.Context()).Info("Song deleted successfully")
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeSong перечитывает песню после изменения и отправляет её в конверте
+func (h *SongHandlerV2) writeSong(w http.ResponseWriter, r *http.Request, songID int) {
+	song, err := h.musicService.GetSong(r.Context(), songID)
+	if err != nil {
+		respondProblem(h.logger, w, r, "Error getting song:", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, SongEnvelope{Data: song})
+}
+
+// writeJSON отправляет клиенту JSON-ответ с указанным статусом
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
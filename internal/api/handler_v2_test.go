@@ -0,0 +1,62 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	catalog_errors "music_catalog/internal/errors"
+	"music_catalog/internal/logger"
+	"music_catalog/internal/models"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// missingSongService отвечает на любое обращение к песне так, будто её нет в каталоге
+type missingSongService struct {
+	MusicService
+}
+
+func (missingSongService) GetSong(ctx context.Context, id int) (models.Song, error) {
+	return models.Song{}, catalog_errors.ErrSongNotFound
+}
+
+func (missingSongService) UpdateSong(ctx context.Context, song models.Song) error {
+	return catalog_errors.ErrSongNotFound
+}
+
+func (missingSongService) DeleteSong(ctx context.Context, id int) error {
+	return catalog_errors.ErrSongNotFound
+}
+
+func TestMissingSongByVersion(t *testing.T) {
+	v1 := NewSongHandler(missingSongService{}, logger.NewLogger("error"))
+	v2 := NewSongHandlerV2(missingSongService{}, logger.NewLogger("error"))
+	body := `{"group":"Muse","title":"Uprising","release_date":"16.07.2009","text":"Paranoia is in bloom","link":"https://example.com"}`
+
+	tests := []struct {
+		name    string
+		method  string
+		handler http.HandlerFunc
+		want    int
+	}{
+		{"v1 delete keeps silent success", http.MethodDelete, v1.DeleteSong, http.StatusNoContent},
+		{"v1 update keeps silent success", http.MethodPut, v1.UpdateSong, http.StatusOK},
+		{"v2 delete", http.MethodDelete, v2.DeleteSong, http.StatusNotFound},
+		{"v2 update", http.MethodPut, v2.UpdateSong, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := chi.NewRouter()
+			router.Method(tt.method, "/songs/{id}", tt.handler)
+			r := httptest.NewRequest(tt.method, "/songs/42", strings.NewReader(body))
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
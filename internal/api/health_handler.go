@@ -0,0 +1,82 @@
+package api
+
+import (
+	"database/sql"
+	"net/http"
+
+	"music_catalog/internal/auth"
+	"music_catalog/internal/buildinfo"
+	"music_catalog/internal/health"
+	"music_catalog/internal/logger"
+)
+
+// HealthHandler отдаёт пробы для оркестратора и диагностическую информацию для администраторов
+type HealthHandler struct {
+	checker   *health.Checker
+	config    func() any         // действующая конфигурация с замаскированными секретами
+	reload    func() any         // номер версии конфигурации и результат последней перезагрузки
+	poolStats func() sql.DBStats // статистика пула соединений с базой
+	logger    logger.Logger
+}
+
+// NewHealthHandler creates a new HealthHandler; config must return the configuration with its secrets
+// already redacted
+func NewHealthHandler(checker *health.Checker, config func() any, reload func() any, poolStats func() sql.DBStats, logger logger.Logger) *HealthHandler {
+	return &HealthHandler{checker: checker, config: config, reload: reload, poolStats: poolStats, logger: logger}
+}
+
+// DebugInfo — диагностическая информация о запущенном экземпляре
+type DebugInfo struct {
+	Build        buildinfo.Info `json:"build"`
+	Config       any            `json:"config"`
+	ConfigReload any            `json:"config_reload"` // generation, loaded_at, pending_restart, last_error
+	Pool         sql.DBStats    `json:"pool"`
+	Readiness    health.Report  `json:"readiness"` // проверки готовности с ошибками и подробностями
+}
+
+// DebugInfoEnvelope — ответ /debug/info
+type DebugInfoEnvelope struct {
+	Data DebugInfo `json:"data"`
+}
+
+// Healthz reports that the process is alive (GET /healthz). It does not touch dependencies:
+// a failing database must not make the orchestrator restart healthy instances.
+func (h *HealthHandler) Healthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": health.StatusOK})
+}
+
+// Readyz runs the readiness checks (GET /readyz) and answers 503 when a critical one fails.
+// The probe is public, so the response carries only the status of each check: errors and details
+// go to the log and to /debug/info.
+func (h *HealthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	report := h.checker.Run(r.Context())
+	status := http.StatusOK
+	switch {
+	case !report.Ready():
+		h.logger.WithContext(r.Context()).Error("Readiness check failed:", report.Checks)
+		status = http.StatusServiceUnavailable
+	case report.Status != health.StatusOK:
+		h.logger.WithContext(r.Context()).Info("Readiness degraded:", report.Checks)
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, status, report.Summary())
+}
+
+// DebugInfo returns the build version, the redacted configuration with its reload generation,
+// pool stats and the full readiness report (GET /debug/info).
+// The configuration is platform-wide, so only platform administrators may read it.
+func (h *HealthHandler) DebugInfo(w http.ResponseWriter, r *http.Request) {
+	if err := auth.AuthorizePlatformAdmin(r.Context()); err != nil {
+		h.logger.WithContext(r.Context()).Info("Debug info denied:", err)
+		writeProblem(w, r, problemFromError(err))
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, DebugInfoEnvelope{Data: DebugInfo{
+		Build:        buildinfo.Get(),
+		Config:       h.config(),
+		ConfigReload: h.reload(),
+		Pool:         h.poolStats(),
+		Readiness:    h.checker.Run(r.Context()),
+	}})
+}
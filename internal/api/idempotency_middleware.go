@@ -0,0 +1,129 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"music_catalog/internal/auth"
+	catalog_errors "music_catalog/internal/errors"
+	"music_catalog/internal/idempotency"
+	"music_catalog/internal/logger"
+	"music_catalog/internal/tenant"
+	"music_catalog/internal/validation"
+)
+
+// idempotent выполняет запрос с заголовком Idempotency-Key не более одного раза: ответ сохраняется
+// на ttl() и воспроизводится для повторов с тем же ключом (с заголовком Idempotent-Replayed: true).
+// Повтор ключа с другим телом или путём отклоняется (422), повтор во время выполнения первого
+// запроса — 409. Ответы 5xx не сохраняются, и запрос можно повторить с тем же ключом.
+// Запросы без заголовка выполняются как обычно. Срок хранения запрашивается для каждого запроса,
+// поэтому его можно менять без перезапуска.
+func idempotent(store idempotency.Store, ttl func() time.Duration, logger logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(idempotency.Header)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			v := validation.New()
+			v.Check(idempotency.Header, &key, validation.IdempotencyKey)
+			if err := v.Err(); err != nil {
+				respondProblem(logger, w, r, "Invalid idempotency key:", err)
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				writeProblem(w, r, malformedBodyProblem(err))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			scope := idempotency.Scope{TenantID: tenant.IDFromContext(r.Context()), Principal: "anonymous", Key: key}
+			if principal, ok := auth.FromContext(r.Context()); ok {
+				scope.Principal = principal.ID
+			}
+			hash := idempotency.HashRequest(r.Method, r.URL.RequestURI(), body)
+
+			record, created, err := store.Begin(r.Context(), scope, hash, ttl())
+			if err != nil {
+				respondProblem(logger, w, r, "Error reserving idempotency key:", err)
+				return
+			}
+			if !created {
+				switch {
+				case record.RequestHash != hash:
+					respondProblem(logger, w, r, "Idempotency key reused:", catalog_errors.ErrIdempotencyKeyReused)
+				case record.Response == nil:
+					w.Header().Set("Retry-After", "1")
+					respondProblem(logger, w, r, "Idempotent request in progress:", catalog_errors.ErrIdempotencyKeyInProgress)
+				default:
+					replayResponse(w, *record.Response)
+				}
+				return
+			}
+
+			recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			// Ключ освобождается и при панике обработчика, иначе повторы получали бы 409 до истечения Lease
+			completed := false
+			defer func() {
+				if !completed {
+					store.Release(context.WithoutCancel(r.Context()), scope)
+				}
+			}()
+			next.ServeHTTP(recorder, r)
+
+			ctx := context.WithoutCancel(r.Context())
+			if recorder.status >= http.StatusInternalServerError {
+				return
+			}
+			response := idempotency.Response{StatusCode: recorder.status, Header: http.Header{}, Body: recorder.body.Bytes()}
+			for _, name := range idempotency.StoredHeaders {
+				if value := w.Header().Get(name); value != "" {
+					response.Header.Set(name, value)
+				}
+			}
+			if err := store.Complete(ctx, scope, response); err != nil {
+				logger.WithContext(ctx).Error("Error saving idempotent response:", err)
+				return
+			}
+			completed = true
+		})
+	}
+}
+
+// replayResponse воспроизводит сохранённый ответ
+func replayResponse(w http.ResponseWriter, response idempotency.Response) {
+	for name, values := range response.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(idempotency.ReplayedHeader, "true")
+	w.WriteHeader(response.StatusCode)
+	w.Write(response.Body)
+}
+
+// responseRecorder передаёт ответ клиенту, запоминая статус и тело
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(p)
+	return r.ResponseWriter.Write(p)
+}
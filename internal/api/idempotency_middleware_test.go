@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"music_catalog/internal/idempotency"
+	"music_catalog/internal/logger"
+	"music_catalog/internal/tenant"
+)
+
+func TestIdempotent(t *testing.T) {
+	store := idempotency.NewMemoryStore()
+	// Ключ "busy" занят запросом, который ещё выполняется
+	busy := idempotency.Scope{TenantID: tenant.IDFromContext(context.Background()), Principal: "anonymous", Key: "busy"}
+	if _, _, err := store.Begin(context.Background(), busy, idempotency.HashRequest(http.MethodPost, "/api/v2/songs", []byte(`{"n":1}`)), time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	executed := 0
+	handler := idempotent(store, func() time.Duration { return time.Hour }, logger.NewLogger("error"))(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			executed++
+			body, _ := io.ReadAll(r.Body)
+			if string(body) == "fail" {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Location", "/api/v2/songs/1")
+			w.WriteHeader(http.StatusCreated)
+			w.Write(body)
+		}))
+
+	// Шаги выполняются по порядку над одним хранилищем
+	steps := []struct {
+		name         string
+		key          string
+		path         string
+		body         string
+		wantStatus   int
+		wantExecuted bool
+		wantReplayed bool
+	}{
+		{"no key", "", "/api/v2/songs", `{"n":1}`, http.StatusCreated, true, false},
+		{"first request", "k1", "/api/v2/songs", `{"n":1}`, http.StatusCreated, true, false},
+		{"replay", "k1", "/api/v2/songs", `{"n":1}`, http.StatusCreated, false, true},
+		{"reused with a different body", "k1", "/api/v2/songs", `{"n":2}`, http.StatusUnprocessableEntity, false, false},
+		{"reused on a different path", "k1", "/api/v2/playlists", `{"n":1}`, http.StatusUnprocessableEntity, false, false},
+		{"in progress", "busy", "/api/v2/songs", `{"n":1}`, http.StatusConflict, false, false},
+		{"server error", "k2", "/api/v2/songs", "fail", http.StatusInternalServerError, true, false},
+		{"retry after server error", "k2", "/api/v2/songs", "fail", http.StatusInternalServerError, true, false},
+		{"invalid key", strings.Repeat("k", 256), "/api/v2/songs", `{"n":1}`, http.StatusBadRequest, false, false},
+	}
+	for _, tt := range steps {
+		t.Run(tt.name, func(t *testing.T) {
+			before := executed
+			r := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			if tt.key != "" {
+				r.Header.Set(idempotency.Header, tt.key)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d; body: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if got := executed > before; got != tt.wantExecuted {
+				t.Errorf("handler executed = %v, want %v", got, tt.wantExecuted)
+			}
+			if got := w.Header().Get(idempotency.ReplayedHeader) == "true"; got != tt.wantReplayed {
+				t.Errorf("%s = %q, want replayed %v", idempotency.ReplayedHeader, w.Header().Get(idempotency.ReplayedHeader), tt.wantReplayed)
+			}
+			if tt.wantReplayed {
+				if w.Body.String() != tt.body || w.Header().Get("Location") != "/api/v2/songs/1" {
+					t.Errorf("replayed response = %q, Location %q", w.Body.String(), w.Header().Get("Location"))
+				}
+			}
+			if tt.wantStatus == http.StatusConflict && w.Header().Get("Retry-After") != "1" {
+				t.Errorf("Retry-After = %q, want 1", w.Header().Get("Retry-After"))
+			}
+		})
+	}
+}
@@ -0,0 +1,460 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"music_catalog/internal/export"
+	"music_catalog/internal/logger"
+	"music_catalog/internal/models"
+	"music_catalog/internal/validation"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// LibraryService интерфейс для работы с избранным и плейлистами пользователя
+type LibraryService interface {
+	CurrentUser(ctx context.Context) (models.User, error)
+	GetFavorites(ctx context.Context, pagination models.Pagination) ([]models.Favorite, error)
+	AddFavorite(ctx context.Context, songID int) error
+	RemoveFavorite(ctx context.Context, songID int) error
+	CreatePlaylist(ctx context.Context, playlist models.Playlist) (models.Playlist, error)
+	GetPlaylists(ctx context.Context, pagination models.Pagination) ([]models.Playlist, error)
+	GetPlaylist(ctx context.Context, id int) (models.Playlist, error)
+	GetSharedPlaylist(ctx context.Context, token string) (models.Playlist, error)
+	UpdatePlaylist(ctx context.Context, id int, patch models.PlaylistPatch) (models.Playlist, error)
+	DeletePlaylist(ctx context.Context, id int) error
+	AddPlaylistItem(ctx context.Context, playlistID int, songID int, position int) (models.Playlist, error)
+	MovePlaylistItem(ctx context.Context, playlistID int, itemID int64, position int) (models.Playlist, error)
+	RemovePlaylistItem(ctx context.Context, playlistID int, itemID int64) (models.Playlist, error)
+	ReorderPlaylistItems(ctx context.Context, playlistID int, itemIDs []int64) (models.Playlist, error)
+}
+
+// LibraryHandler обрабатывает запросы к избранному и плейлистам; ответы в формате конвертов v2
+type LibraryHandler struct {
+	libraryService LibraryService
+	logger         logger.Logger
+}
+
+// NewLibraryHandler creates a new LibraryHandler with the provided library service
+func NewLibraryHandler(libraryService LibraryService, logger logger.Logger) *LibraryHandler {
+	return &LibraryHandler{libraryService: libraryService, logger: logger}
+}
+
+// UserEnvelope — ответ с профилем пользователя
+type UserEnvelope struct {
+	Data models.User `json:"data"`
+}
+
+// FavoriteListEnvelope — ответ со списком избранного
+type FavoriteListEnvelope struct {
+	Data []models.Favorite `json:"data"`
+	Meta ListMeta          `json:"meta"`
+}
+
+// PlaylistEnvelope — ответ с одним плейлистом
+type PlaylistEnvelope struct {
+	Data models.Playlist `json:"data"`
+}
+
+// PlaylistListEnvelope — ответ со списком плейлистов
+type PlaylistListEnvelope struct {
+	Data []models.Playlist `json:"data"`
+	Meta ListMeta          `json:"meta"`
+}
+
+// PlaylistRequest — тело запроса на создание плейлиста
+type PlaylistRequest struct {
+	Name        string `json:"name" example:"Road trip"`
+	Description string `json:"description" example:"Songs for the long drive"`
+	Public      bool   `json:"public" example:"false"`
+}
+
+// PlaylistPatchRequest — тело запроса на изменение плейлиста; переданы только изменяемые поля
+type PlaylistPatchRequest struct {
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+	Public      *bool   `json:"public,omitempty"`
+}
+
+// PlaylistItemRequest — тело запроса на добавление трека; без position трек добавляется в конец
+type PlaylistItemRequest struct {
+	SongID   int `json:"song_id" example:"1"`
+	Position int `json:"position,omitempty" example:"1"`
+}
+
+// PlaylistItemMoveRequest — тело запроса на перемещение трека
+type PlaylistItemMoveRequest struct {
+	Position int `json:"position" example:"1"`
+}
+
+// PlaylistOrderRequest — тело запроса на изменение порядка всех треков
+type PlaylistOrderRequest struct {
+	ItemIDs []int64 `json:"item_ids"`
+}
+
+// RegisterRoutes регистрирует маршруты избранного и плейлистов текущего пользователя
+func (h *LibraryHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/me", h.GetCurrentUser)
+	r.Get("/me/favorites", h.GetFavorites)
+	r.Put("/me/favorites/{songID}", h.AddFavorite)
+	r.Delete("/me/favorites/{songID}", h.RemoveFavorite)
+	r.Get("/me/playlists", h.GetPlaylists)
+	r.Post("/me/playlists", h.CreatePlaylist)
+
+	r.Get("/playlists/{id}", h.GetPlaylist)
+	r.Patch("/playlists/{id}", h.UpdatePlaylist)
+	r.Delete("/playlists/{id}", h.DeletePlaylist)
+	r.Get("/playlists/{id}/export", h.ExportPlaylist)
+	r.Post("/playlists/{id}/items", h.AddPlaylistItem)
+	r.Put("/playlists/{id}/items/order", h.ReorderPlaylistItems)
+	r.Patch("/playlists/{id}/items/{itemID}", h.MovePlaylistItem)
+	r.Delete("/playlists/{id}/items/{itemID}", h.RemovePlaylistItem)
+}
+
+// RegisterSharedRoutes регистрирует маршруты опубликованных плейлистов, доступные без аутентификации
+func (h *LibraryHandler) RegisterSharedRoutes(r chi.Router) {
+	r.Get("/shared/playlists/{token}", h.GetSharedPlaylist)
+	r.Get("/shared/playlists/{token}/export", h.ExportSharedPlaylist)
+}
+
+// GetCurrentUser returns the profile of the authenticated user (GET /me)
+func (h *LibraryHandler) GetCurrentUser(w http.ResponseWriter, r *http.Request) {
+	user, err := h.libraryService.CurrentUser(r.Context())
+	if err != nil {
+		respondProblem(h.logger, w, r, "Error getting current user:", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, UserEnvelope{Data: user})
+}
+
+// GetFavorites lists favorite songs, most recently added first (GET /me/favorites)
+func (h *LibraryHandler) GetFavorites(w http.ResponseWriter, r *http.Request) {
+	pagination, ok := h.parsePagination(w, r)
+	if !ok {
+		return
+	}
+
+	favorites, err := h.libraryService.GetFavorites(r.Context(), pagination)
+	if err != nil {
+		respondProblem(h.logger, w, r, "Error getting favorites:", err)
+		return
+	}
+	if favorites == nil {
+		favorites = []models.Favorite{}
+	}
+
+	writeJSON(w, http.StatusOK, FavoriteListEnvelope{
+		Data: favorites,
+		Meta: ListMeta{Limit: pagination.Limit, Offset: pagination.Offset, Count: len(favorites)},
+	})
+}
+
+// AddFavorite adds a song to favorites (PUT /me/favorites/{songID})
+func (h *LibraryHandler) AddFavorite(w http.ResponseWriter, r *http.Request) {
+	songID, err := parseIDParam(r, "songID")
+	if err != nil {
+		respondProblem(h.logger, w, r, "Invalid song ID:", err)
+		return
+	}
+	if err := h.libraryService.AddFavorite(r.Context(), songID); err != nil {
+		respondProblem(h.logger, w, r, "Error adding favorite:", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RemoveFavorite removes a song from favorites (DELETE /me/favorites/{songID})
+func (h *LibraryHandler) RemoveFavorite(w http.ResponseWriter, r *http.Request) {
+	songID, err := parseIDParam(r, "songID")
+	if err != nil {
+		respondProblem(h.logger, w, r, "Invalid song ID:", err)
+		return
+	}
+	if err := h.libraryService.RemoveFavorite(r.Context(), songID); err != nil {
+		respondProblem(h.logger, w, r, "Error removing favorite:", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetPlaylists lists playlists of the current user (GET /me/playlists)
+func (h *LibraryHandler) GetPlaylists(w http.ResponseWriter, r *http.Request) {
+	pagination, ok := h.parsePagination(w, r)
+	if !ok {
+		return
+	}
+
+	playlists, err := h.libraryService.GetPlaylists(r.Context(), pagination)
+	if err != nil {
+		respondProblem(h.logger, w, r, "Error getting playlists:", err)
+		return
+	}
+	if playlists == nil {
+		playlists = []models.Playlist{}
+	}
+
+	writeJSON(w, http.StatusOK, PlaylistListEnvelope{
+		Data: playlists,
+		Meta: ListMeta{Limit: pagination.Limit, Offset: pagination.Offset, Count: len(playlists)},
+	})
+}
+
+// CreatePlaylist creates a playlist (POST /me/playlists)
+func (h *LibraryHandler) CreatePlaylist(w http.ResponseWriter, r *http.Request) {
+	var requestBody PlaylistRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		respondMalformedBody(h.logger, w, r, err)
+		return
+	}
+
+	playlist, err := h.libraryService.CreatePlaylist(r.Context(), models.Playlist{
+		Name:        requestBody.Name,
+		Description: requestBody.Description,
+		Public:      requestBody.Public,
+	})
+	if err != nil {
+		respondProblem(h.logger, w, r, "Error creating playlist:", err)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/playlists/%d", playlist.ID))
+	writeJSON(w, http.StatusCreated, PlaylistEnvelope{Data: playlist})
+}
+
+// GetPlaylist returns a playlist with its tracks; other users' playlists are visible only when public (GET /playlists/{id})
+func (h *LibraryHandler) GetPlaylist(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		respondProblem(h.logger, w, r, "Invalid playlist ID:", err)
+		return
+	}
+	playlist, err := h.libraryService.GetPlaylist(r.Context(), id)
+	if err != nil {
+		respondProblem(h.logger, w, r, "Error getting playlist:", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, PlaylistEnvelope{Data: playlist})
+}
+
+// UpdatePlaylist renames a playlist or changes its visibility (PATCH /playlists/{id}).
+// Making a playlist public returns its share_token for /shared/playlists/{token}.
+func (h *LibraryHandler) UpdatePlaylist(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		respondProblem(h.logger, w, r, "Invalid playlist ID:", err)
+		return
+	}
+
+	var requestBody PlaylistPatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		respondMalformedBody(h.logger, w, r, err)
+		return
+	}
+
+	playlist, err := h.libraryService.UpdatePlaylist(r.Context(), id, models.PlaylistPatch{
+		Name:        requestBody.Name,
+		Description: requestBody.Description,
+		Public:      requestBody.Public,
+	})
+	if err != nil {
+		respondProblem(h.logger, w, r, "Error updating playlist:", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, PlaylistEnvelope{Data: playlist})
+}
+
+// DeletePlaylist deletes a playlist (DELETE /playlists/{id})
+func (h *LibraryHandler) DeletePlaylist(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		respondProblem(h.logger, w, r, "Invalid playlist ID:", err)
+		return
+	}
+	if err := h.libraryService.DeletePlaylist(r.Context(), id); err != nil {
+		respondProblem(h.logger, w, r, "Error deleting playlist:", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// AddPlaylistItem inserts a song into a playlist (POST /playlists/{id}/items) and returns the updated playlist
+func (h *LibraryHandler) AddPlaylistItem(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		respondProblem(h.logger, w, r, "Invalid playlist ID:", err)
+		return
+	}
+
+	var requestBody PlaylistItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		respondMalformedBody(h.logger, w, r, err)
+		return
+	}
+	v := validation.New()
+	if requestBody.SongID < 1 {
+		v.AddError("song_id", "must be a positive integer")
+	}
+	if requestBody.Position < 0 {
+		v.AddError("position", "must be a positive integer")
+	}
+	if err := v.Err(); err != nil {
+		respondProblem(h.logger, w, r, "Invalid playlist item request:", err)
+		return
+	}
+
+	playlist, err := h.libraryService.AddPlaylistItem(r.Context(), id, requestBody.SongID, requestBody.Position)
+	if err != nil {
+		respondProblem(h.logger, w, r, "Error adding playlist item:", err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, PlaylistEnvelope{Data: playlist})
+}
+
+// MovePlaylistItem moves a track to another position (PATCH /playlists/{id}/items/{itemID})
+func (h *LibraryHandler) MovePlaylistItem(w http.ResponseWriter, r *http.Request) {
+	id, itemID, ok := h.parseItemParams(w, r)
+	if !ok {
+		return
+	}
+
+	var requestBody PlaylistItemMoveRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		respondMalformedBody(h.logger, w, r, err)
+		return
+	}
+
+	playlist, err := h.libraryService.MovePlaylistItem(r.Context(), id, itemID, requestBody.Position)
+	if err != nil {
+		respondProblem(h.logger, w, r, "Error moving playlist item:", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, PlaylistEnvelope{Data: playlist})
+}
+
+// RemovePlaylistItem removes a track from a playlist (DELETE /playlists/{id}/items/{itemID})
+func (h *LibraryHandler) RemovePlaylistItem(w http.ResponseWriter, r *http.Request) {
+	id, itemID, ok := h.parseItemParams(w, r)
+	if !ok {
+		return
+	}
+
+	playlist, err := h.libraryService.RemovePlaylistItem(r.Context(), id, itemID)
+	if err != nil {
+		respondProblem(h.logger, w, r, "Error removing playlist item:", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, PlaylistEnvelope{Data: playlist})
+}
+
+// ReorderPlaylistItems sets the order of all tracks (PUT /playlists/{id}/items/order)
+func (h *LibraryHandler) ReorderPlaylistItems(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		respondProblem(h.logger, w, r, "Invalid playlist ID:", err)
+		return
+	}
+
+	var requestBody PlaylistOrderRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		respondMalformedBody(h.logger, w, r, err)
+		return
+	}
+
+	playlist, err := h.libraryService.ReorderPlaylistItems(r.Context(), id, requestBody.ItemIDs)
+	if err != nil {
+		respondProblem(h.logger, w, r, "Error reordering playlist items:", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, PlaylistEnvelope{Data: playlist})
+}
+
+// ExportPlaylist downloads a playlist as M3U or XSPF (GET /playlists/{id}/export?format=m3u|xspf)
+func (h *LibraryHandler) ExportPlaylist(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		respondProblem(h.logger, w, r, "Invalid playlist ID:", err)
+		return
+	}
+	playlist, err := h.libraryService.GetPlaylist(r.Context(), id)
+	if err != nil {
+		respondProblem(h.logger, w, r, "Error getting playlist:", err)
+		return
+	}
+	h.writeExport(w, r, playlist)
+}
+
+// GetSharedPlaylist returns a public playlist by its share token (GET /shared/playlists/{token})
+func (h *LibraryHandler) GetSharedPlaylist(w http.ResponseWriter, r *http.Request) {
+	playlist, err := h.libraryService.GetSharedPlaylist(r.Context(), chi.URLParam(r, "token"))
+	if err != nil {
+		respondProblem(h.logger, w, r, "Error getting shared playlist:", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, PlaylistEnvelope{Data: playlist})
+}
+
+// ExportSharedPlaylist downloads a public playlist as M3U or XSPF (GET /shared/playlists/{token}/export)
+func (h *LibraryHandler) ExportSharedPlaylist(w http.ResponseWriter, r *http.Request) {
+	playlist, err := h.libraryService.GetSharedPlaylist(r.Context(), chi.URLParam(r, "token"))
+	if err != nil {
+		respondProblem(h.logger, w, r, "Error getting shared playlist:", err)
+		return
+	}
+	h.writeExport(w, r, playlist)
+}
+
+// writeExport отдаёт плейлист файлом в формате из параметра format (по умолчанию m3u)
+func (h *LibraryHandler) writeExport(w http.ResponseWriter, r *http.Request, playlist models.Playlist) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = export.FormatM3U
+	}
+	contentType, extension, ok := export.ContentType(format)
+	if !ok {
+		v := validation.New()
+		v.AddError("format", fmt.Sprintf("must be %s or %s", export.FormatM3U, export.FormatXSPF))
+		respondProblem(h.logger, w, r, "Invalid export format:", v.Err())
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="playlist-%d.%s"`, playlist.ID, extension))
+	if err := export.Write(w, format, playlist); err != nil {
+		h.logger.WithContext(r.Context()).Error("Error writing playlist export:", err)
+	}
+}
+
+// parsePagination извлекает пагинацию списка; по умолчанию 20 элементов
+func (h *LibraryHandler) parsePagination(w http.ResponseWriter, r *http.Request) (models.Pagination, bool) {
+	v := validation.New()
+	pagination := parsePagination(r.URL.Query(), v)
+	if err := v.Err(); err != nil {
+		respondProblem(h.logger, w, r, "Error parsing request parameters:", err)
+		return models.Pagination{}, false
+	}
+	if pagination.Limit == 0 {
+		pagination.Limit = 20
+	}
+	return pagination, true
+}
+
+// parseItemParams извлекает ID плейлиста и трека из параметров URL
+func (h *LibraryHandler) parseItemParams(w http.ResponseWriter, r *http.Request) (int, int64, bool) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		respondProblem(h.logger, w, r, "Invalid playlist ID:", err)
+		return 0, 0, false
+	}
+	itemID, err := strconv.ParseInt(chi.URLParam(r, "itemID"), 10, 64)
+	if err != nil || itemID < 1 {
+		v := validation.New()
+		v.AddError("itemID", "must be a positive integer")
+		respondProblem(h.logger, w, r, "Invalid playlist item ID:", v.Err())
+		return 0, 0, false
+	}
+	return id, itemID, true
+}
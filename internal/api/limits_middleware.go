@@ -0,0 +1,35 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// limitBody ограничивает размер тела запроса: запрос с заявленным Content-Length больше maxBytes
+// отклоняется сразу, а чтение тела без длины прерывается на maxBytes (см. malformedBodyProblem)
+func limitBody(maxBytes int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > maxBytes {
+				writeProblem(w, r, payloadTooLargeProblem(maxBytes))
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// withTimeout ограничивает время обработки запроса: по истечении timeout контекст запроса
+// отменяется, запросы к базе и внешнему API прерываются, а клиент получает 504 (см. writeProblem).
+// Не подключается к потокам событий, которые живут дольше любого запроса.
+func withTimeout(timeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"music_catalog/internal/auth"
+	"music_catalog/internal/logger"
+	"music_catalog/internal/tenant"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// logContext добавляет к записям журнала, сделанным с контекстом запроса, клиента и арендатора
+// (request_id добавляет requestID); подключается после аутентификации и определения арендатора
+func logContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		attrs := []slog.Attr{
+			slog.String("principal", auth.Actor(ctx)),
+		}
+		if t, ok := tenant.FromContext(ctx); ok {
+			attrs = append(attrs, slog.String("tenant", t.Slug))
+		}
+		next.ServeHTTP(w, r.WithContext(logger.WithAttrs(ctx, attrs...)))
+	})
+}
+
+// accessLog записывает по одной записи на запрос: метод, путь, шаблон маршрута, статус, размер
+// ответа и длительность. Запись делается с контекстом запроса, поэтому содержит request_id и trace_id
+func accessLog(log logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+				if r.Header.Get("Upgrade") != "" {
+					status = http.StatusSwitchingProtocols
+				}
+			}
+			ctx := logger.WithAttrs(r.Context(),
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.String("route", metricsRoute(r)),
+				slog.Int("status", status),
+				slog.Int("bytes", ww.BytesWritten()),
+				slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+				slog.String("remote_addr", r.RemoteAddr),
+				slog.String("user_agent", r.UserAgent()),
+			)
+			log.WithContext(ctx).Info("HTTP request")
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"music_catalog/internal/metrics"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// instrument учитывает каждый запрос в метриках по шаблону маршрута chi (/api/v2/songs/{id}),
+// а не по пути, чтобы число рядов не росло с числом песен
+func instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r)
+
+		status := ww.Status()
+		if status == 0 {
+			// Ответ не записан через ResponseWriter: соединение передано WebSocket или тело пустое
+			status = http.StatusOK
+			if r.Header.Get("Upgrade") != "" {
+				status = http.StatusSwitchingProtocols
+			}
+		}
+		metrics.ObserveHTTPRequest(metricsMethod(r.Method), metricsRoute(r), status, time.Since(start))
+	})
+}
+
+// metricsRoute возвращает шаблон сработавшего маршрута; запросы мимо маршрутов
+// объединяются в один ряд
+func metricsRoute(r *http.Request) string {
+	pattern := chi.RouteContext(r.Context()).RoutePattern()
+	if pattern == "" || pattern == "/*" {
+		return "unmatched"
+	}
+	return pattern
+}
+
+// metricsMethod ограничивает метку метода стандартными методами HTTP
+func metricsMethod(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodOptions:
+		return method
+	}
+	return "OTHER"
+}
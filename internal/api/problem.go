@@ -0,0 +1,195 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	catalog_errors "music_catalog/internal/errors"
+	"music_catalog/internal/logger"
+	"music_catalog/internal/tracing"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Стабильные коды типов ошибок (RFC 7807), на которые может опираться клиент
+const (
+	ProblemTypeValidation       = "urn:music-catalog:problem:validation-error"
+	ProblemTypeMalformedBody    = "urn:music-catalog:problem:malformed-body"
+	ProblemTypeSongNotFound     = "urn:music-catalog:problem:song-not-found"
+	ProblemTypeSongsNotFound    = "urn:music-catalog:problem:songs-not-found"
+	ProblemTypeSongExists       = "urn:music-catalog:problem:song-exists"
+	ProblemTypeInvalidPage      = "urn:music-catalog:problem:invalid-page"
+	ProblemTypeUpstream         = "urn:music-catalog:problem:upstream-failure"
+	ProblemTypeWebhookNotFound  = "urn:music-catalog:problem:webhook-not-found"
+	ProblemTypeDeliveryNotFound = "urn:music-catalog:problem:delivery-not-found"
+	ProblemTypeAPIKeyNotFound   = "urn:music-catalog:problem:api-key-not-found"
+	ProblemTypeUnauthenticated  = "urn:music-catalog:problem:unauthenticated"
+	ProblemTypePlaylistNotFound = "urn:music-catalog:problem:playlist-not-found"
+	ProblemTypeItemNotFound     = "urn:music-catalog:problem:playlist-item-not-found"
+	ProblemTypeForbidden        = "urn:music-catalog:problem:forbidden"
+	ProblemTypeTenantNotFound   = "urn:music-catalog:problem:tenant-not-found"
+	ProblemTypeTenantExists     = "urn:music-catalog:problem:tenant-exists"
+	ProblemTypeRateLimited      = "urn:music-catalog:problem:rate-limited"
+	ProblemTypeIdempotencyReuse = "urn:music-catalog:problem:idempotency-key-reused"
+	ProblemTypeIdempotencyBusy  = "urn:music-catalog:problem:idempotency-key-in-progress"
+	ProblemTypeInternal         = "urn:music-catalog:problem:internal-error"
+	ProblemTypePayloadTooLarge  = "urn:music-catalog:problem:payload-too-large"
+	ProblemTypeTimeout          = "urn:music-catalog:problem:request-timeout"
+	problemContentType          = "application/problem+json"
+)
+
+// Problem — тело ответа об ошибке в формате application/problem+json (RFC 7807)
+type Problem struct {
+	Type      string                      `json:"type" example:"urn:music-catalog:problem:song-not-found"`
+	Title     string                      `json:"title" example:"Song not found"`
+	Status    int                         `json:"status" example:"404"`
+	Detail    string                      `json:"detail,omitempty" example:"song not found"`
+	Instance  string                      `json:"instance,omitempty" example:"/songs/42/text"`
+	RequestID string                      `json:"request_id,omitempty" example:"host/abcdef-000001"`
+	TraceID   string                      `json:"trace_id,omitempty" example:"4bf92f3577b34da6a3ce929d0e0e4736"` // трассировка запроса в коллекторе
+	Errors    []catalog_errors.FieldError `json:"errors,omitempty"`
+}
+
+// problemFromError сопоставляет ошибку сервисного слоя с описанием проблемы
+func problemFromError(err error) Problem {
+	var validationErr *catalog_errors.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		return Problem{
+			Type:   ProblemTypeValidation,
+			Title:  "Request validation failed",
+			Status: http.StatusBadRequest,
+			Detail: "one or more fields are invalid",
+			Errors: validationErr.Errors,
+		}
+	case errors.Is(err, catalog_errors.ErrSongNotFound):
+		return Problem{Type: ProblemTypeSongNotFound, Title: "Song not found", Status: http.StatusNotFound}
+	case errors.Is(err, catalog_errors.ErrSongExists):
+		return Problem{Type: ProblemTypeSongExists, Title: "Song already exists", Status: http.StatusConflict}
+	case errors.Is(err, catalog_errors.ErrInvalidPage):
+		return Problem{Type: ProblemTypeInvalidPage, Title: "Invalid page number", Status: http.StatusBadRequest}
+	case errors.Is(err, catalog_errors.ErrWebhookNotFound):
+		return Problem{Type: ProblemTypeWebhookNotFound, Title: "Webhook not found", Status: http.StatusNotFound}
+	case errors.Is(err, catalog_errors.ErrDeliveryNotFound):
+		return Problem{
+			Type:   ProblemTypeDeliveryNotFound,
+			Title:  "Webhook delivery not found",
+			Status: http.StatusNotFound,
+			Detail: "the delivery does not exist, belongs to another webhook or has already been delivered",
+		}
+	case errors.Is(err, catalog_errors.ErrAPIKeyNotFound):
+		return Problem{Type: ProblemTypeAPIKeyNotFound, Title: "API key not found", Status: http.StatusNotFound}
+	case errors.Is(err, catalog_errors.ErrPlaylistNotFound):
+		return Problem{Type: ProblemTypePlaylistNotFound, Title: "Playlist not found", Status: http.StatusNotFound}
+	case errors.Is(err, catalog_errors.ErrPlaylistItemNotFound):
+		return Problem{Type: ProblemTypeItemNotFound, Title: "Playlist item not found", Status: http.StatusNotFound}
+	case errors.Is(err, catalog_errors.ErrTenantNotFound):
+		return Problem{Type: ProblemTypeTenantNotFound, Title: "Tenant not found", Status: http.StatusNotFound}
+	case errors.Is(err, catalog_errors.ErrTenantExists):
+		return Problem{Type: ProblemTypeTenantExists, Title: "Tenant already exists", Status: http.StatusConflict}
+	case errors.Is(err, catalog_errors.ErrIdempotencyKeyReused):
+		return Problem{
+			Type:   ProblemTypeIdempotencyReuse,
+			Title:  "Idempotency key reused",
+			Status: http.StatusUnprocessableEntity,
+			Detail: "the Idempotency-Key was already used with a different request; generate a new key for a new request",
+		}
+	case errors.Is(err, catalog_errors.ErrIdempotencyKeyInProgress):
+		return Problem{
+			Type:   ProblemTypeIdempotencyBusy,
+			Title:  "Request in progress",
+			Status: http.StatusConflict,
+			Detail: "a request with this Idempotency-Key is still being processed; retry later",
+		}
+	case errors.Is(err, catalog_errors.ErrUnauthenticated):
+		return Problem{
+			Type:   ProblemTypeUnauthenticated,
+			Title:  "Authentication required",
+			Status: http.StatusUnauthorized,
+			Detail: "provide a valid API key (X-API-Key) or bearer token (Authorization)",
+		}
+	case errors.Is(err, catalog_errors.ErrForbidden):
+		return Problem{Type: ProblemTypeForbidden, Title: "Permission denied", Status: http.StatusForbidden, Detail: err.Error()}
+	case errors.Is(err, catalog_errors.ErrUpstream):
+		return Problem{
+			Type:   ProblemTypeUpstream,
+			Title:  "External API failure",
+			Status: http.StatusBadGateway,
+			Detail: "the song details provider is unavailable or returned an error",
+		}
+	default:
+		return Problem{Type: ProblemTypeInternal, Title: "Internal server error", Status: http.StatusInternalServerError}
+	}
+}
+
+// writeProblem отправляет клиенту ответ application/problem+json
+func writeProblem(w http.ResponseWriter, r *http.Request, problem Problem) {
+	if problem.Detail == "" {
+		problem.Detail = problem.Title
+	}
+	// После истечения срока запроса (REQUEST_TIMEOUT) запросы к базе и внешнему API завершаются
+	// ошибками отмены: причина ответа 5xx — таймаут, а не сбой сервиса
+	if problem.Status >= http.StatusInternalServerError && errors.Is(r.Context().Err(), context.DeadlineExceeded) {
+		problem = Problem{
+			Type:   ProblemTypeTimeout,
+			Title:  "Request timed out",
+			Status: http.StatusGatewayTimeout,
+			Detail: "the request took too long to process; retry later",
+		}
+	}
+	problem.Instance = r.URL.Path
+	problem.RequestID = middleware.GetReqID(r.Context())
+	problem.TraceID = tracing.TraceID(r.Context())
+
+	if problem.Status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="music-catalog"`)
+	}
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+// respondProblem логирует ошибку (5xx — как Error, остальные — как Info) и отправляет problem+json ответ
+func respondProblem(logger logger.Logger, w http.ResponseWriter, r *http.Request, message string, err error) {
+	problem := problemFromError(err)
+	if problem.Status >= http.StatusInternalServerError {
+		logger.WithContext(r.Context()).Error(message, err)
+	} else {
+		logger.WithContext(r.Context()).Info(message, err)
+	}
+	writeProblem(w, r, problem)
+}
+
+// respondMalformedBody логирует (как Info: это ошибка клиента) и отправляет ответ о теле запроса,
+// которое не удалось разобрать
+func respondMalformedBody(logger logger.Logger, w http.ResponseWriter, r *http.Request, err error) {
+	logger.WithContext(r.Context()).Info("Error decoding JSON:", err)
+	writeProblem(w, r, malformedBodyProblem(err))
+}
+
+// malformedBodyProblem описывает тело запроса, которое не удалось разобрать
+func malformedBodyProblem(err error) Problem {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return payloadTooLargeProblem(tooLarge.Limit)
+	}
+	return Problem{
+		Type:   ProblemTypeMalformedBody,
+		Title:  "Malformed request body",
+		Status: http.StatusBadRequest,
+		Detail: err.Error(),
+	}
+}
+
+// payloadTooLargeProblem описывает тело запроса больше допустимого размера
+func payloadTooLargeProblem(limit int64) Problem {
+	return Problem{
+		Type:   ProblemTypePayloadTooLarge,
+		Title:  "Request body too large",
+		Status: http.StatusRequestEntityTooLarge,
+		Detail: fmt.Sprintf("request body must not exceed %d bytes", limit),
+	}
+}
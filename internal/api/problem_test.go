@@ -0,0 +1,67 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	catalog_errors "music_catalog/internal/errors"
+)
+
+func TestProblemFromError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantType   string
+		wantStatus int
+	}{
+		{"validation", catalog_errors.NewValidationError(catalog_errors.FieldError{Field: "title", Message: "is required"}), ProblemTypeValidation, http.StatusBadRequest},
+		{"song not found", catalog_errors.ErrSongNotFound, ProblemTypeSongNotFound, http.StatusNotFound},
+		{"wrapped song not found", fmt.Errorf("delete song 42: %w", catalog_errors.ErrSongNotFound), ProblemTypeSongNotFound, http.StatusNotFound},
+		{"song exists", catalog_errors.ErrSongExists, ProblemTypeSongExists, http.StatusConflict},
+		{"invalid page", catalog_errors.ErrInvalidPage, ProblemTypeInvalidPage, http.StatusBadRequest},
+		{"webhook not found", catalog_errors.ErrWebhookNotFound, ProblemTypeWebhookNotFound, http.StatusNotFound},
+		{"delivery not found", catalog_errors.ErrDeliveryNotFound, ProblemTypeDeliveryNotFound, http.StatusNotFound},
+		{"api key not found", catalog_errors.ErrAPIKeyNotFound, ProblemTypeAPIKeyNotFound, http.StatusNotFound},
+		{"playlist not found", catalog_errors.ErrPlaylistNotFound, ProblemTypePlaylistNotFound, http.StatusNotFound},
+		{"playlist item not found", catalog_errors.ErrPlaylistItemNotFound, ProblemTypeItemNotFound, http.StatusNotFound},
+		{"tenant not found", catalog_errors.ErrTenantNotFound, ProblemTypeTenantNotFound, http.StatusNotFound},
+		{"tenant exists", catalog_errors.ErrTenantExists, ProblemTypeTenantExists, http.StatusConflict},
+		{"idempotency key reused", catalog_errors.ErrIdempotencyKeyReused, ProblemTypeIdempotencyReuse, http.StatusUnprocessableEntity},
+		{"idempotency key in progress", catalog_errors.ErrIdempotencyKeyInProgress, ProblemTypeIdempotencyBusy, http.StatusConflict},
+		{"unauthenticated", catalog_errors.ErrUnauthenticated, ProblemTypeUnauthenticated, http.StatusUnauthorized},
+		{"forbidden", fmt.Errorf("%w: role editor required", catalog_errors.ErrForbidden), ProblemTypeForbidden, http.StatusForbidden},
+		{"upstream", fmt.Errorf("%w: status 503", catalog_errors.ErrUpstream), ProblemTypeUpstream, http.StatusBadGateway},
+		{"unknown error", errors.New("connection refused"), ProblemTypeInternal, http.StatusInternalServerError},
+		{"context canceled", context.Canceled, ProblemTypeInternal, http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problem := problemFromError(tt.err)
+			if problem.Type != tt.wantType || problem.Status != tt.wantStatus {
+				t.Errorf("problemFromError(%v) = %s %d, want %s %d", tt.err, problem.Type, problem.Status, tt.wantType, tt.wantStatus)
+			}
+			if problem.Title == "" {
+				t.Errorf("problemFromError(%v) has an empty title", tt.err)
+			}
+		})
+	}
+}
+
+func TestProblemFromValidationError(t *testing.T) {
+	fieldErrors := []catalog_errors.FieldError{
+		{Field: "group", Message: "is required"},
+		{Field: "link", Message: "must be a valid http or https URL"},
+	}
+	problem := problemFromError(catalog_errors.NewValidationError(fieldErrors...))
+	if len(problem.Errors) != len(fieldErrors) {
+		t.Fatalf("problem.Errors = %v, want %v", problem.Errors, fieldErrors)
+	}
+	for i := range fieldErrors {
+		if problem.Errors[i] != fieldErrors[i] {
+			t.Errorf("problem.Errors[%d] = %v, want %v", i, problem.Errors[i], fieldErrors[i])
+		}
+	}
+}
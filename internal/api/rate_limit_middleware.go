@@ -0,0 +1,123 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"music_catalog/internal/auth"
+	"music_catalog/internal/logger"
+	"music_catalog/internal/ratelimit"
+)
+
+// rateLimit ограничивает частоту запросов клиента квотами классов маршрутов (см. routeClass)
+// и сообщает состояние квоты заголовками RateLimit-Policy, RateLimit-Limit, RateLimit-Remaining
+// и RateLimit-Reset. Клиент — аутентифицированный principal или, для анонимных запросов, IP-адрес.
+// Сбой хранилища корзин не блокирует запросы. Вызывается после authenticate и resolveTenant.
+func rateLimit(limiter *ratelimit.Limiter, proxyHops int, logger logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			client := rateLimitClient(r, proxyHops)
+			r = r.WithContext(ratelimit.WithClient(r.Context(), client))
+			if allowRequest(limiter, routeClass(r), client, logger, w, r) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// limitAuthentication ограничивает квотой auth запросы с учётными данными с одного IP-адреса.
+// Вызывается до authenticate: иначе неверные ключи и токены отклонялись бы с 401, не расходуя
+// ничьей квоты, и подбирать их можно было бы с любой частотой. Запросы без учётных данных
+// считаются по IP-адресу в rateLimit.
+func limitAuthentication(limiter *ratelimit.Limiter, proxyHops int, logger logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if credentialFromRequest(r) == "" || allowRequest(limiter, ratelimit.ClassAuth, "ip:"+clientIP(r, proxyHops), logger, w, r) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// allowRequest расходует токен клиента в классе class и выставляет заголовки RateLimit-*;
+// при исчерпанной квоте отвечает 429 и возвращает false
+func allowRequest(limiter *ratelimit.Limiter, class ratelimit.Class, client string, logger logger.Logger, w http.ResponseWriter, r *http.Request) bool {
+	result, quota, err := limiter.Allow(r.Context(), class, client)
+	if err != nil {
+		logger.WithContext(r.Context()).Error("Rate limiter unavailable, request allowed:", err)
+		return true
+	}
+	if quota.Unlimited() {
+		return true
+	}
+
+	w.Header().Set("RateLimit-Policy", quota.Policy())
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(quota.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+	if !result.Allowed {
+		retryAfter := ceilSeconds(result.RetryAfter)
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		logger.WithContext(r.Context()).Info("Rate limit exceeded:", class, auth.Actor(r.Context()), r.Method, r.URL.Path)
+		writeProblem(w, r, Problem{
+			Type:   ProblemTypeRateLimited,
+			Title:  "Too many requests",
+			Status: http.StatusTooManyRequests,
+			Detail: fmt.Sprintf("quota of %d %s requests per %s exceeded, retry in %ds", quota.Limit, class, quota.Period, retryAfter),
+		})
+		return false
+	}
+	return true
+}
+
+// routeClass относит запрос к классу квоты: добавление песни (POST .../songs) обращается к внешнему API
+// и ограничивается отдельно, прочие изменяющие запросы (включая GraphQL) — write, безопасные методы — read.
+// Мутация addSong в GraphQL дополнительно расходует квоту enrich в резолвере.
+func routeClass(r *http.Request) ratelimit.Class {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return ratelimit.ClassRead
+	case http.MethodPost:
+		if strings.HasSuffix(strings.TrimSuffix(r.URL.Path, "/"), "/songs") {
+			return ratelimit.ClassEnrich
+		}
+	}
+	return ratelimit.ClassWrite
+}
+
+// rateLimitClient возвращает ключ клиента: principal в пределах арендатора или IP-адрес (см. clientIP)
+func rateLimitClient(r *http.Request, proxyHops int) string {
+	return ratelimit.Client(r.Context(), clientIP(r, proxyHops))
+}
+
+// clientIP возвращает адрес клиента. За proxyHops доверенными прокси адрес берётся из X-Forwarded-For:
+// каждый прокси дописывает в конец адрес, от которого получил запрос, поэтому клиент — proxyHops-й адрес
+// справа. Левее лежат значения, которые клиент мог прислать сам, и их брать нельзя: подменой заголовка
+// он обходил бы квоты.
+func clientIP(r *http.Request, proxyHops int) string {
+	if proxyHops > 0 {
+		// Прокси может дописать адрес отдельной строкой заголовка, поэтому строки объединяются
+		forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+		if i := len(forwarded) - proxyHops; i >= 0 {
+			if ip := net.ParseIP(strings.TrimSpace(forwarded[i])); ip != nil {
+				return ip.String()
+			}
+		}
+		// Заголовка нет или он короче цепочки прокси: запрос пришёл в обход прокси
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	return host
+}
+
+// ceilSeconds округляет длительность вверх до целых секунд
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"music_catalog/internal/auth"
+	"music_catalog/internal/logger"
+	"music_catalog/internal/ratelimit"
+)
+
+func TestRateLimitClient(t *testing.T) {
+	tests := []struct {
+		name      string
+		forwarded []string
+		proxyHops int
+		want      string
+	}{
+		{"proxy not trusted", []string{"198.51.100.7"}, 0, "ip:192.0.2.1"},
+		{"one proxy", []string{"198.51.100.7"}, 1, "ip:198.51.100.7"},
+		{"spoofed entries are ignored", []string{"10.0.0.1, 203.0.113.9, 198.51.100.7"}, 1, "ip:198.51.100.7"},
+		{"two proxies", []string{"10.0.0.1, 198.51.100.7, 172.16.0.2"}, 2, "ip:198.51.100.7"},
+		{"appended as a separate header line", []string{"10.0.0.1", "198.51.100.7"}, 1, "ip:198.51.100.7"},
+		{"chain shorter than proxies", []string{"198.51.100.7"}, 2, "ip:192.0.2.1"},
+		{"malformed entry", []string{"198.51.100.7, not-an-ip"}, 1, "ip:192.0.2.1"},
+		{"no header", nil, 1, "ip:192.0.2.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/api/v2/songs", nil)
+			r.RemoteAddr = "192.0.2.1:54321"
+			for _, value := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+			if got := rateLimitClient(r, tt.proxyHops); got != tt.want {
+				t.Errorf("rateLimitClient() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// Неверные учётные данные расходуют квоту auth адреса клиента: после её исчерпания вместо 401 — 429
+func TestFailedAuthenticationIsRateLimited(t *testing.T) {
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), ratelimit.Quotas{ratelimit.ClassAuth: {Limit: 3, Period: time.Minute}})
+	api := NewRestSongAPI(nil, nil, auth.NewAuthenticator(nil, auth.Config{}), nil, logger.NewLogger("error")).
+		WithV1Deprecation(time.Now(), time.Time{}).
+		WithRateLimit(limiter, 0)
+	handler := api.RegisterRoutes()
+
+	var codes []int
+	for i := 0; i < 5; i++ {
+		r := httptest.NewRequest(http.MethodGet, "/api/v2/songs", nil)
+		r.RemoteAddr = "192.0.2.1:54321"
+		r.Header.Set("Authorization", "Bearer forged.jwt.token")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		codes = append(codes, w.Code)
+	}
+	want := []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests, http.StatusTooManyRequests}
+	if !slices.Equal(codes, want) {
+		t.Errorf("status codes = %v, want %v", codes, want)
+	}
+
+	// Другой адрес расходует свою корзину
+	r := httptest.NewRequest(http.MethodGet, "/api/v2/songs", nil)
+	r.RemoteAddr = "192.0.2.2:54321"
+	r.Header.Set("Authorization", "Bearer forged.jwt.token")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("other client: status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
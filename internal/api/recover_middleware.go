@@ -0,0 +1,37 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+
+	"music_catalog/internal/logger"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// recoverPanics перехватывает панику обработчика: паника со стеком попадает в журнал, а клиент,
+// если ответ ещё не начат, получает problem+json с кодом 500 вместо оборванного соединения.
+// http.ErrAbortHandler пробрасывается дальше — им обработчик намеренно прерывает ответ.
+func recoverPanics(log logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+				if recovered == http.ErrAbortHandler {
+					panic(recovered)
+				}
+				ctx := logger.WithAttrs(r.Context(), slog.String("stack", string(debug.Stack())))
+				log.WithContext(ctx).Error("Panic while handling request:", recovered)
+				if ww.Status() == 0 && r.Header.Get("Upgrade") == "" {
+					writeProblem(ww, r, Problem{Type: ProblemTypeInternal, Title: "Internal server error", Status: http.StatusInternalServerError})
+				}
+			}()
+			next.ServeHTTP(ww, r)
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+
+	"music_catalog/internal/logger"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// maxRequestIDLength — самый длинный идентификатор запроса, принимаемый от клиента или прокси
+const maxRequestIDLength = 128
+
+// requestID присваивает запросу идентификатор: X-Request-ID клиента или балансировщика
+// сохраняется, чтобы запрос можно было проследить через несколько сервисов, а при его отсутствии
+// или недопустимом значении создаётся новый. Идентификатор возвращается в заголовке ответа,
+// попадает в тела ошибок и во все записи журнала с контекстом запроса.
+func requestID(next http.Handler) http.Handler {
+	withID := middleware.RequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := middleware.GetReqID(r.Context())
+		w.Header().Set(middleware.RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(logger.WithAttrs(r.Context(), slog.String("request_id", id))))
+	}))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id := r.Header.Get(middleware.RequestIDHeader); id != "" && !validRequestID(id) {
+			r.Header.Del(middleware.RequestIDHeader)
+		}
+		withID.ServeHTTP(w, r)
+	})
+}
+
+// validRequestID допускает только печатные ASCII-символы без пробелов: значение попадает
+// в заголовки ответов, журналы и исходящие запросы
+func validRequestID(id string) bool {
+	if len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
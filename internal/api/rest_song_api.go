@@ -2,27 +2,292 @@ package api
 
 import (
 	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"music_catalog/internal/auth"
+	"music_catalog/internal/idempotency"
+	"music_catalog/internal/logger"
+	"music_catalog/internal/ratelimit"
+	"music_catalog/internal/tenant"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/cors"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
 type RestSongAPI struct {
-	songHandler *SongHandler
+	songHandler   *SongHandler
+	songHandlerV2 *SongHandlerV2
+	authenticator *auth.Authenticator
+	tenants       *tenant.Resolver
+	v1Deprecated  time.Time // дата объявления API v1 устаревшим
+	v1Sunset      time.Time // дата отключения API v1; нулевая — без заголовка Sunset
+	limiter       *ratelimit.Limiter
+	proxyHops     int // число доверенных прокси перед сервисом; 0 — X-Forwarded-For не учитывается
+	idempotency   idempotency.Store
+	idempotentTTL func() time.Duration
+	accessLog     bool
+	cors          atomic.Pointer[cors.Cors] // nil — CORS выключен
+	compression   int
+	maxBodyBytes  int64
+	timeout       time.Duration
+	logger        logger.Logger
+	mounts        []mount
+	routes        []routes
+	probes        []probe
+}
+
+// probe — служебный обработчик вне цепочки middleware (пробы оркестратора)
+type probe struct {
+	pattern string
+	handler http.HandlerFunc
+}
+
+// mount — дополнительный обработчик, подключаемый к роутеру (GraphQL и т.п.)
+type mount struct {
+	pattern string
+	role    auth.Role
+	handler http.Handler
+	stream  bool // долгоживущий поток: без ограничения времени обработки
+}
+
+// routes — группа маршрутов дополнительного обработчика с общей требуемой ролью
+type routes struct {
+	role     auth.Role
+	register func(chi.Router)
+}
+
+func NewRestSongAPI(songHandler *SongHandler, songHandlerV2 *SongHandlerV2, authenticator *auth.Authenticator, tenants *tenant.Resolver, logger logger.Logger) *RestSongAPI {
+	return &RestSongAPI{songHandler: songHandler, songHandlerV2: songHandlerV2, authenticator: authenticator, tenants: tenants, logger: logger}
+}
+
+// WithV1Deprecation задаёт даты вывода API v1 и маршрутов без префикса версии: since (обязательна) —
+// в заголовке Deprecation, sunset — в заголовке Sunset (нулевая — без заголовка). Вызывается до RegisterRoutes
+func (api *RestSongAPI) WithV1Deprecation(since time.Time, sunset time.Time) *RestSongAPI {
+	api.v1Deprecated = since
+	api.v1Sunset = sunset
+	return api
+}
+
+// WithRateLimit включает ограничение частоты запросов квотами limiter; proxyHops — число доверенных
+// прокси, по цепочке которых в X-Forwarded-For определяется адрес анонимного клиента (0 — не определяется). Вызывается до RegisterRoutes
+func (api *RestSongAPI) WithRateLimit(limiter *ratelimit.Limiter, proxyHops int) *RestSongAPI {
+	api.limiter = limiter
+	api.proxyHops = proxyHops
+	return api
+}
+
+// WithIdempotency включает поддержку заголовка Idempotency-Key для добавления песен:
+// ответы хранятся в store в течение ttl() — текущего значения на момент запроса. Вызывается до RegisterRoutes
+func (api *RestSongAPI) WithIdempotency(store idempotency.Store, ttl func() time.Duration) *RestSongAPI {
+	api.idempotency = store
+	api.idempotentTTL = ttl
+	return api
+}
+
+// WithAccessLog включает или выключает журнал запросов: по записи на каждый запрос к API.
+// Вызывается до RegisterRoutes
+func (api *RestSongAPI) WithAccessLog(enabled bool) *RestSongAPI {
+	api.accessLog = enabled
+	return api
+}
+
+// WithCORS разрешает запросы браузерных клиентов с перечисленных источников (* — с любых);
+// пустой список оставляет CORS выключенным
+func (api *RestSongAPI) WithCORS(origins []string) *RestSongAPI {
+	api.SetCORSOrigins(origins)
+	return api
+}
+
+// SetCORSOrigins заменяет список разрешённых источников CORS в работающем сервере
+func (api *RestSongAPI) SetCORSOrigins(origins []string) {
+	api.cors.Store(newCORS(origins))
+}
+
+// WithCompression включает сжатие ответов со степенью level (1–9; 0 — без сжатия).
+// Вызывается до RegisterRoutes
+func (api *RestSongAPI) WithCompression(level int) *RestSongAPI {
+	api.compression = level
+	return api
+}
+
+// WithLimits ограничивает размер тела запроса maxBodyBytes и время обработки запроса timeout;
+// нулевые значения снимают соответствующее ограничение. Вызывается до RegisterRoutes
+func (api *RestSongAPI) WithLimits(maxBodyBytes int64, timeout time.Duration) *RestSongAPI {
+	api.maxBodyBytes = maxBodyBytes
+	api.timeout = timeout
+	return api
+}
+
+// Mount подключает дополнительный обработчик по указанному пути, доступный клиентам с ролью не ниже role;
+// вызывается до RegisterRoutes
+func (api *RestSongAPI) Mount(pattern string, role auth.Role, handler http.Handler) *RestSongAPI {
+	api.mounts = append(api.mounts, mount{pattern: pattern, role: role, handler: handler})
+	return api
+}
+
+// MountStream подключает долгоживущий поток (SSE, WebSocket) так же, как Mount, но без ограничения
+// времени обработки запроса; вызывается до RegisterRoutes
+func (api *RestSongAPI) MountStream(pattern string, role auth.Role, handler http.Handler) *RestSongAPI {
+	api.mounts = append(api.mounts, mount{pattern: pattern, role: role, handler: handler, stream: true})
+	return api
+}
+
+// Register добавляет маршруты дополнительного обработчика в корень роутера, доступные клиентам
+// с ролью не ниже role (пустая роль — без аутентификации); вызывается до RegisterRoutes
+func (api *RestSongAPI) Register(role auth.Role, register func(chi.Router)) *RestSongAPI {
+	api.routes = append(api.routes, routes{role: role, register: register})
+	return api
 }
 
-func NewRestSongAPI(songHandler *SongHandler) *RestSongAPI {
-	return &RestSongAPI{songHandler: songHandler}
+// Probe подключает служебный GET-обработчик без аутентификации, определения арендатора
+// и ограничения частоты запросов: пробы оркестратора не должны зависеть от них
+// и расходовать квоты; вызывается до RegisterRoutes
+func (api *RestSongAPI) Probe(pattern string, handler http.HandlerFunc) *RestSongAPI {
+	api.probes = append(api.probes, probe{pattern: pattern, handler: handler})
+	return api
 }
 
 func (api *RestSongAPI) RegisterRoutes() http.Handler {
+	root := chi.NewRouter()
+	// Идентификатор запроса нужен уже трассировке и журналу, поэтому он присваивается первым
+	root.Use(requestID, traceRequests(api.logger), instrument)
+	for _, p := range api.probes {
+		root.With(recoverPanics(api.logger)).Get(p.pattern, p.handler)
+	}
+	root.Mount("/", api.routesHandler())
+	return root
+}
+
+// routesHandler строит роутер API с общей цепочкой middleware
+func (api *RestSongAPI) routesHandler() http.Handler {
 	r := chi.NewRouter()
-	r.Get("/songs", api.songHandler.GetSongs)
-	r.Get("/songs/{id}/text", api.songHandler.GetSongText)
-	r.Post("/songs", api.songHandler.AddSong)
-	r.Put("/songs/{id}", api.songHandler.UpdateSong)
-	r.Delete("/songs/{id}", api.songHandler.DeleteSong)
-	// Маршрут для Swagger UI
-	r.Get("/swagger/*", httpSwagger.WrapHandler)
+	// Журнал запросов — снаружи перехвата паник, чтобы в него попадали и ответы 500 после паники
+	if api.accessLog {
+		r.Use(accessLog(api.logger))
+	}
+	r.Use(recoverPanics(api.logger))
+	// Предварительные запросы CORS обрабатываются до аутентификации: браузер не передаёт в них ключи
+	r.Use(api.corsPolicy)
+	if api.compression > 0 {
+		r.Use(compress(api.compression))
+	}
+	if api.maxBodyBytes > 0 {
+		r.Use(limitBody(api.maxBodyBytes))
+	}
+	// Попытки аутентификации ограничиваются по IP-адресу до проверки учётных данных
+	if api.limiter != nil {
+		r.Use(limitAuthentication(api.limiter, api.proxyHops, api.logger))
+	}
+	// Клиент определяется для всех маршрутов, права проверяются на уровне групп маршрутов
+	r.Use(authenticate(api.authenticator, api.logger))
+	// Арендатор — по привязке клиента или заголовку X-Tenant-ID
+	r.Use(resolveTenant(api.tenants, api.logger))
+	// Записи журнала с контекстом запроса получают request_id, principal и tenant
+	r.Use(logContext)
+	// Квоты считаются на клиента, поэтому ограничение — после аутентификации
+	if api.limiter != nil {
+		r.Use(rateLimit(api.limiter, api.proxyHops, api.logger))
+	}
+
+	// Каталог: чтение — viewer, изменение — editor
+	catalogAccess := requireRoleByMethod(auth.RoleViewer, auth.RoleEditor, api.logger)
+
+	r.Route("/api/v1", func(r chi.Router) {
+		r.Use(api.limitTime, deprecated(api.v1Deprecated, api.v1Sunset, "/api/v2"), catalogAccess)
+		api.registerV1(r)
+	})
+	r.Route("/api/v2", func(r chi.Router) {
+		r.Use(api.limitTime, catalogAccess)
+		api.registerV2(r)
+	})
+
+	for _, m := range api.mounts {
+		handler := m.handler
+		if !m.stream {
+			handler = api.limitTime(handler)
+		}
+		r.With(requireRole(m.role, api.logger)).Handle(m.pattern, handler)
+	}
+	for _, group := range api.routes {
+		r.Group(func(r chi.Router) {
+			r.Use(api.limitTime)
+			if group.role != "" {
+				r.Use(requireRole(group.role, api.logger))
+			}
+			group.register(r)
+		})
+	}
+
+	// Маршруты без префикса версии сохранены для существующих клиентов и ведут себя как v1
+	r.Group(func(r chi.Router) {
+		r.Use(api.limitTime, deprecated(api.v1Deprecated, api.v1Sunset, "/api/v2"), catalogAccess)
+		api.registerV1(r)
+	})
+
+	// Маршруты для Swagger UI: отдельная спецификация на каждую версию; прежний адрес /swagger/
+	// по-прежнему отдаёт спецификацию исходного API, т.е. v1
+	r.Get("/swagger/v1/*", httpSwagger.Handler(httpSwagger.InstanceName("v1"), httpSwagger.URL("/swagger/v1/doc.json")))
+	r.Get("/swagger/v2/*", httpSwagger.Handler(httpSwagger.InstanceName("v2"), httpSwagger.URL("/swagger/v2/doc.json")))
+	r.Get("/swagger/*", httpSwagger.Handler(httpSwagger.InstanceName("v1"), httpSwagger.URL("/swagger/doc.json")))
 	return r
 }
+
+// registerV1 регистрирует маршруты API v1 в исходном виде
+func (api *RestSongAPI) registerV1(r chi.Router) {
+	r.Get("/songs", traced("SongHandler.GetSongs", api.songHandler.GetSongs))
+	r.Get("/songs/{id}/text", traced("SongHandler.GetSongText", api.songHandler.GetSongText))
+	r.With(api.idempotent).Post("/songs", traced("SongHandler.AddSong", api.songHandler.AddSong))
+	r.Put("/songs/{id}", traced("SongHandler.UpdateSong", api.songHandler.UpdateSong))
+	r.Patch("/songs/{id}", traced("SongHandler.PatchSong", api.songHandler.PatchSong))
+	r.Delete("/songs/{id}", traced("SongHandler.DeleteSong", api.songHandler.DeleteSong))
+}
+
+// registerV2 регистрирует маршруты API v2
+func (api *RestSongAPI) registerV2(r chi.Router) {
+	r.Get("/songs", traced("SongHandlerV2.GetSongs", api.songHandlerV2.GetSongs))
+	r.Get("/songs/search", traced("SongHandlerV2.SearchSongs", api.songHandlerV2.SearchSongs))
+	r.Get("/songs/{id}", traced("SongHandlerV2.GetSong", api.songHandlerV2.GetSong))
+	r.Get("/songs/{id}/text", traced("SongHandlerV2.GetSongText", api.songHandlerV2.GetSongText))
+	r.With(api.idempotent).Post("/songs", traced("SongHandlerV2.AddSong", api.songHandlerV2.AddSong))
+	r.Put("/songs/{id}", traced("SongHandlerV2.UpdateSong", api.songHandlerV2.UpdateSong))
+	r.Patch("/songs/{id}", traced("SongHandlerV2.PatchSong", api.songHandlerV2.PatchSong))
+	r.Delete("/songs/{id}", traced("SongHandlerV2.DeleteSong", api.songHandlerV2.DeleteSong))
+}
+
+// idempotent подключает обработку Idempotency-Key к неидемпотентным маршрутам (добавление,
+// будущие пакетные операции); без настроенного хранилища заголовок игнорируется
+func (api *RestSongAPI) idempotent(next http.Handler) http.Handler {
+	if api.idempotency == nil {
+		return next
+	}
+	return idempotent(api.idempotency, api.idempotentTTL, api.logger)(next)
+}
+
+// limitTime ограничивает время обработки запроса, если оно задано WithLimits
+func (api *RestSongAPI) limitTime(next http.Handler) http.Handler {
+	if api.timeout <= 0 {
+		return next
+	}
+	return withTimeout(api.timeout)(next)
+}
+
+// deprecated добавляет к ответам заголовки Deprecation (RFC 9745: дата since в форме @<unix-секунды>),
+// Sunset (RFC 8594; только при заданной дате sunset) и ссылку на версию-преемника
+func deprecated(since time.Time, sunset time.Time, successor string) func(http.Handler) http.Handler {
+	deprecation := "@" + strconv.FormatInt(since.Unix(), 10)
+	sunsetValue := sunset.UTC().Format(http.TimeFormat)
+	link := "<" + successor + ">; rel=\"successor-version\""
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", deprecation)
+			if !sunset.IsZero() {
+				w.Header().Set("Sunset", sunsetValue)
+			}
+			w.Header().Add("Link", link)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
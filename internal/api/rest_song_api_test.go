@@ -0,0 +1,40 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDeprecatedHeaders(t *testing.T) {
+	since := time.Date(2026, time.October, 18, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2027, time.June, 30, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name            string
+		since           time.Time
+		sunset          time.Time
+		wantDeprecation string
+		wantSunset      string
+	}{
+		{"deprecation date only", since, time.Time{}, "@1792281600", ""},
+		{"with sunset", since, sunset, "@1792281600", "Wed, 30 Jun 2027 00:00:00 GMT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := deprecated(tt.since, tt.sunset, "/api/v2")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/songs", nil))
+
+			if got := w.Header().Get("Deprecation"); got != tt.wantDeprecation {
+				t.Errorf("Deprecation = %q, want %q", got, tt.wantDeprecation)
+			}
+			if got := w.Header().Get("Sunset"); got != tt.wantSunset {
+				t.Errorf("Sunset = %q, want %q", got, tt.wantSunset)
+			}
+			if got := w.Header().Get("Link"); got != `</api/v2>; rel="successor-version"` {
+				t.Errorf("Link = %q", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package api
+
+// Общая информация Swagger-спецификации API v1 (swag init -g swagger_v1.go --instanceName v1)
+
+// @title Music Catalog API
+// @version 1.0
+// @description Music catalog REST API, version 1. Deprecated: responses carry Deprecation and Sunset headers, use /api/v2 instead.
+// @BasePath /api/v1
+//
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @description API key issued via POST /api-keys
+//
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description "Bearer <JWT or API key>"
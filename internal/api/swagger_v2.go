@@ -0,0 +1,18 @@
+package api
+
+// Общая информация Swagger-спецификации API v2 (swag init -g swagger_v2.go --instanceName v2)
+
+// @title Music Catalog API
+// @version 2.0
+// @description Music catalog REST API, version 2. Responses are wrapped in a {"data", "meta"} envelope and errors use application/problem+json.
+// @BasePath /api/v2
+//
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @description API key issued via POST /api-keys
+//
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description "Bearer <JWT or API key>"
@@ -0,0 +1,24 @@
+package api
+
+import (
+	"net/http"
+
+	"music_catalog/internal/logger"
+	"music_catalog/internal/tenant"
+)
+
+// resolveTenant определяет арендатора запроса по клиенту из контекста и заголовку X-Tenant-ID
+// и кладёт его в контекст; репозитории ограничивают все запросы к каталогу этим арендатором.
+// Вызывается после authenticate.
+func resolveTenant(resolver *tenant.Resolver, logger logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t, err := resolver.Resolve(r.Context(), r.Header.Get(tenant.Header))
+			if err != nil {
+				respondProblem(logger, w, r, "Tenant resolution failed:", err)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(tenant.WithTenant(r.Context(), t)))
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"music_catalog/internal/logger"
+	"music_catalog/internal/models"
+	"music_catalog/internal/validation"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// TenantService интерфейс для управления арендаторами
+type TenantService interface {
+	CreateTenant(ctx context.Context, tenant models.Tenant) (models.Tenant, error)
+	GetTenants(ctx context.Context, pagination models.Pagination) ([]models.Tenant, error)
+}
+
+// TenantsHandler обрабатывает запросы управления арендаторами; ответы в формате конвертов v2
+type TenantsHandler struct {
+	tenantService TenantService
+	logger        logger.Logger
+}
+
+// NewTenantsHandler creates a new TenantsHandler with the provided tenant service
+func NewTenantsHandler(tenantService TenantService, logger logger.Logger) *TenantsHandler {
+	return &TenantsHandler{tenantService: tenantService, logger: logger}
+}
+
+// TenantRequest — тело запроса на создание арендатора
+type TenantRequest struct {
+	Slug string `json:"slug" example:"acme-records"`
+	Name string `json:"name" example:"ACME Records"`
+}
+
+// TenantEnvelope — ответ с одним арендатором
+type TenantEnvelope struct {
+	Data models.Tenant `json:"data"`
+}
+
+// TenantListEnvelope — ответ со списком арендаторов
+type TenantListEnvelope struct {
+	Data []models.Tenant `json:"data"`
+	Meta ListMeta        `json:"meta"`
+}
+
+// RegisterRoutes регистрирует маршруты управления арендаторами
+func (h *TenantsHandler) RegisterRoutes(r chi.Router) {
+	r.Post("/tenants", h.CreateTenant)
+	r.Get("/tenants", h.GetTenants)
+}
+
+// CreateTenant registers a new tenant (POST /tenants); platform admins only
+func (h *TenantsHandler) CreateTenant(w http.ResponseWriter, r *http.Request) {
+	var requestBody TenantRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		respondMalformedBody(h.logger, w, r, err)
+		return
+	}
+
+	tenant, err := h.tenantService.CreateTenant(r.Context(), models.Tenant{Slug: requestBody.Slug, Name: requestBody.Name})
+	if err != nil {
+		respondProblem(h.logger, w, r, "Error creating tenant:", err)
+		return
+	}
+
+	w.Header().Set("Location", r.URL.Path+"/"+tenant.Slug)
+	writeJSON(w, http.StatusCreated, TenantEnvelope{Data: tenant})
+}
+
+// GetTenants lists tenants with pagination (GET /tenants); platform admins only
+func (h *TenantsHandler) GetTenants(w http.ResponseWriter, r *http.Request) {
+	v := validation.New()
+	pagination := parsePagination(r.URL.Query(), v)
+	if err := v.Err(); err != nil {
+		respondProblem(h.logger, w, r, "Error parsing request parameters:", err)
+		return
+	}
+	if pagination.Limit == 0 {
+		pagination.Limit = 10
+	}
+
+	tenants, err := h.tenantService.GetTenants(r.Context(), pagination)
+	if err != nil {
+		respondProblem(h.logger, w, r, "Error getting tenants:", err)
+		return
+	}
+	if tenants == nil {
+		tenants = []models.Tenant{}
+	}
+
+	writeJSON(w, http.StatusOK, TenantListEnvelope{
+		Data: tenants,
+		Meta: ListMeta{Limit: pagination.Limit, Offset: pagination.Offset, Count: len(tenants)},
+	})
+}
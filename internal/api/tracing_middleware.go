@@ -0,0 +1,63 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+
+	"music_catalog/internal/logger"
+	"music_catalog/internal/tracing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traceRequests начинает серверный спан запроса, продолжая трассировку из заголовка traceparent
+// клиента; после маршрутизации спан получает имя по шаблону маршрута chi. Ответы 5xx попадают
+// в журнал с trace ID, по которому трассировку можно найти в коллекторе.
+func traceRequests(logger logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := tracing.Start(ctx, r.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					attribute.String("http.request.method", r.Method),
+					attribute.String("url.path", r.URL.Path),
+				))
+			defer span.End()
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			route := metricsRoute(r)
+			span.SetName(r.Method + " " + route)
+			span.SetAttributes(attribute.String("http.route", route))
+			if status := ww.Status(); status != 0 {
+				span.SetAttributes(attribute.Int("http.response.status_code", status))
+				if status >= http.StatusInternalServerError {
+					span.SetStatus(codes.Error, http.StatusText(status))
+					logger.WithContext(ctx).Error("Request failed:", r.Method, r.URL.Path, status)
+				}
+			}
+		})
+	}
+}
+
+// traced оборачивает обработчик в спан с именем метода обработчика (SongHandler.AddSong);
+// ID песни из пути попадает в атрибуты спана и в записи журнала (song_id)
+func traced(name string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracing.Start(r.Context(), name)
+		defer span.End()
+		if songID := chi.URLParam(r, "id"); songID != "" {
+			span.SetAttributes(attribute.String("song.id", songID))
+			ctx = logger.WithAttrs(ctx, slog.String("song_id", songID))
+		}
+		handler(w, r.WithContext(ctx))
+	}
+}
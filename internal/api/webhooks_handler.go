@@ -0,0 +1,253 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	catalog_errors "music_catalog/internal/errors"
+	"music_catalog/internal/logger"
+	"music_catalog/internal/models"
+	"music_catalog/internal/validation"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// WebhookService интерфейс для управления подписками на вебхуки
+type WebhookService interface {
+	CreateWebhook(ctx context.Context, webhook models.Webhook) (models.Webhook, error)
+	GetWebhooks(ctx context.Context, pagination models.Pagination) ([]models.Webhook, error)
+	GetWebhook(ctx context.Context, id int) (models.Webhook, error)
+	UpdateWebhook(ctx context.Context, webhook models.Webhook) (models.Webhook, error)
+	DeleteWebhook(ctx context.Context, id int) error
+	GetDeliveries(ctx context.Context, webhookID int, status string, pagination models.Pagination) ([]models.WebhookDelivery, error)
+	RetryDelivery(ctx context.Context, webhookID int, deliveryID int64) error
+}
+
+// WebhooksHandler обрабатывает запросы управления вебхуками; ответы в формате конвертов v2
+type WebhooksHandler struct {
+	webhookService WebhookService
+	logger         logger.Logger
+}
+
+// NewWebhooksHandler creates a new WebhooksHandler with the provided webhook service
+func NewWebhooksHandler(webhookService WebhookService, logger logger.Logger) *WebhooksHandler {
+	return &WebhooksHandler{webhookService: webhookService, logger: logger}
+}
+
+// WebhookRequest — тело запроса на создание или замену подписки
+type WebhookRequest struct {
+	TargetURL  string   `json:"target_url" example:"https://partner.example.com/hooks/catalog"`
+	EventTypes []string `json:"event_types" example:"song.created,song.updated"`
+	Secret     string   `json:"secret,omitempty"`
+	Active     *bool    `json:"active,omitempty"`
+}
+
+// toModel преобразует запрос в модель подписки; по умолчанию подписка активна
+func (req WebhookRequest) toModel() models.Webhook {
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+	return models.Webhook{
+		TargetURL:  req.TargetURL,
+		EventTypes: req.EventTypes,
+		Secret:     req.Secret,
+		Active:     active,
+	}
+}
+
+// WebhookEnvelope — ответ с одной подпиской
+type WebhookEnvelope struct {
+	Data models.Webhook `json:"data"`
+}
+
+// WebhookListEnvelope — ответ со списком подписок
+type WebhookListEnvelope struct {
+	Data []models.Webhook `json:"data"`
+	Meta ListMeta         `json:"meta"`
+}
+
+// WebhookDeliveryListEnvelope — ответ с журналом доставок подписки
+type WebhookDeliveryListEnvelope struct {
+	Data []models.WebhookDelivery `json:"data"`
+	Meta ListMeta                 `json:"meta"`
+}
+
+// RegisterRoutes регистрирует маршруты управления вебхуками
+func (h *WebhooksHandler) RegisterRoutes(r chi.Router) {
+	r.Post("/webhooks", h.CreateWebhook)
+	r.Get("/webhooks", h.GetWebhooks)
+	r.Get("/webhooks/{id}", h.GetWebhook)
+	r.Put("/webhooks/{id}", h.UpdateWebhook)
+	r.Delete("/webhooks/{id}", h.DeleteWebhook)
+	r.Get("/webhooks/{id}/deliveries", h.GetDeliveries)
+	r.Post("/webhooks/{id}/deliveries/{deliveryID}/retry", h.RetryDelivery)
+}
+
+// CreateWebhook registers a new subscription (POST /webhooks).
+// The response is the only one that contains the signing secret.
+func (h *WebhooksHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var requestBody WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		respondMalformedBody(h.logger, w, r, err)
+		return
+	}
+
+	webhook, err := h.webhookService.CreateWebhook(r.Context(), requestBody.toModel())
+	if err != nil {
+		respondProblem(h.logger, w, r, "Error creating webhook:", err)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("%s/%d", r.URL.Path, webhook.ID))
+	writeJSON(w, http.StatusCreated, WebhookEnvelope{Data: webhook})
+}
+
+// GetWebhooks lists subscriptions with pagination (GET /webhooks)
+func (h *WebhooksHandler) GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	v := validation.New()
+	pagination := parsePagination(r.URL.Query(), v)
+	if err := v.Err(); err != nil {
+		respondProblem(h.logger, w, r, "Error parsing request parameters:", err)
+		return
+	}
+	if pagination.Limit == 0 {
+		pagination.Limit = 10
+	}
+
+	webhooks, err := h.webhookService.GetWebhooks(r.Context(), pagination)
+	if err != nil {
+		respondProblem(h.logger, w, r, "Error getting webhooks:", err)
+		return
+	}
+	if webhooks == nil {
+		webhooks = []models.Webhook{}
+	}
+
+	writeJSON(w, http.StatusOK, WebhookListEnvelope{
+		Data: webhooks,
+		Meta: ListMeta{Limit: pagination.Limit, Offset: pagination.Offset, Count: len(webhooks)},
+	})
+}
+
+// GetWebhook returns a single subscription (GET /webhooks/{id})
+func (h *WebhooksHandler) GetWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		respondProblem(h.logger, w, r, "Invalid webhook ID:", err)
+		return
+	}
+
+	webhook, err := h.webhookService.GetWebhook(r.Context(), id)
+	if err != nil {
+		respondProblem(h.logger, w, r, "Error getting webhook:", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, WebhookEnvelope{Data: webhook})
+}
+
+// UpdateWebhook replaces a subscription (PUT /webhooks/{id}); an omitted secret keeps the current one
+func (h *WebhooksHandler) UpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		respondProblem(h.logger, w, r, "Invalid webhook ID:", err)
+		return
+	}
+
+	var requestBody WebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+		respondMalformedBody(h.logger, w, r, err)
+		return
+	}
+
+	webhook := requestBody.toModel()
+	webhook.ID = id
+	updated, err := h.webhookService.UpdateWebhook(r.Context(), webhook)
+	if err != nil {
+		respondProblem(h.logger, w, r, "Error updating webhook:", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, WebhookEnvelope{Data: updated})
+}
+
+// DeleteWebhook removes a subscription and its delivery log (DELETE /webhooks/{id})
+func (h *WebhooksHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		respondProblem(h.logger, w, r, "Invalid webhook ID:", err)
+		return
+	}
+
+	if err := h.webhookService.DeleteWebhook(r.Context(), id); err != nil {
+		respondProblem(h.logger, w, r, "Error deleting webhook:", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetDeliveries returns the delivery log of a subscription, newest first
+// (GET /webhooks/{id}/deliveries?status=pending|delivered|dead)
+func (h *WebhooksHandler) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		respondProblem(h.logger, w, r, "Invalid webhook ID:", err)
+		return
+	}
+
+	v := validation.New()
+	pagination := parsePagination(r.URL.Query(), v)
+	if err := v.Err(); err != nil {
+		respondProblem(h.logger, w, r, "Error parsing request parameters:", err)
+		return
+	}
+	if pagination.Limit == 0 {
+		pagination.Limit = 20
+	}
+
+	deliveries, err := h.webhookService.GetDeliveries(r.Context(), id, r.URL.Query().Get("status"), pagination)
+	if err != nil {
+		respondProblem(h.logger, w, r, "Error getting webhook deliveries:", err)
+		return
+	}
+	if deliveries == nil {
+		deliveries = []models.WebhookDelivery{}
+	}
+
+	writeJSON(w, http.StatusOK, WebhookDeliveryListEnvelope{
+		Data: deliveries,
+		Meta: ListMeta{Limit: pagination.Limit, Offset: pagination.Offset, Count: len(deliveries)},
+	})
+}
+
+// RetryDelivery puts a failed or dead-lettered delivery back into the queue
+// (POST /webhooks/{id}/deliveries/{deliveryID}/retry)
+func (h *WebhooksHandler) RetryDelivery(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "id")
+	if err != nil {
+		respondProblem(h.logger, w, r, "Invalid webhook ID:", err)
+		return
+	}
+	deliveryID, err := parseIDParam(r, "deliveryID")
+	if err != nil {
+		respondProblem(h.logger, w, r, "Invalid delivery ID:", err)
+		return
+	}
+
+	if err := h.webhookService.RetryDelivery(r.Context(), id, int64(deliveryID)); err != nil {
+		respondProblem(h.logger, w, r, "Error retrying webhook delivery:", err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// parseIDParam извлекает положительный целочисленный идентификатор из параметра URL
+func parseIDParam(r *http.Request, name string) (int, error) {
+	id, err := strconv.Atoi(chi.URLParam(r, name))
+	if err != nil || id < 1 {
+		return 0, catalog_errors.NewValidationError(catalog_errors.FieldError{Field: name, Message: "must be a positive integer"})
+	}
+	return id, nil
+}
@@ -0,0 +1,208 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	catalog_errors "music_catalog/internal/errors"
+	"music_catalog/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// apiKeyPrefix отличает API-ключи каталога от JWT и упрощает поиск утёкших ключей
+const apiKeyPrefix = "mc_"
+
+// KeyStore — хранилище API-ключей; в базе лежат только SHA-256 хэши ключей
+type KeyStore interface {
+	// GetAPIKeyByHash возвращает действующий (не отозванный) ключ по хэшу или ErrAPIKeyNotFound
+	GetAPIKeyByHash(ctx context.Context, hash string) (models.APIKey, error)
+	// TouchAPIKey отмечает время последнего использования ключа
+	TouchAPIKey(ctx context.Context, id int) error
+}
+
+// Config — параметры аутентификации
+type Config struct {
+	Disabled       bool                  // все запросы выполняются от имени администратора; только для локальной разработки
+	AdminAPIKey    string                // статический ключ администратора для первичной настройки (создания остальных ключей)
+	JWTHMACSecret  []byte                // секрет для токенов HS256/HS384/HS512
+	JWTPublicKeys  []jwt.VerificationKey // открытые ключи для токенов RS*/ES*/EdDSA
+	JWTIssuer      string                // ожидаемый iss; пустое значение не проверяется
+	JWTAudience    string                // ожидаемый aud; пустое значение не проверяется
+	JWTRoleClaim   string                // claim с ролью клиента, по умолчанию "role"
+	JWTTenantClaim string                // claim с арендатором клиента, по умолчанию "tenant"
+}
+
+// Authenticator определяет клиента по API-ключу или JWT
+type Authenticator struct {
+	keys   KeyStore
+	config Config
+	parser *jwt.Parser // nil — ключи JWT не настроены, токены не принимаются
+}
+
+// NewAuthenticator creates a new Authenticator
+func NewAuthenticator(keys KeyStore, config Config) *Authenticator {
+	if config.JWTRoleClaim == "" {
+		config.JWTRoleClaim = "role"
+	}
+	if config.JWTTenantClaim == "" {
+		config.JWTTenantClaim = "tenant"
+	}
+
+	var methods []string
+	if len(config.JWTHMACSecret) > 0 {
+		methods = append(methods, "HS256", "HS384", "HS512")
+	}
+	if len(config.JWTPublicKeys) > 0 {
+		methods = append(methods, "RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA")
+	}
+	authenticator := &Authenticator{keys: keys, config: config}
+	// Без ключей JWT не проверяется вовсе: пустой список алгоритмов в WithValidMethods
+	// отключил бы их проверку, и токен с пустым секретом HS256 прошёл бы проверку подписи
+	if len(methods) == 0 {
+		return authenticator
+	}
+
+	options := []jwt.ParserOption{jwt.WithValidMethods(methods), jwt.WithExpirationRequired(), jwt.WithLeeway(30 * time.Second)}
+	if config.JWTIssuer != "" {
+		options = append(options, jwt.WithIssuer(config.JWTIssuer))
+	}
+	if config.JWTAudience != "" {
+		options = append(options, jwt.WithAudience(config.JWTAudience))
+	}
+	authenticator.parser = jwt.NewParser(options...)
+	return authenticator
+}
+
+// Disabled сообщает, что аутентификация отключена
+func (a *Authenticator) Disabled() bool {
+	return a.config.Disabled
+}
+
+// Authenticate определяет клиента по предъявленным учётным данным: API-ключу или JWT.
+// Пустые учётные данные дают ErrUnauthenticated.
+func (a *Authenticator) Authenticate(ctx context.Context, credential string) (Principal, error) {
+	if a.config.Disabled {
+		return Principal{ID: "local", Name: "auth disabled", Role: RoleAdmin, Method: MethodNone}, nil
+	}
+	if credential == "" {
+		return Principal{}, catalog_errors.ErrUnauthenticated
+	}
+	if strings.HasPrefix(credential, apiKeyPrefix) || strings.Count(credential, ".") != 2 {
+		return a.authenticateAPIKey(ctx, credential)
+	}
+	return a.authenticateJWT(credential)
+}
+
+// authenticateAPIKey ищет ключ по хэшу
+func (a *Authenticator) authenticateAPIKey(ctx context.Context, key string) (Principal, error) {
+	if a.config.AdminAPIKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(a.config.AdminAPIKey)) == 1 {
+		return Principal{ID: "key:bootstrap", Name: "bootstrap admin key", Role: RoleAdmin, Method: MethodAPIKey}, nil
+	}
+
+	apiKey, err := a.keys.GetAPIKeyByHash(ctx, HashAPIKey(key))
+	if err != nil {
+		if errors.Is(err, catalog_errors.ErrAPIKeyNotFound) {
+			return Principal{}, fmt.Errorf("%w: invalid api key", catalog_errors.ErrUnauthenticated)
+		}
+		return Principal{}, err
+	}
+	role, err := ParseRole(apiKey.Role)
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: api key %d: %v", catalog_errors.ErrUnauthenticated, apiKey.ID, err)
+	}
+
+	// Отметка об использовании не должна замедлять или ломать запрос
+	go a.keys.TouchAPIKey(context.WithoutCancel(ctx), apiKey.ID)
+
+	return Principal{ID: fmt.Sprintf("key:%d", apiKey.ID), Name: apiKey.Name, Role: role, Method: MethodAPIKey, Tenant: apiKey.Tenant}, nil
+}
+
+// authenticateJWT проверяет подпись, срок действия, issuer и audience токена
+func (a *Authenticator) authenticateJWT(tokenString string) (Principal, error) {
+	if a.parser == nil {
+		return Principal{}, fmt.Errorf("%w: jwt authentication is not configured", catalog_errors.ErrUnauthenticated)
+	}
+	claims := jwt.MapClaims{}
+	_, err := a.parser.ParseWithClaims(tokenString, claims, a.verificationKey)
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: invalid token: %v", catalog_errors.ErrUnauthenticated, err)
+	}
+
+	subject, _ := claims.GetSubject()
+	if subject == "" {
+		return Principal{}, fmt.Errorf("%w: token has no subject", catalog_errors.ErrUnauthenticated)
+	}
+	roleClaim, _ := claims[a.config.JWTRoleClaim].(string)
+	role, err := ParseRole(roleClaim)
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: token claim %s: %v", catalog_errors.ErrUnauthenticated, a.config.JWTRoleClaim, err)
+	}
+	name, _ := claims["name"].(string)
+	if name == "" {
+		name = subject
+	}
+	tenant, _ := claims[a.config.JWTTenantClaim].(string)
+
+	return Principal{ID: subject, Name: name, Role: role, Method: MethodJWT, Tenant: tenant}, nil
+}
+
+// verificationKey выбирает ключ проверки по алгоритму токена
+func (a *Authenticator) verificationKey(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		if len(a.config.JWTHMACSecret) == 0 {
+			return nil, errors.New("hmac secret is not configured")
+		}
+		return a.config.JWTHMACSecret, nil
+	}
+	if len(a.config.JWTPublicKeys) == 0 {
+		return nil, errors.New("public keys are not configured")
+	}
+	return jwt.VerificationKeySet{Keys: a.config.JWTPublicKeys}, nil
+}
+
+// GenerateAPIKey создаёт новый ключ; клиенту отдаётся key, в базе хранится только hash
+func GenerateAPIKey() (key string, hash string, err error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	key = apiKeyPrefix + hex.EncodeToString(buf)
+	return key, HashAPIKey(key), nil
+}
+
+// HashAPIKey возвращает SHA-256 хэш ключа. Ключи случайные и длинные,
+// поэтому медленное хэширование, как для паролей, не требуется.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// LoadPublicKeys читает PEM-файлы с открытыми ключами RSA, ECDSA или Ed25519 для проверки JWT
+func LoadPublicKeys(paths []string) ([]jwt.VerificationKey, error) {
+	var keys []jwt.VerificationKey
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading jwt public key: %w", err)
+		}
+		if key, err := jwt.ParseRSAPublicKeyFromPEM(data); err == nil {
+			keys = append(keys, key)
+		} else if key, err := jwt.ParseECPublicKeyFromPEM(data); err == nil {
+			keys = append(keys, key)
+		} else if key, err := jwt.ParseEdPublicKeyFromPEM(data); err == nil {
+			keys = append(keys, key)
+		} else {
+			return nil, fmt.Errorf("jwt public key %s: unsupported or malformed PEM", path)
+		}
+	}
+	return keys, nil
+}
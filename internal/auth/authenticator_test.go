@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	catalog_errors "music_catalog/internal/errors"
+	"music_catalog/internal/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// noKeys — хранилище без API-ключей
+type noKeys struct{}
+
+func (noKeys) GetAPIKeyByHash(context.Context, string) (models.APIKey, error) {
+	return models.APIKey{}, catalog_errors.ErrAPIKeyNotFound
+}
+
+func (noKeys) TouchAPIKey(context.Context, int) error { return nil }
+
+func adminClaims() jwt.MapClaims {
+	return jwt.MapClaims{"sub": "attacker", "role": "admin", "exp": time.Now().Add(time.Hour).Unix()}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, key interface{}) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(method, adminClaims()).SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// Токены, подписанные пустым секретом или без подписи, не принимаются ни при какой настройке ключей
+func TestAuthenticateRejectsForgedTokens(t *testing.T) {
+	_, publicKey := mustEd25519(t)
+	configs := map[string]Config{
+		"no keys":          {},
+		"hmac secret":      {JWTHMACSecret: []byte("0123456789abcdef0123456789abcdef")},
+		"public keys only": {JWTPublicKeys: []jwt.VerificationKey{publicKey}},
+	}
+	forged := map[string]string{
+		"hs256 empty secret": sign(t, jwt.SigningMethodHS256, []byte{}),
+		"alg none":           sign(t, jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType),
+	}
+
+	for configName, config := range configs {
+		authenticator := NewAuthenticator(noKeys{}, config)
+		for tokenName, token := range forged {
+			t.Run(configName+"/"+tokenName, func(t *testing.T) {
+				principal, err := authenticator.Authenticate(context.Background(), token)
+				if !errors.Is(err, catalog_errors.ErrUnauthenticated) {
+					t.Fatalf("Authenticate() = %+v, %v; want ErrUnauthenticated", principal, err)
+				}
+			})
+		}
+	}
+}
+
+func TestAuthenticateAcceptsConfiguredKeys(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	privateKey, publicKey := mustEd25519(t)
+	tests := map[string]struct {
+		config Config
+		token  string
+	}{
+		"hmac":    {Config{JWTHMACSecret: secret}, sign(t, jwt.SigningMethodHS256, secret)},
+		"ed25519": {Config{JWTPublicKeys: []jwt.VerificationKey{publicKey}}, sign(t, jwt.SigningMethodEdDSA, privateKey)},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			principal, err := NewAuthenticator(noKeys{}, tt.config).Authenticate(context.Background(), tt.token)
+			if err != nil {
+				t.Fatalf("Authenticate() error = %v", err)
+			}
+			if principal.ID != "attacker" || principal.Role != RoleAdmin || principal.Method != MethodJWT {
+				t.Fatalf("Authenticate() = %+v", principal)
+			}
+		})
+	}
+}
+
+func mustEd25519(t *testing.T) (ed25519.PrivateKey, ed25519.PublicKey) {
+	t.Helper()
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return privateKey, publicKey
+}
@@ -0,0 +1,99 @@
+// Package auth authenticates API clients by API key or JWT and authorizes them by role
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	catalog_errors "music_catalog/internal/errors"
+)
+
+// Role — роль клиента; каждая следующая роль включает права предыдущей
+type Role string
+
+const (
+	RoleViewer Role = "viewer" // чтение каталога
+	RoleEditor Role = "editor" // изменение каталога
+	RoleAdmin  Role = "admin"  // управление ключами, вебхуками и прочими настройками
+)
+
+var roleRank = map[Role]int{RoleViewer: 1, RoleEditor: 2, RoleAdmin: 3}
+
+// ParseRole проверяет название роли
+func ParseRole(s string) (Role, error) {
+	role := Role(s)
+	if _, ok := roleRank[role]; !ok {
+		return "", fmt.Errorf("unknown role %q", s)
+	}
+	return role, nil
+}
+
+// Allows сообщает, достаточно ли роли r для действия, требующего роли required
+func (r Role) Allows(required Role) bool {
+	return roleRank[r] >= roleRank[required]
+}
+
+// Способы аутентификации
+const (
+	MethodAPIKey = "api_key"
+	MethodJWT    = "jwt"
+	MethodNone   = "none" // аутентификация отключена в конфигурации
+)
+
+// Principal — аутентифицированный клиент, от имени которого выполняется запрос
+type Principal struct {
+	ID     string `json:"id"`   // "key:<id>" для API-ключа, subject токена для JWT
+	Name   string `json:"name"` // человекочитаемое имя для логов
+	Role   Role   `json:"role"`
+	Method string `json:"method"`
+	Tenant string `json:"tenant,omitempty"` // арендатор, к которому привязан клиент; пусто — клиент уровня платформы
+}
+
+// String возвращает представление клиента для логов
+func (p Principal) String() string {
+	return fmt.Sprintf("%s(%s, %s)", p.ID, p.Name, p.Role)
+}
+
+type principalKey struct{}
+
+// WithPrincipal кладёт клиента в контекст запроса
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext возвращает клиента из контекста
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// Actor возвращает описание клиента для логов; "anonymous", если клиента в контексте нет
+func Actor(ctx context.Context) string {
+	if p, ok := FromContext(ctx); ok {
+		return p.String()
+	}
+	return "anonymous"
+}
+
+// Authorize проверяет, что в контексте есть клиент с ролью не ниже required
+func Authorize(ctx context.Context, required Role) error {
+	p, ok := FromContext(ctx)
+	if !ok {
+		return catalog_errors.ErrUnauthenticated
+	}
+	if !p.Role.Allows(required) {
+		return fmt.Errorf("%w: role %s required", catalog_errors.ErrForbidden, required)
+	}
+	return nil
+}
+
+// AuthorizePlatformAdmin проверяет, что клиент — администратор уровня платформы (не привязан к арендатору)
+func AuthorizePlatformAdmin(ctx context.Context) error {
+	if err := Authorize(ctx, RoleAdmin); err != nil {
+		return err
+	}
+	if p, _ := FromContext(ctx); p.Tenant != "" {
+		return fmt.Errorf("%w: platform admin required", catalog_errors.ErrForbidden)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+// Package buildinfo describes the running binary
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Задаются при сборке:
+//
+//	go build -ldflags "-X music_catalog/internal/buildinfo.Version=1.4.0 -X music_catalog/internal/buildinfo.Commit=$(git rev-parse HEAD)" ./cmd/app
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+)
+
+// Info — версия и происхождение сборки
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildTime string `json:"build_time,omitempty"`
+	Modified  bool   `json:"modified,omitempty"` // сборка из рабочей копии с незафиксированными изменениями
+	GoVersion string `json:"go_version"`
+}
+
+// Get returns the build information; the commit and build time fall back to the VCS
+// stamp that the go tool embeds when building inside a repository
+func Get() Info {
+	info := Info{Version: Version, Commit: Commit, BuildTime: BuildTime, GoVersion: runtime.Version()}
+	build, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	for _, setting := range build.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = setting.Value
+			}
+		case "vcs.time":
+			if info.BuildTime == "" {
+				info.BuildTime = setting.Value
+			}
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+	return info
+}
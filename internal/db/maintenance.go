@@ -0,0 +1,28 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// catalogTables — таблицы каталога, индексы которых перестраивает Reindex
+var catalogTables = []string{"songs", "song_events", "favorites", "playlists", "playlist_items"}
+
+// Reindex перестраивает индексы таблиц каталога и обновляет статистику планировщика.
+// С concurrently индексы строятся заново без блокировки записи (дольше и требует PostgreSQL 12+).
+func Reindex(ctx context.Context, db *sql.DB, concurrently bool) error {
+	reindex := "REINDEX TABLE "
+	if concurrently {
+		reindex = "REINDEX TABLE CONCURRENTLY "
+	}
+	for _, table := range catalogTables {
+		if _, err := db.ExecContext(ctx, reindex+table); err != nil {
+			return fmt.Errorf("reindex %s: %w", table, err)
+		}
+		if _, err := db.ExecContext(ctx, "ANALYZE "+table); err != nil {
+			return fmt.Errorf("analyze %s: %w", table, err)
+		}
+	}
+	return nil
+}
@@ -1,83 +1,243 @@
 package db
 
 import (
+	"context"
 	"database/sql"
-	"log"
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+
+	"music_catalog/internal/logger"
+	"music_catalog/migrations"
 
 	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/golang-migrate/migrate/v4/database/sqlite"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/lib/pq"
 )
 
-func RunMigrations(db *sql.DB) error {
-	driver, err := postgres.WithInstance(db, &postgres.Config{})
-	if err != nil {
-		return err
+// lockTimeoutMargin — запас к таймауту блокировки на стороне golang-migrate: первым должен сработать
+// lock_timeout в PostgreSQL, который освобождает соединение (см. lockTimeoutDriver)
+const lockTimeoutMargin = 5 * time.Second
+
+// Migration — встроенная миграция схемы
+type Migration struct {
+	Version uint   `json:"version"`
+	Name    string `json:"name"`
+}
+
+// MigrationStatus — состояние схемы относительно миграций, встроенных в бинарный файл
+type MigrationStatus struct {
+	Version uint        `json:"version"`           // применённая версия; 0 — миграции не применялись
+	Dirty   bool        `json:"dirty"`             // миграция Version прервалась, схему нужно исправить и выполнить migrate force
+	Latest  uint        `json:"latest"`            // последняя встроенная миграция
+	Pending []Migration `json:"pending,omitempty"` // встроенные миграции новее Version
+}
+
+// Migrator применяет к базе миграции из пакета migrations. Каждая операция открывает драйвер
+// golang-migrate заново и закрывает его по завершении, поэтому Migrator можно использовать
+// и в работающем сервисе.
+type Migrator struct {
+	source      fs.FS                                              // migrations.FS или migrations.SQLite
+	driver      func(ctx context.Context) (database.Driver, error) // драйвер базы на время одной операции
+	lockTimeout time.Duration                                      // ожидание блокировки в golang-migrate; 0 — по умолчанию
+	logger      logger.Logger
+}
+
+// NewMigrator creates a Migrator for the embedded PostgreSQL migrations. Операции выполняются на отдельном
+// соединении из пула и возвращают его по завершении (закрытие драйвера, созданного через WithInstance,
+// закрыло бы весь пул). Одновременно миграции применяет только один экземпляр: остальные ждут
+// блокировку не дольше lockTimeout.
+func NewMigrator(db *sql.DB, lockTimeout time.Duration, logger logger.Logger) *Migrator {
+	return &Migrator{
+		source: migrations.FS,
+		driver: func(ctx context.Context) (database.Driver, error) {
+			conn, err := db.Conn(ctx)
+			if err != nil {
+				return nil, err
+			}
+			driver, err := postgres.WithConnection(ctx, conn, &postgres.Config{})
+			if err != nil {
+				conn.Close()
+				return nil, err
+			}
+			return &lockTimeoutDriver{Driver: driver, conn: conn, timeout: lockTimeout}, nil
+		},
+		lockTimeout: lockTimeout + lockTimeoutMargin,
+		logger:      logger,
 	}
+}
 
-	m, err := migrate.NewWithDatabaseInstance(
-		"file://migrations", // Папка с миграциями
-		"postgres",          // Название базы данных
-		driver,
-	)
-	if err != nil {
-		return err
+// NewSQLiteMigrator creates a Migrator for the embedded SQLite migrations of the song catalog
+// (STORAGE_BACKEND=sqlite). Каждая операция открывает файл path отдельным пулом: драйвер
+// golang-migrate закрывает переданный ему пул. Одновременную запись из нескольких процессов
+// SQLite упорядочивает сама, ожидая блокировку файла.
+func NewSQLiteMigrator(path string, logger logger.Logger) *Migrator {
+	return &Migrator{
+		source: migrations.SQLite,
+		driver: func(ctx context.Context) (database.Driver, error) {
+			db, err := openSQLite(ctx, path)
+			if err != nil {
+				return nil, err
+			}
+			driver, err := sqlite.WithInstance(db, &sqlite.Config{})
+			if err != nil {
+				db.Close()
+				return nil, err
+			}
+			return driver, nil
+		},
+		logger: logger,
 	}
+}
 
-	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+// Up применяет все неприменённые миграции
+func (m *Migrator) Up(ctx context.Context) error {
+	err := m.run(ctx, func(migration *migrate.Migrate) error {
+		return migration.Up()
+	})
+	if errors.Is(err, migrate.ErrNoChange) {
+		m.logger.Info("Database schema is up to date")
+		return nil
+	}
+	if err != nil {
 		return err
 	}
-
-	log.Println("[INFO] Migrations applied successfully")
+	m.logger.Info("Migrations applied successfully")
 	return nil
 }
 
-func RollbackMigrations(db *sql.DB, steps int) error {
-	driver, err := postgres.WithInstance(db, &postgres.Config{})
-	if err != nil {
+// Down откатывает steps последних миграций
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	err := m.run(ctx, func(migration *migrate.Migrate) error {
+		return migration.Steps(-steps)
+	})
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
 		return err
 	}
+	m.logger.Info(fmt.Sprintf("Rolled back %d migrations", steps))
+	return nil
+}
 
-	m, err := migrate.NewWithDatabaseInstance(
-		"file://migrations",
-		"postgres",
-		driver,
-	)
+// Force записывает версию схемы без выполнения миграций и снимает признак dirty:
+// после ручного исправления схемы, на которой миграция прервалась. version -1 — схема без миграций.
+func (m *Migrator) Force(ctx context.Context, version int) error {
+	err := m.run(ctx, func(migration *migrate.Migrate) error {
+		return migration.Force(version)
+	})
 	if err != nil {
 		return err
 	}
+	m.logger.Info(fmt.Sprintf("Forced migration version %d", version))
+	return nil
+}
 
-	if err := m.Steps(-steps); err != nil && err != migrate.ErrNoChange {
+// Version возвращает текущую версию схемы и признак незавершённой (dirty) миграции;
+// migrate.ErrNilVersion — миграции не применялись
+func (m *Migrator) Version(ctx context.Context) (version uint, dirty bool, err error) {
+	err = m.run(ctx, func(migration *migrate.Migrate) error {
+		version, dirty, err = migration.Version()
 		return err
+	})
+	return version, dirty, err
+}
+
+// Status сравнивает версию схемы со встроенными миграциями
+func (m *Migrator) Status(ctx context.Context) (MigrationStatus, error) {
+	version, dirty, err := m.Version(ctx)
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return MigrationStatus{}, err
+	}
+	available, err := m.Migrations()
+	if err != nil {
+		return MigrationStatus{}, err
 	}
 
-	log.Printf("[INFO] Rolled back %d migrations", steps)
-	return nil
+	status := MigrationStatus{Version: version, Dirty: dirty}
+	for _, migration := range available {
+		status.Latest = migration.Version
+		if migration.Version > version {
+			status.Pending = append(status.Pending, migration)
+		}
+	}
+	return status, nil
 }
 
-func GetMigrationVersion(db *sql.DB) (uint, error) {
-	driver, err := postgres.WithInstance(db, &postgres.Config{})
+// Migrations перечисляет встроенные миграции по возрастанию версии
+func (m *Migrator) Migrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(m.source, ".")
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
+	var result []Migration
+	for _, entry := range entries {
+		parsed, err := source.Parse(entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", entry.Name(), err)
+		}
+		// Имена дополнены нулями, поэтому ReadDir уже вернул их по возрастанию версии
+		if parsed.Direction == source.Up {
+			result = append(result, Migration{Version: parsed.Version, Name: parsed.Identifier})
+		}
+	}
+	return result, nil
+}
 
-	m, err := migrate.NewWithDatabaseInstance(
-		"file://migrations",
-		"postgres",
-		driver,
-	)
+// run выполняет операцию golang-migrate над встроенными миграциями на отдельном соединении.
+// Отмена ctx останавливает применение миграций после текущей.
+func (m *Migrator) run(ctx context.Context, operation func(migration *migrate.Migrate) error) error {
+	driver, err := m.driver(ctx)
 	if err != nil {
-		return 0, err
+		return err
 	}
-
-	version, dirty, err := m.Version()
+	migrationSource, err := iofs.New(m.source, ".")
 	if err != nil {
-		return 0, err
+		driver.Close()
+		return err
 	}
+	migration, err := migrate.NewWithInstance("iofs", migrationSource, "database", driver)
+	if err != nil {
+		migrationSource.Close()
+		driver.Close()
+		return err
+	}
+	defer migration.Close() // закрывает и драйвер, и вместе с ним соединение
+	if m.lockTimeout > 0 {
+		migration.LockTimeout = m.lockTimeout
+	}
+
+	stop := context.AfterFunc(ctx, func() { migration.GracefulStop <- true })
+	defer stop()
+	return operation(migration)
+}
+
+// lockTimeoutDriver ограничивает ожидание блокировки миграций в самой базе. Драйвер PostgreSQL ждёт
+// pg_advisory_lock бесконечно, и после ErrLockTimeout на стороне golang-migrate соединение осталось бы
+// занятым запросом; lock_timeout действует только на время захвата блокировки, чтобы не прерывать
+// ожидание блокировок таблиц в самих миграциях.
+type lockTimeoutDriver struct {
+	database.Driver
+	conn    *sql.Conn
+	timeout time.Duration
+}
 
-	if dirty {
-		log.Println("[WARN] The migration is in a dirty state")
+// Lock захватывает блокировку миграций, ожидая не дольше timeout
+func (d *lockTimeoutDriver) Lock() error {
+	ctx := context.Background()
+	if _, err := d.conn.ExecContext(ctx, fmt.Sprintf("SET lock_timeout = %d", d.timeout.Milliseconds())); err != nil {
+		return err
 	}
+	defer d.conn.ExecContext(ctx, "RESET lock_timeout")
 
-	return version, nil
+	err := d.Driver.Lock()
+	var dbErr *database.Error
+	var pqErr *pq.Error
+	if errors.As(err, &dbErr) && errors.As(dbErr.OrigErr, &pqErr) && pqErr.Code == "55P03" { // lock_not_available
+		return migrate.ErrLockTimeout
+	}
+	return err
 }
@@ -1,28 +1,115 @@
 package db
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"log"
+	"net"
+	"net/url"
+	"time"
 
 	"music_catalog/config"
+	"music_catalog/internal/logger"
 
-	_ "github.com/lib/pq" // PostgreSQL driver
+	"github.com/lib/pq" // PostgreSQL driver
 )
 
-// NewDB initializes a new database connection
-func NewDB(config *config.Config) (*sql.DB, error) {
-	dataSourceName := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		config.DBHost, config.DBPort, config.DBUser, config.DBPassword, config.DBName, config.DBSSLMode)
-	db, err := sql.Open("postgres", dataSourceName)
+// Паузы между попытками подключения при запуске: удваиваются от connectRetryMin до connectRetryMax
+const (
+	connectRetryMin = 250 * time.Millisecond
+	connectRetryMax = 5 * time.Second
+)
+
+// DSN returns the PostgreSQL connection string: DB_DSN as is or a URL built from the split DB_* fields.
+// Поля экранируются, поэтому пароль может содержать пробелы, кавычки и любые другие символы.
+func DSN(config *config.Config) string {
+	if config.DBDSN != "" {
+		return config.DBDSN
+	}
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.User(config.DBUser),
+		Host:     net.JoinHostPort(config.DBHost, config.DBPort),
+		Path:     "/" + config.DBName,
+		RawQuery: url.Values{"sslmode": {config.DBSSLMode}}.Encode(),
+	}
+	if config.DBPassword != "" {
+		u.User = url.UserPassword(config.DBUser, config.DBPassword)
+	}
+	return u.String()
+}
+
+// NewDB initializes a new database connection, waiting up to DB_CONNECT_TIMEOUT for the database to come up
+func NewDB(ctx context.Context, config *config.Config, logger logger.Logger) (*sql.DB, error) {
+	db, err := open(ctx, DSN(config), config, logger)
 	if err != nil {
 		return nil, err
 	}
+	logger.Info("Successfully connected to the database")
+	return db, nil
+}
+
+// NewReplicaDB подключается к реплике для чтения (DB_REPLICA_DSN) с теми же настройками пула;
+// возвращает nil, если реплика не задана
+func NewReplicaDB(ctx context.Context, config *config.Config, logger logger.Logger) (*sql.DB, error) {
+	if config.DBReplicaDSN == "" {
+		return nil, nil
+	}
+	db, err := open(ctx, config.DBReplicaDSN, config, logger)
+	if err != nil {
+		return nil, fmt.Errorf("replica: %w", err)
+	}
+	logger.Info("Successfully connected to the read replica")
+	return db, nil
+}
 
-	if err := db.Ping(); err != nil {
+// open открывает пул соединений и проверяет подключение
+func open(ctx context.Context, dsn string, config *config.Config, logger logger.Logger) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
 		return nil, err
 	}
+	db.SetMaxOpenConns(config.DBMaxOpenConns)
+	db.SetMaxIdleConns(config.DBMaxIdleConns)
+	db.SetConnMaxLifetime(config.DBConnMaxLifetime)
+	db.SetConnMaxIdleTime(config.DBConnMaxIdleTime)
 
-	log.Println("[INFO] Successfully connected to the database")
+	if err := ping(ctx, db, config.DBConnectTimeout, logger); err != nil {
+		db.Close()
+		return nil, err
+	}
 	return db, nil
 }
+
+// ping проверяет подключение, повторяя попытки с растущей паузой, пока база поднимается (например,
+// при одновременном запуске контейнеров), но не дольше timeout. Ошибки авторизации не повторяются:
+// ожидание их не исправит.
+func ping(ctx context.Context, db *sql.DB, timeout time.Duration, logger logger.Logger) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	delay := connectRetryMin
+	for attempt := 1; ; attempt++ {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code.Class() == "28" { // invalid authorization specification
+			return err
+		}
+
+		if ctx.Err() != nil {
+			return fmt.Errorf("database is not available after %d attempts: %w", attempt, err)
+		}
+
+		logger.Info(fmt.Sprintf("Database is not available yet (attempt %d), retrying in %s: %v", attempt, delay, err))
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("database is not available after %d attempts: %w", attempt, err)
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, connectRetryMax)
+	}
+}
@@ -0,0 +1,67 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"music_catalog/config"
+	"music_catalog/internal/logger"
+
+	"modernc.org/sqlite" // SQLite driver без cgo
+)
+
+// sqliteBusyTimeout — сколько соединение ждёт блокировку файла, пока в базу пишет другое соединение
+// или процесс (например, catalogctl рядом с сервером), мс
+const sqliteBusyTimeout = 5000
+
+func init() {
+	// casefold(x) приводит строку к нижнему регистру с учётом Unicode: встроенные lower() и LIKE
+	// в SQLite не различают регистр только для ASCII, а ILIKE в PostgreSQL — для любых букв
+	sqlite.MustRegisterDeterministicScalarFunction("casefold", 1, func(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		switch v := args[0].(type) {
+		case string:
+			return strings.ToLower(v), nil
+		case []byte:
+			return strings.ToLower(string(v)), nil
+		default:
+			return v, nil
+		}
+	})
+}
+
+// NewSQLiteDB opens the SQLite song catalog at SQLITE_PATH, creating the file if it does not exist
+func NewSQLiteDB(ctx context.Context, config *config.Config, logger logger.Logger) (*sql.DB, error) {
+	db, err := openSQLite(ctx, config.SQLitePath)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite %s: %w", config.SQLitePath, err)
+	}
+	logger.Info(fmt.Sprintf("Successfully opened the SQLite database %s", config.SQLitePath))
+	return db, nil
+}
+
+// openSQLite открывает файл базы в режиме WAL: читатели не ждут пишущего, а транзакции сразу
+// захватывают блокировку записи (_txlock=immediate), чтобы две транзакции не упирались друг
+// в друга при переходе от чтения к записи
+func openSQLite(ctx context.Context, path string) (*sql.DB, error) {
+	params := url.Values{
+		"_pragma": {
+			fmt.Sprintf("busy_timeout(%d)", sqliteBusyTimeout),
+			"journal_mode(WAL)",
+			"foreign_keys(1)",
+		},
+		"_txlock": {"immediate"},
+	}
+	db, err := sql.Open("sqlite", path+"?"+params.Encode())
+	if err != nil {
+		return nil, err
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
@@ -1,9 +1,55 @@
 package catalog_errors
 
-import "errors"
+import (
+	"errors"
+	"strings"
+)
 
 var (
 	ErrSongNotFound = errors.New("song not found")
 	ErrInvalidPage  = errors.New("invalid page number")
 	ErrSongExists   = errors.New("song already exists")
+	ErrUpstream     = errors.New("external api failure")
+
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+
+	ErrUnauthenticated = errors.New("authentication required")
+	ErrForbidden       = errors.New("permission denied")
+	ErrAPIKeyNotFound  = errors.New("api key not found")
+
+	ErrPlaylistNotFound     = errors.New("playlist not found")
+	ErrPlaylistItemNotFound = errors.New("playlist item not found")
+
+	ErrTenantNotFound = errors.New("tenant not found")
+	ErrTenantExists   = errors.New("tenant already exists")
+
+	ErrIdempotencyKeyReused     = errors.New("idempotency key was used with a different request")
+	ErrIdempotencyKeyInProgress = errors.New("a request with this idempotency key is still being processed")
+
+	ErrRateLimited = errors.New("rate limit exceeded")
 )
+
+// FieldError — ошибка валидации конкретного поля запроса
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError — ошибка валидации входных данных со списком нарушений по полям
+type ValidationError struct {
+	Errors []FieldError
+}
+
+// NewValidationError creates a ValidationError from the given field errors
+func NewValidationError(fieldErrors ...FieldError) *ValidationError {
+	return &ValidationError{Errors: fieldErrors}
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, fe := range e.Errors {
+		messages = append(messages, fe.Field+": "+fe.Message)
+	}
+	return "validation failed: " + strings.Join(messages, "; ")
+}
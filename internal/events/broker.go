@@ -0,0 +1,266 @@
+package events
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"music_catalog/internal/logger"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// notifyChannel — канал PostgreSQL LISTEN/NOTIFY, в который пишет триггер song_events_notify;
+	// полезная нагрузка уведомления — ID арендатора, в поток которого записано событие
+	notifyChannel = "song_events"
+	// subscriberBuffer — размер очереди подписчика; переполнившийся подписчик отключается
+	// и должен переподключиться с Last-Event-ID
+	subscriberBuffer = 64
+	// fetchBatchSize — сколько событий журнала читается одним запросом, в том числе за один вызов Replay
+	fetchBatchSize = 100
+	pollInterval   = 30 * time.Second
+)
+
+// Subscription — подписка на поток событий
+type Subscription struct {
+	C      <-chan Event
+	events chan Event
+	filter Filter
+}
+
+// stream — поток событий арендатора, на который подписаны клиенты этого экземпляра
+type stream struct {
+	lastID      int64 // ID последнего разосланного события арендатора
+	subscribers int
+}
+
+// Broker раздаёт события из журнала подписчикам текущего экземпляра. С PostgreSQL (Run) новые
+// события обнаруживаются по его уведомлениям, поэтому изменения, сделанные другими экземплярами
+// сервиса, тоже доходят до подписчиков; без него (RunLocal) — по уведомлениям журнала процесса.
+// Журнал упорядочен только в пределах арендатора (см. Store), поэтому брокер ведёт отдельный курсор
+// для каждого арендатора, на поток которого есть подписчики.
+type Broker struct {
+	store  Store
+	logger logger.Logger
+
+	mu          sync.Mutex
+	subscribers map[*Subscription]struct{}
+	streams     map[int]*stream
+}
+
+// NewBroker creates a new Broker reading events from the store
+func NewBroker(store Store, logger logger.Logger) *Broker {
+	return &Broker{
+		store:       store,
+		logger:      logger,
+		subscribers: map[*Subscription]struct{}{},
+		streams:     map[int]*stream{},
+	}
+}
+
+// Subscribe регистрирует подписчика на события арендатора filter.TenantID; канал закрывается
+// при Unsubscribe или переполнении. Первый подписчик арендатора запоминает позицию его потока,
+// с которой брокер начинает раздавать события.
+func (b *Broker) Subscribe(ctx context.Context, filter Filter) (*Subscription, error) {
+	ch := make(chan Event, subscriberBuffer)
+	sub := &Subscription{C: ch, events: ch, filter: filter}
+	for {
+		b.mu.Lock()
+		if st, ok := b.streams[filter.TenantID]; ok {
+			st.subscribers++
+			b.subscribers[sub] = struct{}{}
+			b.mu.Unlock()
+			return sub, nil
+		}
+		b.mu.Unlock()
+
+		// Позиция читается без блокировки брокера, чтобы запрос к журналу не задерживал раздачу событий
+		lastID, err := b.store.GetLastEventID(ctx, filter.TenantID)
+		if err != nil {
+			return nil, err
+		}
+		b.mu.Lock()
+		if _, ok := b.streams[filter.TenantID]; !ok {
+			b.streams[filter.TenantID] = &stream{lastID: lastID}
+		}
+		b.mu.Unlock()
+	}
+}
+
+// Unsubscribe отменяет подписку
+func (b *Broker) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subscribers[sub]; ok {
+		b.remove(sub)
+	}
+}
+
+// remove отключает подписчика; поток арендатора без подписчиков больше не читается.
+// Вызывается под b.mu
+func (b *Broker) remove(sub *Subscription) {
+	delete(b.subscribers, sub)
+	close(sub.events)
+	if st := b.streams[sub.filter.TenantID]; st != nil {
+		st.subscribers--
+		if st.subscribers <= 0 {
+			delete(b.streams, sub.filter.TenantID)
+		}
+	}
+}
+
+// Replay возвращает сохранённые события арендатора filter.TenantID после afterID, подходящие под фильтр.
+// За вызов просматривается не больше fetchBatchSize событий журнала, чтобы длинный хвост не загружался
+// в память целиком: lastID — ID последнего просмотренного события, с которого продолжает следующий вызов,
+// done — хвост журнала прочитан.
+func (b *Broker) Replay(ctx context.Context, afterID int64, filter Filter) (matched []Event, lastID int64, done bool, err error) {
+	batch, err := b.store.GetEventsAfter(ctx, filter.TenantID, afterID, fetchBatchSize)
+	if err != nil {
+		return nil, afterID, false, err
+	}
+	lastID = afterID
+	for _, event := range batch {
+		if filter.Match(event) {
+			matched = append(matched, event)
+		}
+		lastID = event.ID
+	}
+	return matched, lastID, len(batch) < fetchBatchSize, nil
+}
+
+// Run слушает уведомления PostgreSQL по dsn и раздаёт новые события до отмены ctx
+func (b *Broker) Run(ctx context.Context, dsn string) error {
+	listener := pq.NewListener(dsn, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			b.logger.Error("Event listener connection problem:", err)
+		}
+	})
+	defer listener.Close()
+
+	if err := listener.Listen(notifyChannel); err != nil {
+		return err
+	}
+	b.logger.Info("Listening for catalog change events")
+
+	// nil-уведомление приходит после переподключения: пропущенные события дочитываются из потоков всех арендаторов
+	tenantOf := func(n *pq.Notification) (int, bool) {
+		if n == nil {
+			return 0, false
+		}
+		tenantID, err := strconv.Atoi(n.Extra)
+		return tenantID, err == nil
+	}
+	return serve(ctx, b, listener.NotificationChannel(), tenantOf, func() { go listener.Ping() })
+}
+
+// RunLocal раздаёт новые события до отмены ctx, узнавая о них по changes — уведомлениям журнала,
+// который ведёт этот же процесс (хранилища без PostgreSQL). Журнал всё равно перечитывается
+// раз в pollInterval: в общий файл SQLite может писать и другой процесс (catalogctl)
+func (b *Broker) RunLocal(ctx context.Context, changes <-chan struct{}) error {
+	b.logger.Info("Watching the local event log for catalog change events")
+	return serve(ctx, b, changes, func(struct{}) (int, bool) { return 0, false }, func() {})
+}
+
+// serve раздаёт события после каждого уведомления и раз в pollInterval (после tick) до отмены ctx.
+// tenantOf извлекает из уведомления арендатора, в поток которого записано событие; если арендатор
+// неизвестен, перечитываются потоки всех арендаторов с подписчиками.
+func serve[T any](ctx context.Context, b *Broker, notifications <-chan T, tenantOf func(T) (int, bool), tick func()) error {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			b.closeAll()
+			return nil
+		case notification := <-notifications:
+			if tenantID, ok := tenantOf(notification); ok {
+				b.dispatchNew(ctx, tenantID)
+			} else {
+				b.dispatchNew(ctx)
+			}
+		case <-ticker.C:
+			tick()
+			b.dispatchNew(ctx)
+		}
+	}
+}
+
+// dispatchNew дочитывает из потоков арендаторов tenantIDs (без аргументов — всех, у которых есть
+// подписчики) события после последних разосланных и раздаёт их
+func (b *Broker) dispatchNew(ctx context.Context, tenantIDs ...int) {
+	if len(tenantIDs) == 0 {
+		b.mu.Lock()
+		for tenantID := range b.streams {
+			tenantIDs = append(tenantIDs, tenantID)
+		}
+		b.mu.Unlock()
+	}
+	for _, tenantID := range tenantIDs {
+		b.dispatchStream(ctx, tenantID)
+	}
+}
+
+// dispatchStream раздаёт новые события арендатора. Курсор по ID не пропускает события: в потоке
+// арендатора их ID выдаются в порядке фиксации транзакций (см. Store)
+func (b *Broker) dispatchStream(ctx context.Context, tenantID int) {
+	for {
+		b.mu.Lock()
+		st, ok := b.streams[tenantID]
+		var afterID int64
+		if ok {
+			afterID = st.lastID
+		}
+		b.mu.Unlock()
+		if !ok {
+			return
+		}
+
+		batch, err := b.store.GetEventsAfter(ctx, tenantID, afterID, fetchBatchSize)
+		if err != nil {
+			b.logger.Error("Error fetching new events:", err)
+			return
+		}
+
+		b.mu.Lock()
+		// Пока шёл запрос, последний подписчик мог уйти, а новый — открыть поток заново со своей позиции
+		if b.streams[tenantID] != st {
+			b.mu.Unlock()
+			return
+		}
+		for _, event := range batch {
+			b.dispatch(event)
+			st.lastID = event.ID
+		}
+		b.mu.Unlock()
+		if len(batch) < fetchBatchSize {
+			return
+		}
+	}
+}
+
+// dispatch отправляет событие подходящим подписчикам; вызывается под b.mu
+func (b *Broker) dispatch(event Event) {
+	for sub := range b.subscribers {
+		if !sub.filter.Match(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			// Подписчик не успевает читать — отключаем его, клиент возобновит поток по Last-Event-ID
+			b.logger.Info("Dropping slow event subscriber at event", event.ID)
+			b.remove(sub)
+		}
+	}
+}
+
+func (b *Broker) closeAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for sub := range b.subscribers {
+		b.remove(sub)
+	}
+}
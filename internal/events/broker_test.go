@@ -0,0 +1,102 @@
+package events_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"music_catalog/internal/events"
+	"music_catalog/internal/logger"
+	"music_catalog/internal/models"
+	"music_catalog/internal/repository/memory_repo"
+	"music_catalog/internal/tenant"
+)
+
+func publish(t *testing.T, journal *memory_repo.MemoryEventRepository, tenantID int, songID int) {
+	t.Helper()
+	ctx := tenant.WithTenant(context.Background(), models.Tenant{ID: tenantID})
+	if err := journal.Publish(ctx, events.Event{Type: events.SongCreated, SongID: songID}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Подписчик получает события только своего арендатора и только опубликованные после подписки
+func TestBrokerDispatchesPerTenant(t *testing.T) {
+	journal := memory_repo.NewMemoryEventRepository()
+	broker := events.NewBroker(journal, logger.NewLogger("error"))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	publish(t, journal, 1, 100) // до подписки
+	first, err := broker.Subscribe(ctx, events.Filter{TenantID: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := broker.Subscribe(ctx, events.Filter{TenantID: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	go broker.RunLocal(ctx, journal.Changes())
+
+	publish(t, journal, 2, 200)
+	publish(t, journal, 1, 101)
+	publish(t, journal, 2, 201)
+
+	for _, tt := range []struct {
+		name string
+		sub  *events.Subscription
+		want []int
+	}{
+		{"tenant 1", first, []int{101}},
+		{"tenant 2", second, []int{200, 201}},
+	} {
+		for _, songID := range tt.want {
+			select {
+			case event := <-tt.sub.C:
+				if event.SongID != songID {
+					t.Errorf("%s: got event for song %d, want %d", tt.name, event.SongID, songID)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("%s: no event for song %d", tt.name, songID)
+			}
+		}
+	}
+	select {
+	case event := <-first.C:
+		t.Errorf("tenant 1: unexpected event for song %d", event.SongID)
+	default:
+	}
+}
+
+// Replay отдаёт хвост журнала арендатора страницами, не загружая его целиком
+func TestBrokerReplayPages(t *testing.T) {
+	journal := memory_repo.NewMemoryEventRepository()
+	broker := events.NewBroker(journal, logger.NewLogger("error"))
+	for i := 1; i <= 150; i++ {
+		publish(t, journal, 1, i)
+		publish(t, journal, 2, i)
+	}
+
+	var replayed []events.Event
+	pages := 0
+	for afterID, done := int64(0), false; !done; pages++ {
+		var page []events.Event
+		var err error
+		page, afterID, done, err = broker.Replay(context.Background(), afterID, events.Filter{TenantID: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page) > 100 {
+			t.Fatalf("page %d has %d events, want at most 100", pages, len(page))
+		}
+		replayed = append(replayed, page...)
+	}
+	if pages != 2 || len(replayed) != 150 {
+		t.Fatalf("replayed %d events in %d pages, want 150 in 2", len(replayed), pages)
+	}
+	for i, event := range replayed {
+		if event.TenantID != 1 || event.SongID != i+1 {
+			t.Fatalf("replayed[%d] = tenant %d song %d, want tenant 1 song %d", i, event.TenantID, event.SongID, i+1)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// Package events provides the catalog change feed: event types, publishing and fan-out to subscribers
+package events
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"music_catalog/internal/models"
+)
+
+// Типы событий изменения каталога
+const (
+	SongCreated = "song.created"
+	SongUpdated = "song.updated"
+	SongDeleted = "song.deleted"
+)
+
+// Event — событие изменения песни; Song содержит состояние после изменения
+// (для song.deleted — последнее состояние перед удалением)
+type Event struct {
+	ID         int64       `json:"id"`
+	Type       string      `json:"type"`
+	SongID     int         `json:"song_id"`
+	Song       models.Song `json:"song"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	TenantID   int         `json:"-"`
+}
+
+// Publisher публикует событие в журнал изменений
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// Store — журнал событий, из которого читают подписчики. Поток каждого арендатора упорядочен
+// отдельно: ID событий одного арендатора выдаются в порядке фиксации, и событие с меньшим ID
+// не может стать видимым после события с большим, поэтому курсор по ID в потоке арендатора
+// (Broker, Last-Event-ID) ничего не пропускает. Между арендаторами такого порядка нет.
+type Store interface {
+	// GetEventsAfter возвращает не больше limit событий арендатора tenantID с ID больше afterID
+	// в порядке возрастания ID
+	GetEventsAfter(ctx context.Context, tenantID int, afterID int64, limit int) ([]Event, error)
+	// GetLastEventID возвращает ID последнего события арендатора tenantID или 0, если событий нет
+	GetLastEventID(ctx context.Context, tenantID int) (int64, error)
+}
+
+// Filter отбирает события для подписчика; пустые поля не ограничивают выборку
+type Filter struct {
+	Types  []string `json:"types,omitempty"`
+	Group  string   `json:"group,omitempty"`
+	SongID int      `json:"song_id,omitempty"`
+	// TenantID задаётся сервером по арендатору подписчика, а не клиентом; подписка всегда
+	// ограничена одним арендатором
+	TenantID int `json:"-"`
+}
+
+// Match сообщает, подходит ли событие под фильтр
+func (f Filter) Match(event Event) bool {
+	if f.TenantID != 0 && f.TenantID != event.TenantID {
+		return false
+	}
+	if len(f.Types) > 0 {
+		matched := false
+		for _, t := range f.Types {
+			if t == event.Type {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if f.Group != "" && !strings.EqualFold(f.Group, event.Song.Group) {
+		return false
+	}
+	if f.SongID != 0 && f.SongID != event.SongID {
+		return false
+	}
+	return true
+}
+
+// NopPublisher отбрасывает события; используется, когда журнал изменений не нужен
+type NopPublisher struct{}
+
+// Publish implements Publisher
+func (NopPublisher) Publish(ctx context.Context, event Event) error {
+	return nil
+}
@@ -0,0 +1,99 @@
+// Package export renders playlists in formats understood by media players
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"music_catalog/internal/models"
+)
+
+// Поддерживаемые форматы выгрузки
+const (
+	FormatM3U  = "m3u"
+	FormatXSPF = "xspf"
+)
+
+// ContentType возвращает MIME-тип формата и расширение файла
+func ContentType(format string) (contentType string, extension string, ok bool) {
+	switch format {
+	case FormatM3U:
+		return "audio/x-mpegurl; charset=utf-8", "m3u8", true
+	case FormatXSPF:
+		return "application/xspf+xml", "xspf", true
+	default:
+		return "", "", false
+	}
+}
+
+// Write выгружает плейлист в указанном формате
+func Write(w io.Writer, format string, playlist models.Playlist) error {
+	switch format {
+	case FormatM3U:
+		return WriteM3U(w, playlist)
+	case FormatXSPF:
+		return WriteXSPF(w, playlist)
+	default:
+		return fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+// WriteM3U выгружает плейлист в расширенном формате M3U (UTF-8); ссылкой на трек служит link песни
+func WriteM3U(w io.Writer, playlist models.Playlist) error {
+	var b strings.Builder
+	b.WriteString("#EXTM3U\n")
+	fmt.Fprintf(&b, "#PLAYLIST:%s\n", m3uLine(playlist.Name))
+	for _, item := range playlist.Items {
+		fmt.Fprintf(&b, "#EXTINF:-1,%s - %s\n", m3uLine(item.Song.Group), m3uLine(item.Song.Title))
+		fmt.Fprintf(&b, "%s\n", m3uLine(item.Song.Link))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// m3uLine убирает переводы строк, которые сломали бы построчный формат
+func m3uLine(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
+
+// xspfPlaylist — корневой элемент XSPF (https://xspf.org/spec)
+type xspfPlaylist struct {
+	XMLName    xml.Name    `xml:"http://xspf.org/ns/0/ playlist"`
+	Version    string      `xml:"version,attr"`
+	Title      string      `xml:"title"`
+	Annotation string      `xml:"annotation,omitempty"`
+	Tracks     []xspfTrack `xml:"trackList>track"`
+}
+
+type xspfTrack struct {
+	Location string `xml:"location,omitempty"`
+	Creator  string `xml:"creator"`
+	Title    string `xml:"title"`
+	TrackNum int    `xml:"trackNum"`
+}
+
+// WriteXSPF выгружает плейлист в формате XSPF
+func WriteXSPF(w io.Writer, playlist models.Playlist) error {
+	doc := xspfPlaylist{Version: "1", Title: playlist.Name, Annotation: playlist.Description, Tracks: []xspfTrack{}}
+	for _, item := range playlist.Items {
+		doc.Tracks = append(doc.Tracks, xspfTrack{
+			Location: item.Song.Link,
+			Creator:  item.Song.Group,
+			Title:    item.Song.Title,
+			TrackNum: item.Position,
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
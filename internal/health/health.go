@@ -0,0 +1,137 @@
+// Package health runs readiness checks of the service dependencies
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Статусы проверок и отчёта
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded" // не прошла некритичная проверка: сервис продолжает принимать трафик
+	StatusFail     = "fail"
+)
+
+// CheckFunc проверяет зависимость; details попадают в отчёт (например, версия схемы)
+type CheckFunc func(ctx context.Context) (details any, err error)
+
+// Result — итог одной проверки
+type Result struct {
+	Status     string `json:"status"`
+	Critical   bool   `json:"critical"`
+	Error      string `json:"error,omitempty"`
+	Details    any    `json:"details,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// Report — итог всех проверок
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Ready сообщает, прошли ли все критичные проверки
+func (r Report) Ready() bool {
+	return r.Status != StatusFail
+}
+
+// Summary — отчёт только со статусами проверок, без текстов ошибок и подробностей:
+// его можно отдавать без аутентификации
+type Summary struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+// Summary убирает из отчёта ошибки и подробности проверок
+func (r Report) Summary() Summary {
+	summary := Summary{Status: r.Status, Checks: make(map[string]string, len(r.Checks))}
+	for name, result := range r.Checks {
+		summary.Checks[name] = result.Status
+	}
+	return summary
+}
+
+type check struct {
+	name     string
+	critical bool
+	run      CheckFunc
+}
+
+// Checker выполняет зарегистрированные проверки параллельно, каждую не дольше timeout
+type Checker struct {
+	timeout time.Duration
+	checks  []check
+}
+
+// NewChecker creates a Checker that gives each check at most timeout
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// Add регистрирует проверку; проваленная критичная проверка делает сервис неготовым,
+// некритичная только понижает статус до degraded
+func (c *Checker) Add(name string, critical bool, run CheckFunc) *Checker {
+	c.checks = append(c.checks, check{name: name, critical: critical, run: run})
+	return c
+}
+
+// Run выполняет все проверки и собирает отчёт
+func (c *Checker) Run(ctx context.Context) Report {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	results := make([]Result, len(c.checks))
+	var wg sync.WaitGroup
+	for i, check := range c.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			details, err := check.run(ctx)
+			result := Result{Status: StatusOK, Critical: check.critical, Details: details, DurationMs: time.Since(start).Milliseconds()}
+			if err != nil {
+				result.Status, result.Error = StatusFail, err.Error()
+			}
+			results[i] = result
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]Result, len(c.checks))}
+	for i, check := range c.checks {
+		result := results[i]
+		report.Checks[check.name] = result
+		switch {
+		case result.Status == StatusOK:
+		case check.critical:
+			report.Status = StatusFail
+		case report.Status == StatusOK:
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+// Cached запоминает результат проверки на ttl(): дорогие проверки внешних сервисов
+// не выполняются на каждый запрос оркестратора. Срок запрашивается при каждой проверке,
+// поэтому его можно менять без перезапуска
+func Cached(ttl func() time.Duration, run CheckFunc) CheckFunc {
+	var (
+		mu      sync.Mutex
+		checked time.Time
+		details any
+		err     error
+	)
+	return func(ctx context.Context) (any, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !checked.IsZero() && time.Since(checked) < ttl() {
+			return details, err
+		}
+		details, err = run(ctx)
+		checked = time.Now()
+		return details, err
+	}
+}
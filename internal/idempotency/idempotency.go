@@ -0,0 +1,68 @@
+// Package idempotency stores responses of non-idempotent requests under client-supplied
+// Idempotency-Key values so that retried requests are answered without being executed again
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"time"
+)
+
+// Header — заголовок запроса с ключом идемпотентности
+const Header = "Idempotency-Key"
+
+// ReplayedHeader — заголовок ответа, отмечающий повтор сохранённого ответа
+const ReplayedHeader = "Idempotent-Replayed"
+
+// Lease — сколько ключ может оставаться занятым выполняющимся запросом. Если экземпляр сервиса
+// упал, не сохранив ответ, по истечении Lease ключ можно занять заново.
+const Lease = 5 * time.Minute
+
+// StoredHeaders — заголовки ответа, которые сохраняются и воспроизводятся вместе с телом.
+// Остальные заголовки (RateLimit-*, Deprecation и т.п.) выставляются заново при каждом запросе.
+var StoredHeaders = []string{"Content-Type", "Location"}
+
+// Scope — владелец ключа: одинаковые ключи разных клиентов и арендаторов не пересекаются
+type Scope struct {
+	TenantID  int
+	Principal string
+	Key       string
+}
+
+// Response — сохранённый ответ на запрос
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// Record — состояние ключа
+type Record struct {
+	RequestHash string
+	Response    *Response // nil, пока первый запрос с этим ключом выполняется
+}
+
+// Store — хранилище ключей идемпотентности
+type Store interface {
+	// Begin резервирует ключ за запросом с хэшем requestHash на ttl. Если ключ свободен, истёк
+	// или брошен выполнявшимся запросом дольше Lease, возвращает created = true; иначе — текущее состояние ключа.
+	Begin(ctx context.Context, scope Scope, requestHash string, ttl time.Duration) (record Record, created bool, err error)
+	// Complete сохраняет ответ на запрос, зарезервировавший ключ
+	Complete(ctx context.Context, scope Scope, response Response) error
+	// Release освобождает ключ, чтобы запрос можно было повторить (после ошибки сервера)
+	Release(ctx context.Context, scope Scope) error
+}
+
+// HashRequest возвращает хэш запроса: метод, путь и тело. Повтор с тем же ключом
+// и другим хэшем — ошибка клиента.
+func HashRequest(method string, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{0})
+	h.Write([]byte(path))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,86 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// sweepInterval — как часто MemoryStore удаляет истёкшие ключи
+const sweepInterval = 10 * time.Minute
+
+type entry struct {
+	requestHash string
+	response    *Response
+	createdAt   time.Time
+	expiresAt   time.Time
+}
+
+// MemoryStore хранит ключи в памяти процесса: для установок без PostgreSQL, где сервис работает
+// одним экземпляром. Ключи теряются при перезапуске
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[Scope]*entry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryStore creates a new in-memory idempotency key store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: map[Scope]*entry{}, lastSweep: time.Now(), now: time.Now}
+}
+
+// Begin implements Store
+func (s *MemoryStore) Begin(ctx context.Context, scope Scope, requestHash string, ttl time.Duration) (Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		s.sweep(now)
+	}
+
+	e, ok := s.entries[scope]
+	// Занятый ключ перезаписывается, только если он истёк или запрос не сохранил ответ за Lease
+	if ok && !now.After(e.expiresAt) && (e.response != nil || now.Sub(e.createdAt) < Lease) {
+		return Record{RequestHash: e.requestHash, Response: e.response}, false, nil
+	}
+	s.entries[scope] = &entry{requestHash: requestHash, createdAt: now, expiresAt: now.Add(ttl)}
+	return Record{RequestHash: requestHash}, true, nil
+}
+
+// Complete implements Store
+func (s *MemoryStore) Complete(ctx context.Context, scope Scope, response Response) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.entries[scope]; ok {
+		response.Header = response.Header.Clone()
+		if response.Header == nil {
+			response.Header = http.Header{}
+		}
+		response.Body = append([]byte(nil), response.Body...)
+		e.response = &response
+	}
+	return nil
+}
+
+// Release implements Store
+func (s *MemoryStore) Release(ctx context.Context, scope Scope) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if e, ok := s.entries[scope]; ok && e.response == nil {
+		delete(s.entries, scope)
+	}
+	return nil
+}
+
+// sweep удаляет истёкшие ключи
+func (s *MemoryStore) sweep(now time.Time) {
+	for scope, e := range s.entries {
+		if now.After(e.expiresAt) {
+			delete(s.entries, scope)
+		}
+	}
+	s.lastSweep = now
+}
@@ -0,0 +1,141 @@
+// Package lifecycle runs the application's servers and background workers and shuts them down in order
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"music_catalog/internal/logger"
+)
+
+// Server — сервер, принимающий соединения до вызова Shutdown
+type Server interface {
+	// Serve блокируется до остановки сервера; штатная остановка возвращает nil
+	Serve() error
+	// Shutdown дожидается завершения обрабатываемых запросов, но не дольше ctx
+	Shutdown(ctx context.Context) error
+}
+
+type namedServer struct {
+	name   string
+	server Server
+}
+
+type namedCloser struct {
+	name  string
+	close func() error
+}
+
+// Lifecycle запускает серверы и фоновые задачи и останавливает их по сигналу SIGINT/SIGTERM
+// или при падении любого из серверов. Порядок остановки: серверы перестают принимать запросы
+// и дожидаются текущих, затем отменяются фоновые задачи, затем освобождаются ресурсы
+// (пул соединений с базой) в порядке, обратном регистрации.
+type Lifecycle struct {
+	logger          logger.Logger
+	shutdownTimeout time.Duration
+
+	servers []namedServer
+	workers []func(ctx context.Context)
+	closers []namedCloser
+}
+
+// New creates a Lifecycle that gives servers and workers shutdownTimeout to drain
+func New(shutdownTimeout time.Duration, logger logger.Logger) *Lifecycle {
+	return &Lifecycle{logger: logger, shutdownTimeout: shutdownTimeout}
+}
+
+// Serve регистрирует сервер; он запускается в Run
+func (l *Lifecycle) Serve(name string, server Server) {
+	l.servers = append(l.servers, namedServer{name: name, server: server})
+}
+
+// Go регистрирует фоновую задачу; её контекст отменяется после остановки серверов.
+// Ошибка задачи записывается в журнал и не останавливает приложение.
+func (l *Lifecycle) Go(name string, run func(ctx context.Context) error) {
+	l.workers = append(l.workers, func(ctx context.Context) {
+		if err := run(ctx); err != nil {
+			l.logger.Error(fmt.Sprintf("%s stopped:", name), err)
+		}
+	})
+}
+
+// OnClose регистрирует освобождение ресурса после остановки фоновых задач
+func (l *Lifecycle) OnClose(name string, close func() error) {
+	l.closers = append(l.closers, namedCloser{name: name, close: close})
+}
+
+// Run запускает зарегистрированные серверы и задачи и блокируется до сигнала остановки,
+// отмены ctx или падения сервера. Возвращает ошибку, если сервер упал или остановка не уложилась в срок.
+func (l *Lifecycle) Run(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	workerCtx, cancelWorkers := context.WithCancel(context.Background())
+	defer cancelWorkers()
+	var workers sync.WaitGroup
+	for _, run := range l.workers {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(workerCtx)
+		}()
+	}
+
+	serveErrors := make(chan error, len(l.servers))
+	for _, s := range l.servers {
+		go func() {
+			if err := s.server.Serve(); err != nil {
+				serveErrors <- fmt.Errorf("%s: %w", s.name, err)
+			}
+		}()
+	}
+
+	var errs []error
+	select {
+	case <-ctx.Done():
+		l.logger.Info("Shutdown signal received, draining in-flight requests...")
+	case err := <-serveErrors:
+		errs = append(errs, err)
+		l.logger.Error("Server stopped unexpectedly, shutting down:", err)
+	}
+	stop() // повторный сигнал завершает процесс немедленно
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), l.shutdownTimeout)
+	defer cancel()
+
+	for _, s := range l.servers {
+		if err := s.server.Shutdown(shutdownCtx); err != nil {
+			errs = append(errs, fmt.Errorf("%s shutdown: %w", s.name, err))
+		}
+	}
+
+	cancelWorkers()
+	done := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-shutdownCtx.Done():
+		errs = append(errs, errors.New("background workers did not stop before the shutdown deadline"))
+	}
+
+	for i := len(l.closers) - 1; i >= 0; i-- {
+		if err := l.closers[i].close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s close: %w", l.closers[i].name, err))
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	l.logger.Info("Shutdown complete")
+	return nil
+}
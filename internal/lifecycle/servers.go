@@ -0,0 +1,75 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"google.golang.org/grpc"
+)
+
+// HTTPServer адаптирует http.Server к Server
+type HTTPServer struct {
+	server   *http.Server
+	listener net.Listener
+}
+
+// NewHTTPServer wraps server that serves connections accepted by listener
+func NewHTTPServer(server *http.Server, listener net.Listener) *HTTPServer {
+	return &HTTPServer{server: server, listener: listener}
+}
+
+// Serve implements Server
+func (s *HTTPServer) Serve() error {
+	if err := s.server.Serve(s.listener); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown implements Server. Соединения, не закрывшиеся к сроку, обрываются.
+func (s *HTTPServer) Shutdown(ctx context.Context) error {
+	if err := s.server.Shutdown(ctx); err != nil {
+		s.server.Close()
+		return err
+	}
+	return nil
+}
+
+// GRPCServer адаптирует grpc.Server к Server
+type GRPCServer struct {
+	server   *grpc.Server
+	listener net.Listener
+}
+
+// NewGRPCServer wraps server that serves connections accepted by listener
+func NewGRPCServer(server *grpc.Server, listener net.Listener) *GRPCServer {
+	return &GRPCServer{server: server, listener: listener}
+}
+
+// Serve implements Server
+func (s *GRPCServer) Serve() error {
+	if err := s.server.Serve(s.listener); !errors.Is(err, grpc.ErrServerStopped) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown implements Server. GracefulStop не принимает контекст, поэтому по истечении срока
+// незавершённые вызовы (в том числе потоковые) прерываются через Stop.
+func (s *GRPCServer) Shutdown(ctx context.Context) error {
+	stopped := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.server.Stop()
+		<-stopped
+		return ctx.Err()
+	}
+}
@@ -2,7 +2,12 @@
 package logger
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
 )
 
 // Logger — интерфейс для логирования
@@ -10,63 +15,106 @@ type Logger interface {
 	Debug(args ...interface{})
 	Info(args ...interface{})
 	Error(args ...interface{})
+	// WithContext возвращает логгер, добавляющий к записям поля из контекста:
+	// request_id, principal, tenant, song_id (см. WithAttrs) и trace_id/span_id текущего спана
+	WithContext(ctx context.Context) Logger
 }
 
-// LogLevel type for log levels
-type LogLevel int
-
+// Форматы вывода
 const (
-	DEBUG LogLevel = iota
-	INFO
-	ERROR
-	FATAL
+	FormatJSON = "json"
+	FormatText = "text"
 )
 
-// StandardLogger реализация логгера с использованием стандартного логирования
-type StandardLogger struct {
-	level LogLevel
+// SlogLogger реализация Logger поверх log/slog
+type SlogLogger struct {
+	logger *slog.Logger
+	level  *slog.LevelVar // общий для логгера и всех его копий из WithContext
+	ctx    context.Context
 }
 
-// NewLogger creates a new Logger instance based on log level from environment
-func NewLogger(levelStr string) *StandardLogger {
-	level := getLogLevel(levelStr)
-	return &StandardLogger{level: level}
-}
+// New creates a Logger writing records of at least the given level (debug, info, warn, error)
+// to w in the given format (json or text)
+func New(level string, format string, w io.Writer) (*SlogLogger, error) {
+	lvl := new(slog.LevelVar)
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("unknown log level %q", level)
+	}
+	options := &slog.HandlerOptions{Level: lvl}
 
-// getLogLevel fetches log level from environment or defaults to INFO
-func getLogLevel(levelStr string) LogLevel {
-	switch levelStr {
-	case "debug":
-		return DEBUG
-	case "error":
-		return ERROR
-	case "fatal":
-		return FATAL
+	var handler slog.Handler
+	switch format {
+	case FormatJSON:
+		handler = slog.NewJSONHandler(w, options)
+	case FormatText:
+		handler = slog.NewTextHandler(w, options)
 	default:
-		return INFO
+		return nil, fmt.Errorf("unknown log format %q", format)
 	}
+	return &SlogLogger{logger: slog.New(contextHandler{handler}), level: lvl, ctx: context.Background()}, nil
 }
 
-// Debug logs
-func (l *StandardLogger) Debug(v ...interface{}) {
-	if l.level <= DEBUG {
-		log.Println("[DEBUG]", v)
+// NewLogger creates a text Logger writing to stderr; unknown levels fall back to info
+func NewLogger(level string) *SlogLogger {
+	l, err := New(level, FormatText, os.Stderr)
+	if err != nil {
+		l, _ = New("info", FormatText, os.Stderr)
 	}
+	return l
 }
 
-// Info logs
-func (l *StandardLogger) Info(v ...interface{}) {
-	if l.level <= INFO {
-		log.Println("[INFO]", v)
+// SetLevel меняет уровень журнала на ходу, в том числе для уже созданных копий логгера
+func (l *SlogLogger) SetLevel(level string) error {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("unknown log level %q", level)
 	}
+	l.level.Set(lvl)
+	return nil
+}
+
+// SetDefault делает логгер журналом по умолчанию для slog и стандартного пакета log,
+// чтобы записи сторонних библиотек шли в тот же вывод и формат
+func (l *SlogLogger) SetDefault() {
+	slog.SetDefault(l.logger)
+}
+
+// Debug logs
+func (l *SlogLogger) Debug(args ...interface{}) {
+	l.logger.Log(l.ctx, slog.LevelDebug, message(args))
+}
+
+// Info logs
+func (l *SlogLogger) Info(args ...interface{}) {
+	l.logger.Log(l.ctx, slog.LevelInfo, message(args))
 }
 
 // Error logs
-func (l *StandardLogger) Error(v ...interface{}) {
-	log.Println("[ERROR]", v)
+func (l *SlogLogger) Error(args ...interface{}) {
+	l.logger.Log(l.ctx, slog.LevelError, message(args))
 }
 
-// Fatal logs and exits
-func (l *StandardLogger) Fatal(v ...interface{}) {
-	log.Fatal("[FATAL]", v)
+// Fatal logs a printf-style message and exits
+func (l *SlogLogger) Fatal(format string, v ...interface{}) {
+	l.logger.Log(l.ctx, slog.LevelError, fmt.Sprintf(format, v...))
+	os.Exit(1)
+}
+
+// WithContext implements Logger
+func (l *SlogLogger) WithContext(ctx context.Context) Logger {
+	return &SlogLogger{logger: l.logger, level: l.level, ctx: ctx}
+}
+
+// message склеивает аргументы через пробел, как log.Println, но без лишнего пробела
+// после аргументов, которые уже им заканчиваются ("Error: ", err)
+func message(args []interface{}) string {
+	var b strings.Builder
+	for i, arg := range args {
+		s := fmt.Sprint(arg)
+		if i > 0 && !strings.HasSuffix(b.String(), " ") {
+			b.WriteByte(' ')
+		}
+		b.WriteString(s)
+	}
+	return strings.TrimSpace(b.String())
 }
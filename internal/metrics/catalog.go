@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"music_catalog/internal/models"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// CatalogStatsSource — источник размеров каталогов арендаторов
+type CatalogStatsSource interface {
+	GetCatalogStats(ctx context.Context) ([]models.CatalogStats, error)
+}
+
+// catalogCollector считает песни при каждом сборе метрик: значения всегда актуальны,
+// а запросы выполняются не чаще, чем Prometheus опрашивает сервис
+type catalogCollector struct {
+	source        CatalogStatsSource
+	timeout       time.Duration
+	songs         *prometheus.Desc
+	missingLyrics *prometheus.Desc
+}
+
+// RegisterCatalogStats публикует число песен и песен без текста по арендаторам;
+// подсчёт при сборе метрик ограничен timeout
+func RegisterCatalogStats(source CatalogStatsSource, timeout time.Duration) {
+	Registry.MustRegister(&catalogCollector{
+		source:  source,
+		timeout: timeout,
+		songs: prometheus.NewDesc(prometheus.BuildFQName(namespace, "catalog", "songs"),
+			"Number of songs in the catalog.", []string{"tenant"}, nil),
+		missingLyrics: prometheus.NewDesc(prometheus.BuildFQName(namespace, "catalog", "songs_missing_lyrics"),
+			"Number of songs without lyrics.", []string{"tenant"}, nil),
+	})
+}
+
+// Describe implements prometheus.Collector
+func (c *catalogCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.songs
+	ch <- c.missingLyrics
+}
+
+// Collect implements prometheus.Collector
+func (c *catalogCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	stats, err := c.source.GetCatalogStats(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.songs, err)
+		return
+	}
+	for _, s := range stats {
+		ch <- prometheus.MustNewConstMetric(c.songs, prometheus.GaugeValue, float64(s.Songs), s.Tenant)
+		ch <- prometheus.MustNewConstMetric(c.missingLyrics, prometheus.GaugeValue, float64(s.SongsMissingLyrics), s.Tenant)
+	}
+}
@@ -0,0 +1,101 @@
+// Package metrics exposes Prometheus metrics of the service
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "music_catalog"
+
+// Исходы обращений к внешнему API
+const (
+	OutcomeSuccess        = "success"
+	OutcomeHTTPError      = "http_error"      // API ответил статусом, отличным от 200
+	OutcomeTransportError = "transport_error" // соединение не установлено или прервано
+	OutcomeDecodeError    = "decode_error"    // ответ не разобран
+)
+
+// Registry — реестр метрик сервиса; отдельный от глобального, чтобы в /metrics
+// не попадали метрики, зарегистрированные сторонними библиотеками
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, chi route pattern and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, chi route pattern and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	queryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "repository_query_duration_seconds",
+		Help:      "Latency of song repository methods.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"method"})
+
+	externalAPIRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "external_api_requests_total",
+		Help:      "Song details requests to the external API by outcome.",
+	}, []string{"outcome"})
+
+	externalAPIDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "external_api_request_duration_seconds",
+		Help:      "Latency of song details requests to the external API by outcome.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"outcome"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests, httpDuration, queryDuration, externalAPIRequests, externalAPIDuration,
+	)
+}
+
+// Handler отдаёт метрики реестра в текстовом формате Prometheus
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// RegisterDBStats публикует статистику пула соединений (открытые, занятые, ожидания и т.д.)
+// с меткой db_name=name
+func RegisterDBStats(db *sql.DB, name string) {
+	Registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// ObserveHTTPRequest учитывает обработанный HTTP-запрос
+func ObserveHTTPRequest(method string, route string, status int, duration time.Duration) {
+	code := strconv.Itoa(status)
+	httpRequests.WithLabelValues(method, route, code).Inc()
+	httpDuration.WithLabelValues(method, route, code).Observe(duration.Seconds())
+}
+
+// ObserveQuery учитывает длительность метода репозитория, начатого в start:
+//
+//	defer metrics.ObserveQuery("GetSongs", time.Now())
+func ObserveQuery(method string, start time.Time) {
+	queryDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
+
+// ObserveExternalAPICall учитывает обращение к внешнему API с указанным исходом
+func ObserveExternalAPICall(outcome string, duration time.Duration) {
+	externalAPIRequests.WithLabelValues(outcome).Inc()
+	externalAPIDuration.WithLabelValues(outcome).Observe(duration.Seconds())
+}
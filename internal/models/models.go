@@ -1,5 +1,10 @@
 package models
 
+import (
+	"strings"
+	"time"
+)
+
 // Songs - структура для хранения данных о песне
 type Song struct {
 	ID          int    `json:"id"`
@@ -22,3 +27,132 @@ type Pagination struct {
 	Limit  int // максимальное количество записей на странице
 	Offset int // смещение (номер записи, с которой начинать выборку)
 }
+
+// SongPatch - структура для частичного обновления песни; nil означает, что поле не изменяется
+type SongPatch struct {
+	Group       *string
+	Title       *string
+	Text        *string
+	Link        *string
+	ReleaseDate *string
+}
+
+// SplitVerses разбивает текст песни на куплеты, разделённые пустой строкой
+func SplitVerses(text string) []string {
+	return strings.Split(text, "\n\n")
+}
+
+// Webhook - подписка внешней системы на события каталога
+type Webhook struct {
+	ID         int       `json:"id"`
+	TargetURL  string    `json:"target_url"`
+	EventTypes []string  `json:"event_types"` // пустой список — все события
+	Secret     string    `json:"secret,omitempty"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// Статусы доставки вебхука
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+// WebhookDelivery - доставка одного события одному подписчику
+type WebhookDelivery struct {
+	ID            int64                    `json:"id"`
+	WebhookID     int                      `json:"webhook_id"`
+	EventID       int64                    `json:"event_id"`
+	EventType     string                   `json:"event_type"`
+	Status        string                   `json:"status"`
+	Attempts      int                      `json:"attempts"`
+	NextAttemptAt *time.Time               `json:"next_attempt_at,omitempty"`
+	DeliveredAt   *time.Time               `json:"delivered_at,omitempty"`
+	CreatedAt     time.Time                `json:"created_at"`
+	AttemptLog    []WebhookDeliveryAttempt `json:"attempt_log"`
+}
+
+// WebhookDeliveryAttempt - результат одной попытки доставки
+type WebhookDeliveryAttempt struct {
+	Attempt     int       `json:"attempt"`
+	StatusCode  int       `json:"status_code,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	DurationMs  int64     `json:"duration_ms"`
+	AttemptedAt time.Time `json:"attempted_at"`
+}
+
+// APIKey - ключ доступа к API; в базе хранится только хэш ключа
+type APIKey struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Role       string     `json:"role"`
+	Prefix     string     `json:"prefix"`           // начало ключа, чтобы отличать ключи в списке
+	Tenant     string     `json:"tenant,omitempty"` // арендатор ключа; пусто — ключ уровня платформы
+	TenantID   int        `json:"-"`
+	Key        string     `json:"key,omitempty"` // сам ключ; возвращается только при создании
+	KeyHash    string     `json:"-"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// User - пользователь каталога, связанный с аутентифицированным клиентом
+type User struct {
+	ID          int       `json:"id"`
+	Subject     string    `json:"subject"`
+	DisplayName string    `json:"display_name"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Favorite - песня в избранном пользователя
+type Favorite struct {
+	Song    Song      `json:"song"`
+	AddedAt time.Time `json:"added_at"`
+}
+
+// Playlist - плейлист пользователя; Items заполняется при чтении одного плейлиста
+type Playlist struct {
+	ID          int            `json:"id"`
+	OwnerID     int            `json:"owner_id"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Public      bool           `json:"public"`
+	ShareToken  string         `json:"share_token,omitempty"` // только для владельца публичного плейлиста
+	ItemCount   int            `json:"item_count"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	Items       []PlaylistItem `json:"items,omitempty"`
+	TenantID    int            `json:"-"` // арендатор владельца
+}
+
+// PlaylistPatch - изменяемые поля плейлиста; nil означает "не менять"
+type PlaylistPatch struct {
+	Name        *string
+	Description *string
+	Public      *bool
+}
+
+// PlaylistItem - трек плейлиста; Position начинается с 1
+type PlaylistItem struct {
+	ID       int64     `json:"id"`
+	Position int       `json:"position"`
+	Song     Song      `json:"song"`
+	AddedAt  time.Time `json:"added_at"`
+}
+
+// Tenant - арендатор (лейбл) со своим каталогом
+type Tenant struct {
+	ID        int       `json:"id"`
+	Slug      string    `json:"slug"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CatalogStats - размер каталога арендатора
+type CatalogStats struct {
+	Tenant             string `json:"tenant"` // slug арендатора
+	Songs              int    `json:"songs"`
+	SongsMissingLyrics int    `json:"songs_missing_lyrics"`
+}
@@ -0,0 +1,60 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval — как часто MemoryStore удаляет корзины, которые успели наполниться
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	period  time.Duration
+}
+
+// MemoryStore хранит корзины в памяти процесса; квоты действуют для каждого экземпляра сервиса отдельно
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryStore creates a new in-memory bucket store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]*bucket{}, lastSweep: time.Now(), now: time.Now}
+}
+
+// Take implements Store
+func (s *MemoryStore) Take(ctx context.Context, key string, quota Quota) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		s.sweep(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(quota.Limit), updated: now}
+		s.buckets[key] = b
+	}
+	tokens, allowed := refill(b.tokens, now.Sub(b.updated), quota)
+	b.tokens, b.updated, b.period = tokens, now, quota.Period
+	return NewResult(allowed, tokens, quota), nil
+}
+
+// sweep удаляет корзины, не тронутые дольше периода квоты: они уже полны,
+// и новая корзина ничем от них не отличается
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, b := range s.buckets {
+		if now.Sub(b.updated) >= b.period {
+			delete(s.buckets, key)
+		}
+	}
+	s.lastSweep = now
+}
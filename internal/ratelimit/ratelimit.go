@@ -0,0 +1,194 @@
+// Package ratelimit enforces per-client token-bucket quotas for classes of API routes
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"music_catalog/internal/auth"
+	catalog_errors "music_catalog/internal/errors"
+	"music_catalog/internal/tenant"
+)
+
+// Class — класс маршрутов с общей квотой
+type Class string
+
+const (
+	ClassRead   Class = "read"   // чтение каталога
+	ClassWrite  Class = "write"  // изменение каталога и настроек
+	ClassEnrich Class = "enrich" // добавление песен: каждый запрос обращается к внешнему API
+	ClassAuth   Class = "auth"   // запросы с учётными данными с одного IP-адреса, до их проверки
+)
+
+// Quota — не более Limit запросов за Period. Корзина вмещает Limit токенов и пополняется
+// равномерно, поэтому клиент может израсходовать квоту всплеском, а затем ждёт пополнения.
+type Quota struct {
+	Limit  int
+	Period time.Duration
+}
+
+// Unlimited сообщает, что квота не ограничивает запросы
+func (q Quota) Unlimited() bool {
+	return q.Limit <= 0 || q.Period <= 0
+}
+
+// rate — скорость пополнения корзины, токенов в секунду
+func (q Quota) rate() float64 {
+	return float64(q.Limit) / q.Period.Seconds()
+}
+
+// Policy возвращает квоту в формате заголовка RateLimit-Policy: "60;w=60"
+func (q Quota) Policy() string {
+	return fmt.Sprintf("%d;w=%d", q.Limit, int(math.Ceil(q.Period.Seconds())))
+}
+
+// String возвращает квоту в формате ParseQuota
+func (q Quota) String() string {
+	if q.Unlimited() {
+		return "0"
+	}
+	return fmt.Sprintf("%d/%s", q.Limit, q.Period)
+}
+
+// ParseQuota разбирает квоту вида "60/1m" или "10/s"; пустая строка и "0" означают отсутствие ограничения
+func ParseQuota(s string) (Quota, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "0" {
+		return Quota{}, nil
+	}
+	limitStr, periodStr, ok := strings.Cut(s, "/")
+	if !ok {
+		return Quota{}, fmt.Errorf("invalid quota %q: expected <requests>/<period>, e.g. 60/1m", s)
+	}
+	limit, err := strconv.Atoi(strings.TrimSpace(limitStr))
+	if err != nil || limit < 0 {
+		return Quota{}, fmt.Errorf("invalid quota %q: request count must be a non-negative integer", s)
+	}
+	periodStr = strings.TrimSpace(periodStr)
+	// "10/s", "60/m" — без числа перед единицей измерения
+	if periodStr != "" && (periodStr[0] < '0' || periodStr[0] > '9') {
+		periodStr = "1" + periodStr
+	}
+	period, err := time.ParseDuration(periodStr)
+	if err != nil || period <= 0 {
+		return Quota{}, fmt.Errorf("invalid quota %q: period must be a positive duration", s)
+	}
+	return Quota{Limit: limit, Period: period}, nil
+}
+
+// Result — решение по запросу и состояние корзины после него
+type Result struct {
+	Allowed    bool
+	Remaining  int           // целых токенов осталось в корзине
+	Reset      time.Duration // через сколько корзина наполнится полностью
+	RetryAfter time.Duration // через сколько появится токен (для отклонённого запроса)
+}
+
+// Store — хранилище корзин. Take атомарно пополняет корзину key за прошедшее время
+// и, если в ней есть токен, забирает его.
+type Store interface {
+	Take(ctx context.Context, key string, quota Quota) (Result, error)
+}
+
+// NewResult вычисляет Result по числу токенов в корзине после запроса; используется реализациями Store
+func NewResult(allowed bool, tokens float64, quota Quota) Result {
+	rate := quota.rate()
+	result := Result{
+		Allowed:   allowed,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     time.Duration((float64(quota.Limit) - tokens) / rate * float64(time.Second)),
+	}
+	if !allowed {
+		result.RetryAfter = time.Duration((1 - tokens) / rate * float64(time.Second))
+	}
+	return result
+}
+
+// refill пополняет корзину за elapsed и забирает токен, если он есть
+func refill(tokens float64, elapsed time.Duration, quota Quota) (float64, bool) {
+	tokens = math.Min(float64(quota.Limit), tokens+elapsed.Seconds()*quota.rate())
+	if tokens >= 1 {
+		return tokens - 1, true
+	}
+	return tokens, false
+}
+
+// Quotas — квоты по классам маршрутов; класс без квоты не ограничивается
+type Quotas map[Class]Quota
+
+// Limiter применяет квоты классов маршрутов к клиентам
+type Limiter struct {
+	store  Store
+	quotas atomic.Pointer[Quotas]
+}
+
+// NewLimiter creates a new Limiter with the given store and quotas
+func NewLimiter(store Store, quotas Quotas) *Limiter {
+	l := &Limiter{store: store}
+	l.SetQuotas(quotas)
+	return l
+}
+
+// SetQuotas заменяет квоты; уже накопленные корзины клиентов сохраняются
+func (l *Limiter) SetQuotas(quotas Quotas) {
+	l.quotas.Store(&quotas)
+}
+
+// Quota возвращает квоту класса
+func (l *Limiter) Quota(class Class) Quota {
+	return (*l.quotas.Load())[class]
+}
+
+// Allow расходует токен клиента client в классе class. Для класса без квоты возвращает
+// разрешение и нулевую квоту.
+func (l *Limiter) Allow(ctx context.Context, class Class, client string) (Result, Quota, error) {
+	quota := l.Quota(class)
+	if quota.Unlimited() {
+		return Result{Allowed: true}, quota, nil
+	}
+	result, err := l.store.Take(ctx, string(class)+":"+client, quota)
+	return result, quota, err
+}
+
+// Check расходует токен клиента client в классе class для транспортов без заголовков RateLimit-*
+// (GraphQL, gRPC): исчерпанная квота возвращается ошибкой, обёртывающей catalog_errors.ErrRateLimited.
+// Сбой хранилища корзин возвращается как есть — вызывающий, как и HTTP-middleware, пропускает запрос.
+func (l *Limiter) Check(ctx context.Context, class Class, client string) error {
+	result, quota, err := l.Allow(ctx, class, client)
+	if err != nil {
+		return err
+	}
+	if !result.Allowed {
+		return fmt.Errorf("%w: quota of %d %s requests per %s exceeded, retry in %ds",
+			catalog_errors.ErrRateLimited, quota.Limit, class, quota.Period, int(math.Ceil(result.RetryAfter.Seconds())))
+	}
+	return nil
+}
+
+// Client возвращает ключ клиента для квот: аутентифицированный principal в пределах арендатора
+// или, для анонимного запроса, адрес ip
+func Client(ctx context.Context, ip string) string {
+	if principal, ok := auth.FromContext(ctx); ok {
+		return "principal:" + strconv.Itoa(tenant.IDFromContext(ctx)) + ":" + principal.ID
+	}
+	return "ip:" + ip
+}
+
+type clientKey struct{}
+
+// WithClient сохраняет в контексте ключ клиента, определённый HTTP-middleware, чтобы обработчики
+// могли расходовать квоты других классов (например, enrich для мутации addSong в GraphQL)
+func WithClient(ctx context.Context, client string) context.Context {
+	return context.WithValue(ctx, clientKey{}, client)
+}
+
+// ClientFromContext возвращает ключ клиента, сохранённый WithClient
+func ClientFromContext(ctx context.Context) (string, bool) {
+	client, ok := ctx.Value(clientKey{}).(string)
+	return client, ok
+}
@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRefill(t *testing.T) {
+	quota := Quota{Limit: 60, Period: time.Minute} // токен в секунду
+	tests := []struct {
+		name        string
+		tokens      float64
+		elapsed     time.Duration
+		wantTokens  float64
+		wantAllowed bool
+	}{
+		{"full bucket", 60, 0, 59, true},
+		{"last token", 1, 0, 0, true},
+		{"empty bucket", 0, 0, 0, false},
+		{"partial refill is not enough", 0, 500 * time.Millisecond, 0.5, false},
+		{"refilled one token", 0, time.Second, 0, true},
+		{"refill capped at limit", 30, time.Hour, 59, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, allowed := refill(tt.tokens, tt.elapsed, quota)
+			if math.Abs(tokens-tt.wantTokens) > 1e-9 || allowed != tt.wantAllowed {
+				t.Errorf("refill(%v, %v) = %v, %v, want %v, %v", tt.tokens, tt.elapsed, tokens, allowed, tt.wantTokens, tt.wantAllowed)
+			}
+		})
+	}
+}
+
+func TestNewResult(t *testing.T) {
+	quota := Quota{Limit: 10, Period: 10 * time.Second} // токен в секунду
+	tests := []struct {
+		name    string
+		allowed bool
+		tokens  float64
+		want    Result
+	}{
+		{"bucket full after request", true, 10, Result{Allowed: true, Remaining: 10}},
+		{"fractional tokens rounded down", true, 4.5, Result{Allowed: true, Remaining: 4, Reset: 5500 * time.Millisecond}},
+		{"bucket emptied", true, 0, Result{Allowed: true, Remaining: 0, Reset: 10 * time.Second}},
+		{"rejected", false, 0.25, Result{Allowed: false, Remaining: 0, Reset: 9750 * time.Millisecond, RetryAfter: 750 * time.Millisecond}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewResult(tt.allowed, tt.tokens, quota); got != tt.want {
+				t.Errorf("NewResult(%v, %v) = %+v, want %+v", tt.allowed, tt.tokens, got, tt.want)
+			}
+		})
+	}
+}
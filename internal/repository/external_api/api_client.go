@@ -1,12 +1,23 @@
 package external_api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"music_catalog/config"
+	"music_catalog/internal/logger"
+	"music_catalog/internal/metrics"
+	"music_catalog/internal/tracing"
 	"net/http"
 	"net/url"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type SongDetail struct {
@@ -16,43 +27,111 @@ type SongDetail struct {
 }
 
 type APIClient interface {
-	FetchSongDetails(group, song string) (*SongDetail, error)
+	FetchSongDetails(ctx context.Context, group, song string) (*SongDetail, error)
 }
 
 type ExternalAPIClient struct {
-	BaseURL string
+	endpoint atomic.Pointer[endpoint]
+	logger   logger.Logger
 }
 
-func NewExternalAPIClient(cfg *config.Config) *ExternalAPIClient {
-	return &ExternalAPIClient{
-		BaseURL: cfg.ExternalAPIURL, // Используем базовый URL из конфигурации
-	}
+// endpoint — адрес внешнего API и клиент с его таймаутом; заменяется целиком при перезагрузке конфигурации
+type endpoint struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func NewExternalAPIClient(cfg *config.Config, logger logger.Logger) *ExternalAPIClient {
+	client := &ExternalAPIClient{logger: logger}
+	client.SetEndpoint(cfg.ExternalAPIURL, cfg.ExternalAPITimeout) // Используем базовый URL из конфигурации
+	return client
+}
+
+// SetEndpoint меняет адрес внешнего API и время ожидания ответа; запросы, начатые раньше,
+// завершаются с прежними настройками
+func (client *ExternalAPIClient) SetEndpoint(baseURL string, timeout time.Duration) {
+	client.endpoint.Store(&endpoint{baseURL: baseURL, httpClient: &http.Client{Timeout: timeout}})
 }
 
-func (client *ExternalAPIClient) FetchSongDetails(group, song string) (*SongDetail, error) {
+// FetchSongDetails запрашивает детали песни; контекст трассировки передаётся внешнему API
+// в заголовке traceparent (W3C Trace Context), идентификатор входящего запроса — в X-Request-ID
+func (client *ExternalAPIClient) FetchSongDetails(ctx context.Context, group, song string) (*SongDetail, error) {
+	endpoint := client.endpoint.Load()
 	encodedGroup := url.QueryEscape(group)
 	encodedSong := url.QueryEscape(song)
-	url := fmt.Sprintf("%s/info?group=%s&song=%s", client.BaseURL, encodedGroup, encodedSong)
-	log.Printf("[INFO] Выполняем запрос к внешнему API: %s", url)
+	url := fmt.Sprintf("%s/info?group=%s&song=%s", endpoint.baseURL, encodedGroup, encodedSong)
+	log := client.logger.WithContext(ctx)
+	log.Info("Выполняем запрос к внешнему API:", url)
+
+	ctx, span := tracing.Start(ctx, "ExternalAPI.FetchSongDetails",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("http.request.method", http.MethodGet), attribute.String("url.full", url)))
+	defer span.End()
+
+	// Исход запроса уточняется по мере выполнения и учитывается в метриках при выходе
+	start := time.Now()
+	outcome := metrics.OutcomeTransportError
+	defer func() {
+		metrics.ObserveExternalAPICall(outcome, time.Since(start))
+		span.SetAttributes(attribute.String("outcome", outcome))
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		tracing.Fail(span, err)
+		return nil, err
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	if requestID := middleware.GetReqID(ctx); requestID != "" {
+		req.Header.Set(middleware.RequestIDHeader, requestID)
+	}
 
-	resp, err := http.Get(url)
+	resp, err := endpoint.httpClient.Do(req)
 	if err != nil {
-		log.Printf("[ERROR] Ошибка при выполнении запроса к внешнему API: %v", err)
+		tracing.Fail(span, err)
+		log.Error("Ошибка при выполнении запроса к внешнему API:", err)
 		return nil, err
 	}
 	defer resp.Body.Close()
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
 
 	if resp.StatusCode != http.StatusOK {
-		log.Printf("[ERROR] Внешний API вернул статус: %d", resp.StatusCode)
-		return nil, fmt.Errorf("внешний API вернул ошибку: %d", resp.StatusCode)
+		outcome = metrics.OutcomeHTTPError
+		err := fmt.Errorf("внешний API вернул ошибку: %d", resp.StatusCode)
+		tracing.Fail(span, err)
+		log.Error("Внешний API вернул статус:", resp.StatusCode)
+		return nil, err
 	}
 
 	var songDetail SongDetail
 	err = json.NewDecoder(resp.Body).Decode(&songDetail)
 	if err != nil {
-		log.Printf("[ERROR] Ошибка при декодировании ответа внешнего API: %v", err)
+		outcome = metrics.OutcomeDecodeError
+		tracing.Fail(span, err)
+		log.Error("Ошибка при декодировании ответа внешнего API:", err)
 		return nil, err
 	}
 
+	outcome = metrics.OutcomeSuccess
 	return &songDetail, nil
 }
+
+// CheckReachable проверяет, что внешний API принимает соединения: любой ответ, кроме 5xx,
+// считается признаком доступности (корневой путь API может и не обслуживать)
+func (client *ExternalAPIClient) CheckReachable(ctx context.Context) error {
+	endpoint := client.endpoint.Load()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.baseURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := endpoint.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("внешний API вернул ошибку: %d", resp.StatusCode)
+	}
+	return nil
+}
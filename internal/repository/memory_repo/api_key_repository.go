@@ -0,0 +1,93 @@
+package memory_repo
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	catalog_errors "music_catalog/internal/errors"
+	"music_catalog/internal/models"
+	"music_catalog/internal/repository/pg_repo"
+)
+
+// MemoryAPIKeyRepository — API-ключи в памяти процесса (хранится только хэш ключа);
+// ключи теряются при перезапуске, ADMIN_API_KEY и JWT продолжают действовать.
+type MemoryAPIKeyRepository struct {
+	tenants *MemoryTenantRepository
+
+	mu   sync.RWMutex
+	keys []models.APIKey // по возрастанию ID
+}
+
+// NewMemoryAPIKeyRepository — конструктор для MemoryAPIKeyRepository; арендаторы нужны для slug в ключах.
+func NewMemoryAPIKeyRepository(tenants *MemoryTenantRepository) *MemoryAPIKeyRepository {
+	return &MemoryAPIKeyRepository{tenants: tenants}
+}
+
+// CreateAPIKey — добавление ключа; TenantID = 0 — ключ уровня платформы.
+func (r *MemoryAPIKeyRepository) CreateAPIKey(ctx context.Context, key models.APIKey) (models.APIKey, error) {
+	key.Key = ""
+	key.Tenant = r.tenants.slug(key.TenantID)
+	key.CreatedAt = time.Now().UTC()
+	key.LastUsedAt, key.RevokedAt = nil, nil
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key.ID = len(r.keys) + 1
+	r.keys = append(r.keys, key)
+	return key, nil
+}
+
+// GetAPIKeys — получение списка ключей арендатора, включая отозванные; tenantID = 0 — ключи всех арендаторов.
+func (r *MemoryAPIKeyRepository) GetAPIKeys(ctx context.Context, tenantID int, pagination models.Pagination) ([]models.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var keys []models.APIKey
+	for _, key := range r.keys {
+		if tenantID == 0 || key.TenantID == tenantID {
+			keys = append(keys, key)
+		}
+	}
+	return paginate(keys, pagination), nil
+}
+
+// GetAPIKeyByHash — получение действующего ключа по хэшу.
+func (r *MemoryAPIKeyRepository) GetAPIKeyByHash(ctx context.Context, hash string) (models.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, key := range r.keys {
+		if key.KeyHash == hash && key.RevokedAt == nil {
+			return key, nil
+		}
+	}
+	return models.APIKey{}, catalog_errors.ErrAPIKeyNotFound
+}
+
+// TouchAPIKey — отметка о последнем использовании ключа.
+func (r *MemoryAPIKeyRepository) TouchAPIKey(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id >= 1 && id <= len(r.keys) {
+		now := time.Now().UTC()
+		r.keys[id-1].LastUsedAt = &now
+	}
+	return nil
+}
+
+// RevokeAPIKey — отзыв ключа арендатора (tenantID = 0 — любого ключа); запись остаётся в списке.
+func (r *MemoryAPIKeyRepository) RevokeAPIKey(ctx context.Context, tenantID int, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id < 1 || id > len(r.keys) {
+		return catalog_errors.ErrAPIKeyNotFound
+	}
+	key := &r.keys[id-1]
+	if key.RevokedAt != nil || (tenantID != 0 && key.TenantID != tenantID) {
+		return catalog_errors.ErrAPIKeyNotFound
+	}
+	now := time.Now().UTC()
+	key.RevokedAt = &now
+	return nil
+}
+
+var _ pg_repo.APIKeyRepository = (*MemoryAPIKeyRepository)(nil)
@@ -0,0 +1,92 @@
+package memory_repo
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"music_catalog/internal/events"
+	"music_catalog/internal/tenant"
+)
+
+// MemoryEventRepository — журнал событий изменения каталога в памяти процесса. Событие,
+// опубликованное в транзакции MemoryTransactor, попадает в журнал при её фиксации и пропадает
+// при откате вместе с изменением песни.
+type MemoryEventRepository struct {
+	mu      sync.RWMutex
+	events  []events.Event
+	nextID  int64
+	changed chan struct{}
+}
+
+// NewMemoryEventRepository — конструктор для MemoryEventRepository.
+func NewMemoryEventRepository() *MemoryEventRepository {
+	return &MemoryEventRepository{nextID: 1, changed: make(chan struct{}, 1)}
+}
+
+// Changes — уведомления о новых событиях для events.Broker.RunLocal.
+func (r *MemoryEventRepository) Changes() <-chan struct{} {
+	return r.changed
+}
+
+// Publish — запись события в журнал (при фиксации транзакции, если она открыта).
+// ID выдаются при записи, поэтому идут в порядке фиксации.
+func (r *MemoryEventRepository) Publish(ctx context.Context, event events.Event) error {
+	event.TenantID = tenant.IDFromContext(ctx)
+	if tx := currentTx(ctx); tx != nil {
+		tx.onCommit = append(tx.onCommit, func() { r.append(event) })
+		return nil
+	}
+	r.append(event)
+	return nil
+}
+
+// append записывает событие и будит брокер
+func (r *MemoryEventRepository) append(event events.Event) {
+	r.mu.Lock()
+	event.ID = r.nextID
+	event.OccurredAt = time.Now().UTC()
+	r.nextID++
+	r.events = append(r.events, event)
+	r.mu.Unlock()
+
+	select {
+	case r.changed <- struct{}{}:
+	default: // брокер ещё не забрал прошлое уведомление и прочитает журнал и так
+	}
+}
+
+// GetEventsAfter — получение событий арендатора с ID больше afterID.
+func (r *MemoryEventRepository) GetEventsAfter(ctx context.Context, tenantID int, afterID int64, limit int) ([]events.Event, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	i := sort.Search(len(r.events), func(i int) bool { return r.events[i].ID > afterID })
+	var batch []events.Event
+	for _, event := range r.events[i:] {
+		if len(batch) == limit {
+			break
+		}
+		if event.TenantID == tenantID {
+			batch = append(batch, event)
+		}
+	}
+	return batch, nil
+}
+
+// GetLastEventID — получение ID последнего события арендатора.
+func (r *MemoryEventRepository) GetLastEventID(ctx context.Context, tenantID int) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for i := len(r.events) - 1; i >= 0; i-- {
+		if r.events[i].TenantID == tenantID {
+			return r.events[i].ID, nil
+		}
+	}
+	return 0, nil
+}
+
+var (
+	_ events.Publisher = (*MemoryEventRepository)(nil)
+	_ events.Store     = (*MemoryEventRepository)(nil)
+)
@@ -0,0 +1,422 @@
+// Package memory_repo хранит каталог песен в памяти процесса: для демонстраций и тестов сервиса без PostgreSQL
+package memory_repo
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	catalog_errors "music_catalog/internal/errors"
+	"music_catalog/internal/metrics"
+	"music_catalog/internal/models"
+	"music_catalog/internal/repository/pg_repo"
+	"music_catalog/internal/tenant"
+	"music_catalog/internal/validation"
+)
+
+// storedSong — песня с арендатором и датой выпуска в виде даты, как в таблице songs
+type storedSong struct {
+	tenantID    int
+	song        models.Song
+	releaseDate time.Time
+}
+
+// MemorySongRepository — реализация pg_repo.SongRepository в памяти с тем же поведением, что
+// у PostgresMusicRepository: фильтры ILIKE, LIMIT/OFFSET, уникальность группы и названия
+// в пределах арендатора, разбиение текста на куплеты. Безопасна для одновременного использования.
+// В транзакции MemoryTransactor изменения отменяются при откате; вне транзакции сохраняются сразу.
+type MemorySongRepository struct {
+	mu      sync.RWMutex
+	songs   map[int]*storedSong
+	nextID  int
+	tenants map[int]string // slug арендаторов, с каталогами которых работали, для GetCatalogStats
+}
+
+// NewMemorySongRepository — конструктор для MemorySongRepository.
+func NewMemorySongRepository() *MemorySongRepository {
+	return &MemorySongRepository{
+		songs:   make(map[int]*storedSong),
+		nextID:  1,
+		tenants: map[int]string{tenant.DefaultID: tenant.DefaultSlug},
+	}
+}
+
+// GetSongs — получение списка песен с фильтрацией и пагинацией.
+func (r *MemorySongRepository) GetSongs(ctx context.Context, filters models.SongFilters, pagination models.Pagination) ([]models.Song, error) {
+	defer metrics.ObserveQuery("GetSongs", time.Now())
+	group := ilike("%" + filters.Group + "%")
+	title := ilike("%" + filters.Title + "%")
+	var releaseDate time.Time
+	if filters.ReleaseDate != "" {
+		date, err := parseDate(filters.ReleaseDate)
+		if err != nil {
+			return nil, err
+		}
+		releaseDate = date
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var songs []models.Song
+	for _, s := range r.tenantSongs(ctx) {
+		if !group.MatchString(s.song.Group) || !title.MatchString(s.song.Title) {
+			continue
+		}
+		if !releaseDate.IsZero() && !s.releaseDate.Equal(releaseDate) {
+			continue
+		}
+		songs = append(songs, s.song)
+	}
+	return paginate(songs, pagination), nil
+}
+
+// GetSongsByIDs — получение песен по списку ID.
+func (r *MemorySongRepository) GetSongsByIDs(ctx context.Context, ids []int) ([]models.Song, error) {
+	defer metrics.ObserveQuery("GetSongsByIDs", time.Now())
+	wanted := make(map[int]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var songs []models.Song
+	for _, s := range r.tenantSongs(ctx) {
+		if wanted[s.song.ID] {
+			songs = append(songs, s.song)
+		}
+	}
+	return songs, nil
+}
+
+// GetSongsByGroups — получение всех песен указанных групп, упорядоченных по группе и ID.
+func (r *MemorySongRepository) GetSongsByGroups(ctx context.Context, groups []string) ([]models.Song, error) {
+	defer metrics.ObserveQuery("GetSongsByGroups", time.Now())
+	wanted := make(map[string]bool, len(groups))
+	for _, group := range groups {
+		wanted[group] = true
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var songs []models.Song
+	for _, s := range r.tenantSongs(ctx) {
+		if wanted[s.song.Group] {
+			songs = append(songs, s.song)
+		}
+	}
+	sort.SliceStable(songs, func(i, j int) bool { return songs[i].Group < songs[j].Group })
+	return songs, nil
+}
+
+// GetGroups — получение списка групп с фильтрацией по названию и пагинацией.
+func (r *MemorySongRepository) GetGroups(ctx context.Context, name string, pagination models.Pagination) ([]string, error) {
+	defer metrics.ObserveQuery("GetGroups", time.Now())
+	pattern := ilike("%" + name + "%")
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	seen := make(map[string]bool)
+	var groups []string
+	for _, s := range r.tenantSongs(ctx) {
+		if !seen[s.song.Group] && pattern.MatchString(s.song.Group) {
+			seen[s.song.Group] = true
+			groups = append(groups, s.song.Group)
+		}
+	}
+	sort.Strings(groups)
+	return paginate(groups, pagination), nil
+}
+
+// SearchLyrics — поиск по текстам песен: песни, в тексте которых есть все слова запроса (целиком, без учёта
+// регистра), как plainto_tsquery в PostgreSQL и FTS5 в SQLite. Выше те, где слова запроса встречаются чаще.
+func (r *MemorySongRepository) SearchLyrics(ctx context.Context, text string, pagination models.Pagination) ([]models.Song, error) {
+	defer metrics.ObserveQuery("SearchLyrics", time.Now())
+	query := words(text)
+	if len(query) == 0 {
+		return nil, nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	type match struct {
+		song models.Song
+		hits int
+	}
+	var matches []match
+	for _, s := range r.tenantSongs(ctx) {
+		counts := make(map[string]int)
+		for _, word := range words(s.song.Text) {
+			counts[word]++
+		}
+		hits := 0
+		for _, word := range query {
+			if counts[word] == 0 {
+				hits = 0
+				break
+			}
+			hits += counts[word]
+		}
+		if hits > 0 {
+			matches = append(matches, match{song: s.song, hits: hits})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].hits > matches[j].hits })
+
+	songs := make([]models.Song, 0, len(matches))
+	for _, m := range matches {
+		songs = append(songs, m.song)
+	}
+	return paginate(songs, pagination), nil
+}
+
+// AddSong — добавление новой песни.
+func (r *MemorySongRepository) AddSong(ctx context.Context, song models.Song) (int, error) {
+	defer metrics.ObserveQuery("AddSong", time.Now())
+	releaseDate, err := parseDate(song.ReleaseDate)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при добавлении песни: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID := r.useTenant(ctx)
+	if r.find(tenantID, song.Group, song.Title) != nil {
+		return 0, catalog_errors.ErrSongExists
+	}
+	song.ID = r.nextID
+	song.ReleaseDate = formatDate(releaseDate)
+	r.nextID++
+	r.songs[song.ID] = &storedSong{tenantID: tenantID, song: song, releaseDate: releaseDate}
+	id := song.ID
+	onRollback(ctx, func() { r.restore(id, nil) })
+	return song.ID, nil
+}
+
+// GetSongByID — получение песни по ID; пустая песня, если её нет.
+func (r *MemorySongRepository) GetSongByID(ctx context.Context, id int) (models.Song, error) {
+	defer metrics.ObserveQuery("GetSongByID", time.Now())
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if s, ok := r.songs[id]; ok && s.tenantID == tenant.IDFromContext(ctx) {
+		return s.song, nil
+	}
+	return models.Song{}, nil
+}
+
+// LockSongByID — получение песни по ID для изменения в транзакции. MemoryTransactor выполняет
+// транзакции по одной, поэтому строку отдельно блокировать не нужно.
+func (r *MemorySongRepository) LockSongByID(ctx context.Context, id int) (models.Song, error) {
+	return r.GetSongByID(ctx, id)
+}
+
+// GetSong — получение песни по группе и названию; пустая песня, если её нет.
+func (r *MemorySongRepository) GetSong(ctx context.Context, group string, title string) (models.Song, error) {
+	defer metrics.ObserveQuery("GetSong", time.Now())
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if s := r.find(tenant.IDFromContext(ctx), group, title); s != nil {
+		return s.song, nil
+	}
+	return models.Song{}, nil
+}
+
+// UpdateSong — обновление данных песни; отсутствующая песня не считается ошибкой.
+func (r *MemorySongRepository) UpdateSong(ctx context.Context, song models.Song) error {
+	defer metrics.ObserveQuery("UpdateSong", time.Now())
+	releaseDate, err := parseDate(song.ReleaseDate)
+	if err != nil {
+		return fmt.Errorf("ошибка при обновлении песни: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tenantID := r.useTenant(ctx)
+	s, ok := r.songs[song.ID]
+	if !ok || s.tenantID != tenantID {
+		return nil
+	}
+	if other := r.find(tenantID, song.Group, song.Title); other != nil && other != s {
+		return catalog_errors.ErrSongExists
+	}
+	song.ReleaseDate = formatDate(releaseDate)
+	previous := *s
+	onRollback(ctx, func() { r.restore(song.ID, &previous) })
+	s.song = song
+	s.releaseDate = releaseDate
+	return nil
+}
+
+// DeleteSong — удаление песни по ID; отсутствующая песня не считается ошибкой.
+func (r *MemorySongRepository) DeleteSong(ctx context.Context, id int) error {
+	defer metrics.ObserveQuery("DeleteSong", time.Now())
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s, ok := r.songs[id]; ok && s.tenantID == tenant.IDFromContext(ctx) {
+		delete(r.songs, id)
+		previous := *s
+		onRollback(ctx, func() { r.restore(id, &previous) })
+	}
+	return nil
+}
+
+// GetSongText — текст песни целиком (page 0) или куплет с номером page.
+func (r *MemorySongRepository) GetSongText(ctx context.Context, songID int, page int) (string, error) {
+	defer metrics.ObserveQuery("GetSongText", time.Now())
+	r.mu.RLock()
+	s, ok := r.songs[songID]
+	ok = ok && s.tenantID == tenant.IDFromContext(ctx)
+	var fullText string
+	if ok {
+		fullText = s.song.Text
+	}
+	r.mu.RUnlock()
+	if !ok {
+		return "", catalog_errors.ErrSongNotFound
+	}
+
+	if page == 0 {
+		return fullText, nil
+	}
+	verses := models.SplitVerses(fullText)
+	if page < 1 || page > len(verses) {
+		return "", catalog_errors.ErrInvalidPage
+	}
+	return verses[page-1], nil
+}
+
+// GetCatalogStats — число песен и песен без текста в каталоге каждого арендатора, с которым работали.
+func (r *MemorySongRepository) GetCatalogStats(ctx context.Context) ([]models.CatalogStats, error) {
+	defer metrics.ObserveQuery("GetCatalogStats", time.Now())
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := make([]int, 0, len(r.tenants))
+	for id := range r.tenants {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	stats := make([]models.CatalogStats, len(ids))
+	index := make(map[int]int, len(ids))
+	for i, id := range ids {
+		stats[i].Tenant = r.tenants[id]
+		index[id] = i
+	}
+	for _, s := range r.songs {
+		i := index[s.tenantID]
+		stats[i].Songs++
+		if s.song.Text == "" {
+			stats[i].SongsMissingLyrics++
+		}
+	}
+	return stats, nil
+}
+
+// restore возвращает песню id в состояние song (nil — песни нет) при откате транзакции
+func (r *MemorySongRepository) restore(id int, song *storedSong) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if song == nil {
+		delete(r.songs, id)
+		return
+	}
+	r.songs[id] = song
+}
+
+// tenantSongs — песни арендатора из контекста по возрастанию ID; вызывается под блокировкой
+func (r *MemorySongRepository) tenantSongs(ctx context.Context) []*storedSong {
+	tenantID := tenant.IDFromContext(ctx)
+	var songs []*storedSong
+	for _, s := range r.songs {
+		if s.tenantID == tenantID {
+			songs = append(songs, s)
+		}
+	}
+	sort.Slice(songs, func(i, j int) bool { return songs[i].song.ID < songs[j].song.ID })
+	return songs
+}
+
+// find — песня арендатора по группе и названию; вызывается под блокировкой
+func (r *MemorySongRepository) find(tenantID int, group string, title string) *storedSong {
+	for _, s := range r.songs {
+		if s.tenantID == tenantID && s.song.Group == group && s.song.Title == title {
+			return s
+		}
+	}
+	return nil
+}
+
+// useTenant возвращает ID арендатора из контекста и запоминает его slug для статистики;
+// вызывается под блокировкой на запись
+func (r *MemorySongRepository) useTenant(ctx context.Context) int {
+	if t, ok := tenant.FromContext(ctx); ok {
+		r.tenants[t.ID] = t.Slug
+		return t.ID
+	}
+	return tenant.DefaultID
+}
+
+// paginate применяет LIMIT и OFFSET
+func paginate[T any](items []T, pagination models.Pagination) []T {
+	if pagination.Offset >= len(items) {
+		return nil
+	}
+	items = items[max(pagination.Offset, 0):]
+	if pagination.Limit < len(items) {
+		items = items[:max(pagination.Limit, 0)]
+	}
+	return items
+}
+
+// words разбивает текст на слова в нижнем регистре; разделитель — всё, кроме букв и цифр
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsNumber(c)
+	})
+}
+
+// ilike переводит шаблон ILIKE в регулярное выражение без учёта регистра:
+// % — любая строка, _ — любой символ, \ экранирует следующий символ
+func ilike(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString(`(?is)^`)
+	escaped := false
+	for _, c := range pattern {
+		switch {
+		case escaped:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == '%':
+			b.WriteString(".*")
+		case c == '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString(`$`)
+	return regexp.MustCompile(b.String())
+}
+
+// parseDate разбирает дату выпуска в любом формате, который принимает сервис
+func parseDate(value string) (time.Time, error) {
+	date, err := validation.ParseDate(value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC), nil
+}
+
+// formatDate форматирует дату выпуска так же, как её возвращает PostgreSQL (DATE через lib/pq)
+func formatDate(date time.Time) string {
+	return date.Format(time.RFC3339Nano)
+}
+
+var _ pg_repo.SongRepository = (*MemorySongRepository)(nil)
@@ -0,0 +1,15 @@
+package memory_repo
+
+import (
+	"testing"
+
+	"music_catalog/internal/models"
+	"music_catalog/internal/repository/pg_repo"
+	"music_catalog/internal/repository/repotest"
+)
+
+func TestMemorySongRepository(t *testing.T) {
+	repotest.SongRepository(t, func(t *testing.T) (pg_repo.SongRepository, models.Tenant) {
+		return NewMemorySongRepository(), models.Tenant{ID: 2, Slug: "acme"}
+	})
+}
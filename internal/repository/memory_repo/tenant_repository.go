@@ -0,0 +1,74 @@
+package memory_repo
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	catalog_errors "music_catalog/internal/errors"
+	"music_catalog/internal/models"
+	"music_catalog/internal/repository/pg_repo"
+	"music_catalog/internal/tenant"
+)
+
+// MemoryTenantRepository — справочник арендаторов в памяти процесса; арендатор по умолчанию
+// существует всегда, как после миграции 000006.
+type MemoryTenantRepository struct {
+	mu      sync.RWMutex
+	tenants []models.Tenant // по возрастанию ID
+}
+
+// NewMemoryTenantRepository — конструктор для MemoryTenantRepository.
+func NewMemoryTenantRepository() *MemoryTenantRepository {
+	return &MemoryTenantRepository{tenants: []models.Tenant{
+		{ID: tenant.DefaultID, Slug: tenant.DefaultSlug, Name: "Default", CreatedAt: time.Now().UTC()},
+	}}
+}
+
+// CreateTenant — добавление арендатора; занятый slug даёт ErrTenantExists.
+func (r *MemoryTenantRepository) CreateTenant(ctx context.Context, t models.Tenant) (models.Tenant, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.tenants {
+		if existing.Slug == t.Slug {
+			return models.Tenant{}, catalog_errors.ErrTenantExists
+		}
+	}
+	t.ID = r.tenants[len(r.tenants)-1].ID + 1
+	t.CreatedAt = time.Now().UTC()
+	r.tenants = append(r.tenants, t)
+	return t, nil
+}
+
+// GetTenants — получение списка арендаторов с пагинацией.
+func (r *MemoryTenantRepository) GetTenants(ctx context.Context, pagination models.Pagination) ([]models.Tenant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]models.Tenant(nil), paginate(r.tenants, pagination)...), nil
+}
+
+// GetTenantBySlug — получение арендатора по slug.
+func (r *MemoryTenantRepository) GetTenantBySlug(ctx context.Context, slug string) (models.Tenant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, t := range r.tenants {
+		if t.Slug == slug {
+			return t, nil
+		}
+	}
+	return models.Tenant{}, catalog_errors.ErrTenantNotFound
+}
+
+// slug — slug арендатора по ID; пустая строка, если его нет
+func (r *MemoryTenantRepository) slug(id int) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, t := range r.tenants {
+		if t.ID == id {
+			return t.Slug
+		}
+	}
+	return ""
+}
+
+var _ pg_repo.TenantRepository = (*MemoryTenantRepository)(nil)
@@ -0,0 +1,70 @@
+package memory_repo
+
+import (
+	"context"
+	"sync"
+)
+
+type txKey struct{}
+
+// memoryTx — изменения, сделанные в транзакции: undo отменяет их при откате,
+// onCommit выполняется при фиксации (так публикуются события транзакции)
+type memoryTx struct {
+	undo     []func()
+	onCommit []func()
+}
+
+// currentTx возвращает транзакцию из контекста или nil
+func currentTx(ctx context.Context) *memoryTx {
+	tx, _ := ctx.Value(txKey{}).(*memoryTx)
+	return tx
+}
+
+// onRollback запоминает отмену изменения, если оно сделано в транзакции; вне транзакции
+// изменение фиксируется сразу
+func onRollback(ctx context.Context, undo func()) {
+	if tx := currentTx(ctx); tx != nil {
+		tx.undo = append(tx.undo, undo)
+	}
+}
+
+// MemoryTransactor — транзакции над репозиториями memory_repo. Транзакции выполняются по одной:
+// при ошибке fn изменения отменяются в обратном порядке, а события публикуются только после
+// успешного завершения fn. Чтение вне транзакции видит ещё не зафиксированные изменения.
+type MemoryTransactor struct {
+	mu sync.Mutex
+}
+
+// NewMemoryTransactor — конструктор для MemoryTransactor.
+func NewMemoryTransactor() *MemoryTransactor {
+	return &MemoryTransactor{}
+}
+
+// WithinTransaction выполняет fn в транзакции; вложенный вызов переиспользует уже открытую.
+func (t *MemoryTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if currentTx(ctx) != nil {
+		return fn(ctx)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	tx := &memoryTx{}
+	committed := false
+	defer func() {
+		if !committed {
+			for i := len(tx.undo) - 1; i >= 0; i-- {
+				tx.undo[i]()
+			}
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+	committed = true
+	// Под блокировкой: события транзакций публикуются в порядке их фиксации
+	for _, commit := range tx.onCommit {
+		commit()
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package pg_repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	catalog_errors "music_catalog/internal/errors"
+	"music_catalog/internal/models"
+)
+
+// PostgresAPIKeyRepository — хранилище API-ключей.
+type PostgresAPIKeyRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresAPIKeyRepository — конструктор для PostgresAPIKeyRepository.
+func NewPostgresAPIKeyRepository(db *sql.DB) *PostgresAPIKeyRepository {
+	return &PostgresAPIKeyRepository{db: db}
+}
+
+// apiKeyColumns — колонки ключа в порядке scanAPIKey; запрос должен включать apiKeyFrom.
+const apiKeyColumns = `k.id, k.name, k.role, k.prefix, COALESCE(k.tenant_id, 0), COALESCE(t.slug, ''), k.created_at, k.last_used_at, k.revoked_at`
+
+// apiKeyFrom — ключи вместе с арендаторами; ключ без арендатора — ключ уровня платформы.
+const apiKeyFrom = ` FROM api_keys k LEFT JOIN tenants t ON t.id = k.tenant_id`
+
+// CreateAPIKey — добавление ключа (сохраняется только хэш); TenantID = 0 — ключ уровня платформы.
+func (r *PostgresAPIKeyRepository) CreateAPIKey(ctx context.Context, key models.APIKey) (models.APIKey, error) {
+	query := `INSERT INTO api_keys (name, role, prefix, key_hash, tenant_id) VALUES ($1, $2, $3, $4, NULLIF($5, 0)) RETURNING id`
+	var id int
+	if err := conn(ctx, r.db).QueryRowContext(ctx, query, key.Name, key.Role, key.Prefix, key.KeyHash, key.TenantID).Scan(&id); err != nil {
+		return models.APIKey{}, fmt.Errorf("ошибка при добавлении ключа: %w", err)
+	}
+	created, err := scanAPIKey(conn(ctx, r.db).QueryRowContext(ctx, `SELECT `+apiKeyColumns+apiKeyFrom+` WHERE k.id = $1`, id))
+	if err != nil {
+		return models.APIKey{}, fmt.Errorf("ошибка при получении ключа: %w", err)
+	}
+	return created, nil
+}
+
+// GetAPIKeys — получение списка ключей арендатора, включая отозванные; tenantID = 0 — ключи всех арендаторов.
+func (r *PostgresAPIKeyRepository) GetAPIKeys(ctx context.Context, tenantID int, pagination models.Pagination) ([]models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + apiKeyFrom + ` WHERE ($1 = 0 OR k.tenant_id = $1) ORDER BY k.id LIMIT $2 OFFSET $3`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, tenantID, pagination.Limit, pagination.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении ключей: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []models.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+// GetAPIKeyByHash — получение действующего ключа по хэшу.
+func (r *PostgresAPIKeyRepository) GetAPIKeyByHash(ctx context.Context, hash string) (models.APIKey, error) {
+	query := `SELECT ` + apiKeyColumns + apiKeyFrom + ` WHERE k.key_hash = $1 AND k.revoked_at IS NULL`
+	key, err := scanAPIKey(conn(ctx, r.db).QueryRowContext(ctx, query, hash))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.APIKey{}, catalog_errors.ErrAPIKeyNotFound
+		}
+		return models.APIKey{}, fmt.Errorf("ошибка при получении ключа: %w", err)
+	}
+	return key, nil
+}
+
+// TouchAPIKey — отметка о последнем использовании ключа.
+// Обновление не чаще раза в минуту, чтобы не писать в базу на каждый запрос.
+func (r *PostgresAPIKeyRepository) TouchAPIKey(ctx context.Context, id int) error {
+	query := `UPDATE api_keys SET last_used_at = NOW()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')`
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("ошибка при обновлении ключа: %w", err)
+	}
+	return nil
+}
+
+// RevokeAPIKey — отзыв ключа арендатора (tenantID = 0 — любого ключа); запись остаётся для аудита.
+func (r *PostgresAPIKeyRepository) RevokeAPIKey(ctx context.Context, tenantID int, id int) error {
+	query := `UPDATE api_keys SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL AND ($2 = 0 OR tenant_id = $2)`
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, id, tenantID)
+	if err != nil {
+		return fmt.Errorf("ошибка при отзыве ключа: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return catalog_errors.ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// scanAPIKey — чтение ключа с колонками apiKeyColumns.
+func scanAPIKey(row rowScanner) (models.APIKey, error) {
+	var key models.APIKey
+	var lastUsedAt, revokedAt sql.NullTime
+	if err := row.Scan(&key.ID, &key.Name, &key.Role, &key.Prefix, &key.TenantID, &key.Tenant, &key.CreatedAt, &lastUsedAt, &revokedAt); err != nil {
+		return models.APIKey{}, err
+	}
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	return key, nil
+}
@@ -0,0 +1,90 @@
+package pg_repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"music_catalog/internal/events"
+	"music_catalog/internal/models"
+	"music_catalog/internal/tenant"
+)
+
+// eventOrderLock — пространство ключей транзакционных advisory-блокировок, под которыми записываются
+// события; второй ключ — ID арендатора
+const eventOrderLock int32 = 0x736f6e67 // "song"
+
+// PostgresEventRepository — журнал событий изменения каталога в таблице song_events.
+// Вставка строки вызывает NOTIFY song_events (триггер из миграции 000002).
+type PostgresEventRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresEventRepository — конструктор для PostgresEventRepository.
+func NewPostgresEventRepository(db *sql.DB) *PostgresEventRepository {
+	return &PostgresEventRepository{db: db}
+}
+
+// Publish — запись события в журнал (в транзакции изменения, если она открыта).
+// Читатели потока арендатора (events.Broker, Last-Event-ID) идут по возрастанию ID, поэтому его события
+// должны становиться видимыми в том же порядке: транзакция, выдавшая ID раньше, но зафиксированная позже,
+// оказалась бы позади курсора и потерялась. Блокировка (eventOrderLock, арендатор) берётся перед выдачей ID
+// и держится до фиксации, так что в потоке арендатора ID выдаются в порядке фиксации транзакций, а изменения
+// разных арендаторов друг друга не ждут.
+func (r *PostgresEventRepository) Publish(ctx context.Context, event events.Event) error {
+	payload, err := json.Marshal(event.Song)
+	if err != nil {
+		return fmt.Errorf("ошибка при сериализации события: %w", err)
+	}
+	query := `INSERT INTO song_events (tenant_id, type, song_id, payload) VALUES ($1, $2, $3, $4)`
+	err = withinTransaction(ctx, r.db, func(ctx context.Context) error {
+		tenantID := tenant.IDFromContext(ctx)
+		if _, err := conn(ctx, r.db).ExecContext(ctx, `SELECT pg_advisory_xact_lock($1, $2)`, eventOrderLock, tenantID); err != nil {
+			return err
+		}
+		_, err := conn(ctx, r.db).ExecContext(ctx, query, tenantID, event.Type, event.SongID, payload)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("ошибка при записи события: %w", err)
+	}
+	return nil
+}
+
+// GetEventsAfter — получение событий арендатора с ID больше afterID (индекс idx_song_events_tenant).
+func (r *PostgresEventRepository) GetEventsAfter(ctx context.Context, tenantID int, afterID int64, limit int) ([]events.Event, error) {
+	query := `SELECT id, tenant_id, type, song_id, payload, created_at FROM song_events
+		WHERE tenant_id = $1 AND id > $2 ORDER BY id LIMIT $3`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, tenantID, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении событий: %w", err)
+	}
+	defer rows.Close()
+
+	var result []events.Event
+	for rows.Next() {
+		var event events.Event
+		var payload []byte
+		if err := rows.Scan(&event.ID, &event.TenantID, &event.Type, &event.SongID, &payload, &event.OccurredAt); err != nil {
+			return nil, err
+		}
+		var song models.Song
+		if err := json.Unmarshal(payload, &song); err != nil {
+			return nil, fmt.Errorf("ошибка при разборе события %d: %w", event.ID, err)
+		}
+		event.Song = song
+		result = append(result, event)
+	}
+	return result, rows.Err()
+}
+
+// GetLastEventID — получение ID последнего события арендатора.
+func (r *PostgresEventRepository) GetLastEventID(ctx context.Context, tenantID int) (int64, error) {
+	var id int64
+	err := conn(ctx, r.db).QueryRowContext(ctx, `SELECT COALESCE(MAX(id), 0) FROM song_events WHERE tenant_id = $1`, tenantID).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при получении последнего события: %w", err)
+	}
+	return id, nil
+}
@@ -0,0 +1,124 @@
+package pg_repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"music_catalog/internal/idempotency"
+)
+
+// idempotencySweepInterval — как часто удалять истёкшие ключи идемпотентности
+const idempotencySweepInterval = 10 * time.Minute
+
+// PostgresIdempotencyStore — ключи идемпотентности и сохранённые ответы в таблице idempotency_keys.
+type PostgresIdempotencyStore struct {
+	db *sql.DB
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+// NewPostgresIdempotencyStore — конструктор для PostgresIdempotencyStore.
+func NewPostgresIdempotencyStore(db *sql.DB) *PostgresIdempotencyStore {
+	return &PostgresIdempotencyStore{db: db}
+}
+
+// Begin — резервирование ключа. Занятый ключ перезаписывается, только если он истёк
+// или выполнявшийся запрос не сохранил ответ за idempotency.Lease.
+func (s *PostgresIdempotencyStore) Begin(ctx context.Context, scope idempotency.Scope, requestHash string, ttl time.Duration) (idempotency.Record, bool, error) {
+	s.sweep(ctx)
+
+	query := `INSERT INTO idempotency_keys AS k (tenant_id, principal, key, request_hash, expires_at)
+		VALUES ($1, $2, $3, $4, NOW() + $5 * INTERVAL '1 second')
+		ON CONFLICT (tenant_id, principal, key) DO UPDATE SET
+			request_hash = EXCLUDED.request_hash, status_code = NULL, response_header = NULL, response_body = NULL,
+			created_at = NOW(), expires_at = EXCLUDED.expires_at
+		WHERE k.expires_at < NOW() OR (k.status_code IS NULL AND k.created_at < NOW() - $6 * INTERVAL '1 second')
+		RETURNING request_hash`
+	var hash string
+	err := conn(ctx, s.db).QueryRowContext(ctx, query, scope.TenantID, scope.Principal, scope.Key, requestHash,
+		ttl.Seconds(), idempotency.Lease.Seconds()).Scan(&hash)
+	if err == nil {
+		return idempotency.Record{RequestHash: hash}, true, nil
+	}
+	if err != sql.ErrNoRows {
+		return idempotency.Record{}, false, fmt.Errorf("ошибка при резервировании ключа идемпотентности: %w", err)
+	}
+
+	// Ключ занят: читаем его состояние
+	var statusCode sql.NullInt64
+	var header, body []byte
+	query = `SELECT request_hash, status_code, response_header, response_body FROM idempotency_keys
+		WHERE tenant_id = $1 AND principal = $2 AND key = $3`
+	err = conn(ctx, s.db).QueryRowContext(ctx, query, scope.TenantID, scope.Principal, scope.Key).Scan(&hash, &statusCode, &header, &body)
+	if err == sql.ErrNoRows {
+		// Ключ освобождён между двумя запросами — для клиента это то же, что запрос в процессе выполнения
+		return idempotency.Record{RequestHash: requestHash}, false, nil
+	}
+	if err != nil {
+		return idempotency.Record{}, false, fmt.Errorf("ошибка при получении ключа идемпотентности: %w", err)
+	}
+
+	record := idempotency.Record{RequestHash: hash}
+	if statusCode.Valid {
+		response := &idempotency.Response{StatusCode: int(statusCode.Int64), Header: http.Header{}, Body: body}
+		if len(header) > 0 {
+			if err := json.Unmarshal(header, &response.Header); err != nil {
+				return idempotency.Record{}, false, fmt.Errorf("ошибка при разборе сохранённого ответа: %w", err)
+			}
+		}
+		record.Response = response
+	}
+	return record, false, nil
+}
+
+// Complete — сохранение ответа на запрос.
+func (s *PostgresIdempotencyStore) Complete(ctx context.Context, scope idempotency.Scope, response idempotency.Response) error {
+	header, err := json.Marshal(response.Header)
+	if err != nil {
+		return fmt.Errorf("ошибка при сериализации ответа: %w", err)
+	}
+	query := `UPDATE idempotency_keys SET status_code = $4, response_header = $5, response_body = $6
+		WHERE tenant_id = $1 AND principal = $2 AND key = $3`
+	_, err = conn(ctx, s.db).ExecContext(ctx, query, scope.TenantID, scope.Principal, scope.Key, response.StatusCode, header, response.Body)
+	if err != nil {
+		return fmt.Errorf("ошибка при сохранении ответа: %w", err)
+	}
+	return nil
+}
+
+// Release — освобождение ключа, ответ на который не сохраняется.
+func (s *PostgresIdempotencyStore) Release(ctx context.Context, scope idempotency.Scope) error {
+	query := `DELETE FROM idempotency_keys WHERE tenant_id = $1 AND principal = $2 AND key = $3 AND status_code IS NULL`
+	if _, err := conn(ctx, s.db).ExecContext(ctx, query, scope.TenantID, scope.Principal, scope.Key); err != nil {
+		return fmt.Errorf("ошибка при освобождении ключа идемпотентности: %w", err)
+	}
+	return nil
+}
+
+// sweep не чаще раза в idempotencySweepInterval удаляет истёкшие ключи
+func (s *PostgresIdempotencyStore) sweep(ctx context.Context) {
+	s.mu.Lock()
+	if time.Since(s.lastSweep) < idempotencySweepInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastSweep = time.Now()
+	s.mu.Unlock()
+
+	go s.PurgeExpired(context.WithoutCancel(ctx))
+}
+
+// PurgeExpired — удаление истёкших ключей; возвращает число удалённых.
+func (s *PostgresIdempotencyStore) PurgeExpired(ctx context.Context) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at < NOW()`)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при удалении истёкших ключей идемпотентности: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,356 @@
+package pg_repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	catalog_errors "music_catalog/internal/errors"
+	"music_catalog/internal/models"
+	"music_catalog/internal/tenant"
+
+	"github.com/lib/pq"
+)
+
+// PostgresLibraryRepository — пользователи, избранное и плейлисты.
+// Удаление песни каскадно удаляет её из избранного и плейлистов (внешние ключи из миграции 000005).
+type PostgresLibraryRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresLibraryRepository — конструктор для PostgresLibraryRepository.
+func NewPostgresLibraryRepository(db *sql.DB) *PostgresLibraryRepository {
+	return &PostgresLibraryRepository{db: db}
+}
+
+// EnsureUser — получение пользователя арендатора по subject с созданием при первом обращении.
+func (r *PostgresLibraryRepository) EnsureUser(ctx context.Context, subject string, displayName string) (models.User, error) {
+	// Существующий пользователь читается без записи, чтобы обычные запросы не обновляли строку
+	query := `WITH inserted AS (
+			INSERT INTO users (tenant_id, subject, display_name) VALUES ($3, $1, $2)
+			ON CONFLICT (tenant_id, subject) DO NOTHING
+			RETURNING id, subject, display_name, created_at
+		)
+		SELECT id, subject, display_name, created_at FROM inserted
+		UNION ALL
+		SELECT id, subject, display_name, created_at FROM users WHERE tenant_id = $3 AND subject = $1
+		LIMIT 1`
+	var user models.User
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, subject, displayName, tenant.IDFromContext(ctx)).Scan(&user.ID, &user.Subject, &user.DisplayName, &user.CreatedAt)
+	if err != nil {
+		return models.User{}, fmt.Errorf("ошибка при получении пользователя: %w", err)
+	}
+	return user, nil
+}
+
+// GetFavorites — получение избранных песен пользователя (последние добавленные первыми).
+func (r *PostgresLibraryRepository) GetFavorites(ctx context.Context, userID int, pagination models.Pagination) ([]models.Favorite, error) {
+	query := `SELECT s.id, s.group_name, s.title, s.release_date, s.text, s.link, f.created_at
+		FROM favorites f JOIN songs s ON s.id = f.song_id
+		WHERE f.user_id = $1 AND s.tenant_id = $2 ORDER BY f.created_at DESC, s.id DESC LIMIT $3 OFFSET $4`
+	var favorites []models.Favorite
+	err := inTenant(ctx, r.db, func(ctx context.Context, tenantID int) error {
+		rows, err := conn(ctx, r.db).QueryContext(ctx, query, userID, tenantID, pagination.Limit, pagination.Offset)
+		if err != nil {
+			return fmt.Errorf("ошибка при получении избранного: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var f models.Favorite
+			if err := rows.Scan(&f.Song.ID, &f.Song.Group, &f.Song.Title, &f.Song.ReleaseDate, &f.Song.Text, &f.Song.Link, &f.AddedAt); err != nil {
+				return err
+			}
+			favorites = append(favorites, f)
+		}
+		return rows.Err()
+	})
+	return favorites, err
+}
+
+// AddFavorite — добавление песни в избранное; повторное добавление ничего не меняет.
+func (r *PostgresLibraryRepository) AddFavorite(ctx context.Context, userID int, songID int) error {
+	return inTenant(ctx, r.db, func(ctx context.Context, tenantID int) error {
+		if err := r.checkSong(ctx, tenantID, songID); err != nil {
+			return err
+		}
+		query := `INSERT INTO favorites (user_id, song_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+		if _, err := conn(ctx, r.db).ExecContext(ctx, query, userID, songID); err != nil {
+			if isForeignKeyViolation(err) {
+				return catalog_errors.ErrSongNotFound
+			}
+			return fmt.Errorf("ошибка при добавлении в избранное: %w", err)
+		}
+		return nil
+	})
+}
+
+// checkSong — проверка, что песня принадлежит арендатору: внешний ключ на songs этого не проверяет.
+func (r *PostgresLibraryRepository) checkSong(ctx context.Context, tenantID int, songID int) error {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM songs WHERE id = $1 AND tenant_id = $2)`
+	if err := conn(ctx, r.db).QueryRowContext(ctx, query, songID, tenantID).Scan(&exists); err != nil {
+		return fmt.Errorf("ошибка при проверке песни: %w", err)
+	}
+	if !exists {
+		return catalog_errors.ErrSongNotFound
+	}
+	return nil
+}
+
+// RemoveFavorite — удаление песни из избранного; отсутствие записи не считается ошибкой.
+func (r *PostgresLibraryRepository) RemoveFavorite(ctx context.Context, userID int, songID int) error {
+	query := `DELETE FROM favorites WHERE user_id = $1 AND song_id = $2`
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, userID, songID); err != nil {
+		return fmt.Errorf("ошибка при удалении из избранного: %w", err)
+	}
+	return nil
+}
+
+// playlistColumns — колонки плейлиста в порядке scanPlaylist; запрос должен включать playlistFrom.
+const playlistColumns = `p.id, p.owner_id, p.name, p.description, p.public, COALESCE(p.share_token, ''), p.created_at, p.updated_at,
+	(SELECT COUNT(*) FROM playlist_items i WHERE i.playlist_id = p.id), u.tenant_id`
+
+// playlistFrom — плейлисты вместе с владельцами: арендатор плейлиста — арендатор его владельца.
+const playlistFrom = ` FROM playlists p JOIN users u ON u.id = p.owner_id`
+
+// tenantPlaylist — условие на плейлист арендатора для UPDATE/DELETE по playlists.
+const tenantPlaylist = `owner_id IN (SELECT id FROM users WHERE tenant_id = $2)`
+
+// CreatePlaylist — создание плейлиста.
+func (r *PostgresLibraryRepository) CreatePlaylist(ctx context.Context, playlist models.Playlist) (models.Playlist, error) {
+	query := `INSERT INTO playlists (owner_id, name, description, public, share_token) VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+		RETURNING id`
+	var id int
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, playlist.OwnerID, playlist.Name, playlist.Description, playlist.Public, playlist.ShareToken).Scan(&id)
+	if err != nil {
+		return models.Playlist{}, fmt.Errorf("ошибка при создании плейлиста: %w", err)
+	}
+	return r.GetPlaylist(ctx, id)
+}
+
+// GetPlaylists — получение плейлистов пользователя.
+func (r *PostgresLibraryRepository) GetPlaylists(ctx context.Context, ownerID int, pagination models.Pagination) ([]models.Playlist, error) {
+	query := `SELECT ` + playlistColumns + playlistFrom + ` WHERE p.owner_id = $1 AND u.tenant_id = $2 ORDER BY p.id LIMIT $3 OFFSET $4`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, ownerID, tenant.IDFromContext(ctx), pagination.Limit, pagination.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении плейлистов: %w", err)
+	}
+	defer rows.Close()
+
+	var playlists []models.Playlist
+	for rows.Next() {
+		playlist, err := scanPlaylist(rows)
+		if err != nil {
+			return nil, err
+		}
+		playlists = append(playlists, playlist)
+	}
+	return playlists, rows.Err()
+}
+
+// GetPlaylist — получение плейлиста по ID (без треков).
+func (r *PostgresLibraryRepository) GetPlaylist(ctx context.Context, id int) (models.Playlist, error) {
+	query := `SELECT ` + playlistColumns + playlistFrom + ` WHERE p.id = $1 AND u.tenant_id = $2`
+	return r.getPlaylist(ctx, query, id, tenant.IDFromContext(ctx))
+}
+
+// GetPlaylistByShareToken — получение опубликованного плейлиста по токену (без треков).
+// Токен уникален во всём развёртывании, поэтому поиск не ограничен арендатором;
+// TenantID результата — арендатор, в котором нужно читать треки.
+func (r *PostgresLibraryRepository) GetPlaylistByShareToken(ctx context.Context, token string) (models.Playlist, error) {
+	query := `SELECT ` + playlistColumns + playlistFrom + ` WHERE p.share_token = $1 AND p.public`
+	return r.getPlaylist(ctx, query, token)
+}
+
+func (r *PostgresLibraryRepository) getPlaylist(ctx context.Context, query string, args ...interface{}) (models.Playlist, error) {
+	playlist, err := scanPlaylist(conn(ctx, r.db).QueryRowContext(ctx, query, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Playlist{}, catalog_errors.ErrPlaylistNotFound
+		}
+		return models.Playlist{}, fmt.Errorf("ошибка при получении плейлиста: %w", err)
+	}
+	return playlist, nil
+}
+
+// GetPlaylistItems — получение треков плейлиста по порядку; позиции нумеруются с 1 без пропусков.
+func (r *PostgresLibraryRepository) GetPlaylistItems(ctx context.Context, playlistID int) ([]models.PlaylistItem, error) {
+	query := `SELECT i.id, ROW_NUMBER() OVER (ORDER BY i.position, i.id), i.added_at,
+			s.id, s.group_name, s.title, s.release_date, s.text, s.link
+		FROM playlist_items i JOIN songs s ON s.id = i.song_id
+		WHERE i.playlist_id = $1 AND s.tenant_id = $2 ORDER BY i.position, i.id`
+	items := []models.PlaylistItem{}
+	err := inTenant(ctx, r.db, func(ctx context.Context, tenantID int) error {
+		rows, err := conn(ctx, r.db).QueryContext(ctx, query, playlistID, tenantID)
+		if err != nil {
+			return fmt.Errorf("ошибка при получении треков плейлиста: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var item models.PlaylistItem
+			if err := rows.Scan(&item.ID, &item.Position, &item.AddedAt,
+				&item.Song.ID, &item.Song.Group, &item.Song.Title, &item.Song.ReleaseDate, &item.Song.Text, &item.Song.Link); err != nil {
+				return err
+			}
+			items = append(items, item)
+		}
+		return rows.Err()
+	})
+	return items, err
+}
+
+// UpdatePlaylist — обновление названия, описания и видимости; токен публикации выдаётся один раз.
+func (r *PostgresLibraryRepository) UpdatePlaylist(ctx context.Context, playlist models.Playlist) error {
+	query := `UPDATE playlists SET name = $1, description = $2, public = $3,
+			share_token = COALESCE(share_token, NULLIF($4, '')), updated_at = NOW()
+		WHERE id = $5 AND owner_id IN (SELECT id FROM users WHERE tenant_id = $6)`
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, playlist.Name, playlist.Description, playlist.Public, playlist.ShareToken, playlist.ID, tenant.IDFromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("ошибка при обновлении плейлиста: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return catalog_errors.ErrPlaylistNotFound
+	}
+	return nil
+}
+
+// DeletePlaylist — удаление плейлиста вместе с треками.
+func (r *PostgresLibraryRepository) DeletePlaylist(ctx context.Context, id int) error {
+	result, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM playlists WHERE id = $1 AND `+tenantPlaylist, id, tenant.IDFromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("ошибка при удалении плейлиста: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return catalog_errors.ErrPlaylistNotFound
+	}
+	return nil
+}
+
+// TouchPlaylist — отметка об изменении плейлиста. Внутри транзакции блокирует строку плейлиста,
+// так что параллельные изменения треков одного плейлиста выполняются по очереди.
+func (r *PostgresLibraryRepository) TouchPlaylist(ctx context.Context, id int) error {
+	result, err := conn(ctx, r.db).ExecContext(ctx, `UPDATE playlists SET updated_at = NOW() WHERE id = $1 AND `+tenantPlaylist, id, tenant.IDFromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("ошибка при обновлении плейлиста: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return catalog_errors.ErrPlaylistNotFound
+	}
+	return nil
+}
+
+// CompactPlaylistItems — перенумерация треков с 1 без пропусков (после каскадного удаления песен);
+// возвращает число треков.
+func (r *PostgresLibraryRepository) CompactPlaylistItems(ctx context.Context, playlistID int) (int, error) {
+	query := `UPDATE playlist_items i SET position = n.rn
+		FROM (SELECT id, ROW_NUMBER() OVER (ORDER BY position, id) AS rn FROM playlist_items WHERE playlist_id = $1) n
+		WHERE i.id = n.id AND i.position <> n.rn`
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, playlistID); err != nil {
+		return 0, fmt.Errorf("ошибка при перенумерации треков: %w", err)
+	}
+	var count int
+	err := conn(ctx, r.db).QueryRowContext(ctx, `SELECT COUNT(*) FROM playlist_items WHERE playlist_id = $1`, playlistID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при подсчёте треков: %w", err)
+	}
+	return count, nil
+}
+
+// InsertPlaylistItem — вставка трека на позицию position со сдвигом последующих; позиции должны быть уплотнены.
+func (r *PostgresLibraryRepository) InsertPlaylistItem(ctx context.Context, playlistID int, songID int, position int) (int64, error) {
+	if err := inTenant(ctx, r.db, func(ctx context.Context, tenantID int) error {
+		return r.checkSong(ctx, tenantID, songID)
+	}); err != nil {
+		return 0, err
+	}
+
+	shift := `UPDATE playlist_items SET position = position + 1 WHERE playlist_id = $1 AND position >= $2`
+	if _, err := conn(ctx, r.db).ExecContext(ctx, shift, playlistID, position); err != nil {
+		return 0, fmt.Errorf("ошибка при сдвиге треков: %w", err)
+	}
+
+	var id int64
+	query := `INSERT INTO playlist_items (playlist_id, song_id, position) VALUES ($1, $2, $3) RETURNING id`
+	if err := conn(ctx, r.db).QueryRowContext(ctx, query, playlistID, songID, position).Scan(&id); err != nil {
+		if isForeignKeyViolation(err) {
+			return 0, catalog_errors.ErrSongNotFound
+		}
+		return 0, fmt.Errorf("ошибка при добавлении трека: %w", err)
+	}
+	return id, nil
+}
+
+// MovePlaylistItem — перемещение трека на позицию position; позиции должны быть уплотнены.
+func (r *PostgresLibraryRepository) MovePlaylistItem(ctx context.Context, playlistID int, itemID int64, position int) error {
+	current, err := r.itemPosition(ctx, playlistID, itemID)
+	if err != nil {
+		return err
+	}
+
+	// Треки между старой и новой позицией сдвигаются на одну позицию навстречу перемещаемому
+	query := `UPDATE playlist_items SET position = CASE
+			WHEN id = $2 THEN $4
+			WHEN $4 < $3 THEN position + 1
+			ELSE position - 1 END
+		WHERE playlist_id = $1 AND position BETWEEN LEAST($3, $4) AND GREATEST($3, $4)`
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, playlistID, itemID, current, position); err != nil {
+		return fmt.Errorf("ошибка при перемещении трека: %w", err)
+	}
+	return nil
+}
+
+// DeletePlaylistItem — удаление трека со сдвигом последующих; позиции должны быть уплотнены.
+func (r *PostgresLibraryRepository) DeletePlaylistItem(ctx context.Context, playlistID int, itemID int64) error {
+	current, err := r.itemPosition(ctx, playlistID, itemID)
+	if err != nil {
+		return err
+	}
+	if _, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM playlist_items WHERE id = $1`, itemID); err != nil {
+		return fmt.Errorf("ошибка при удалении трека: %w", err)
+	}
+	shift := `UPDATE playlist_items SET position = position - 1 WHERE playlist_id = $1 AND position > $2`
+	if _, err := conn(ctx, r.db).ExecContext(ctx, shift, playlistID, current); err != nil {
+		return fmt.Errorf("ошибка при сдвиге треков: %w", err)
+	}
+	return nil
+}
+
+// ReorderPlaylistItems — задание нового порядка треков; itemIDs должен содержать все треки плейлиста.
+func (r *PostgresLibraryRepository) ReorderPlaylistItems(ctx context.Context, playlistID int, itemIDs []int64) error {
+	query := `UPDATE playlist_items i SET position = o.ord
+		FROM unnest($2::BIGINT[]) WITH ORDINALITY AS o(id, ord)
+		WHERE i.id = o.id AND i.playlist_id = $1`
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, playlistID, pq.Array(itemIDs)); err != nil {
+		return fmt.Errorf("ошибка при изменении порядка треков: %w", err)
+	}
+	return nil
+}
+
+// itemPosition — текущая позиция трека в плейлисте.
+func (r *PostgresLibraryRepository) itemPosition(ctx context.Context, playlistID int, itemID int64) (int, error) {
+	var position int
+	query := `SELECT position FROM playlist_items WHERE id = $1 AND playlist_id = $2`
+	if err := conn(ctx, r.db).QueryRowContext(ctx, query, itemID, playlistID).Scan(&position); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, catalog_errors.ErrPlaylistItemNotFound
+		}
+		return 0, fmt.Errorf("ошибка при получении трека: %w", err)
+	}
+	return position, nil
+}
+
+// scanPlaylist — чтение плейлиста с колонками playlistColumns.
+func scanPlaylist(row rowScanner) (models.Playlist, error) {
+	var p models.Playlist
+	err := row.Scan(&p.ID, &p.OwnerID, &p.Name, &p.Description, &p.Public, &p.ShareToken, &p.CreatedAt, &p.UpdatedAt, &p.ItemCount, &p.TenantID)
+	return p, err
+}
+
+// isForeignKeyViolation — нарушение внешнего ключа (ссылка на несуществующую запись).
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
@@ -4,14 +4,21 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strconv"
+	"time"
+
 	catalog_errors "music_catalog/internal/errors"
+	"music_catalog/internal/metrics"
 	"music_catalog/internal/models"
-	"strings"
+	"music_catalog/internal/tenant"
+
+	"github.com/lib/pq"
 )
 
 // PostgresMusicRepository — структура для работы с PostgreSQL.
 type PostgresMusicRepository struct {
-	db *sql.DB
+	db      *sql.DB
+	replica *sql.DB // реплика для чтения списка песен и текстов; nil — всё читается из db
 }
 
 // NewPostgresSongRepository — конструктор для PostgresSongRepository.
@@ -19,11 +26,28 @@ func NewPostgresSongRepository(db *sql.DB) *PostgresMusicRepository {
 	return &PostgresMusicRepository{db: db}
 }
 
+// WithReplica — чтение списка песен и текстов из реплики. Реплика может отставать: только что
+// добавленная песня появится в списке с задержкой репликации.
+func (r *PostgresMusicRepository) WithReplica(replica *sql.DB) *PostgresMusicRepository {
+	r.replica = replica
+	return r
+}
+
+// reader — пул для чтения: реплика, если она задана и запрос выполняется вне транзакции
+// (в транзакции читаем из основной базы, чтобы видеть свои изменения).
+func (r *PostgresMusicRepository) reader(ctx context.Context) *sql.DB {
+	if _, inTx := ctx.Value(txKey{}).(*sql.Tx); inTx || r.replica == nil {
+		return r.db
+	}
+	return r.replica
+}
+
 // GetSongs — получение списка песен с фильтрацией и пагинацией.
 func (r *PostgresMusicRepository) GetSongs(ctx context.Context, filters models.SongFilters, pagination models.Pagination) ([]models.Song, error) {
-	query := "SELECT id, group_name, title, release_date, text, link FROM songs WHERE 1=1" // базовый запрос
-	args := []interface{}{}
-	argCount := 1
+	defer metrics.ObserveQuery("GetSongs", time.Now())
+	query := "SELECT id, group_name, title, release_date, text, link FROM songs WHERE tenant_id = $1" // базовый запрос
+	args := []interface{}{tenant.IDFromContext(ctx)}
+	argCount := 2
 
 	// Фильтрация по группе
 	if filters.Group != "" {
@@ -46,18 +70,108 @@ func (r *PostgresMusicRepository) GetSongs(ctx context.Context, filters models.S
 		argCount++
 	}
 
-	// Пагинация добавляется в конец запроса
-	query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", argCount, argCount+1)
+	// Пагинация добавляется в конец запроса; без ORDER BY страницы могли бы пересекаться
+	query += fmt.Sprintf(" ORDER BY id LIMIT $%d OFFSET $%d", argCount, argCount+1)
 	args = append(args, pagination.Limit, pagination.Offset)
 
-	// Выполнение запроса
-	rows, err := r.db.QueryContext(ctx, query, args...)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
+	// Выполнение запроса и парсинг результатов
+	var songs []models.Song
+	db := r.reader(ctx)
+	err := inTenant(ctx, db, func(ctx context.Context, _ int) error {
+		rows, err := conn(ctx, db).QueryContext(ctx, query, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		songs, err = scanSongs(rows)
+		return err
+	})
+	return songs, err
+}
+
+// GetSongsByIDs — получение песен по списку ID одним запросом.
+func (r *PostgresMusicRepository) GetSongsByIDs(ctx context.Context, ids []int) ([]models.Song, error) {
+	defer metrics.ObserveQuery("GetSongsByIDs", time.Now())
+	query := `SELECT id, group_name, title, release_date, text, link FROM songs WHERE tenant_id = $1 AND id = ANY($2) ORDER BY id`
+	return r.querySongs(ctx, "ошибка при получении песен по ID", query, pq.Array(ids))
+}
+
+// GetSongsByGroups — получение всех песен указанных групп одним запросом.
+func (r *PostgresMusicRepository) GetSongsByGroups(ctx context.Context, groups []string) ([]models.Song, error) {
+	defer metrics.ObserveQuery("GetSongsByGroups", time.Now())
+	query := `SELECT id, group_name, title, release_date, text, link FROM songs WHERE tenant_id = $1 AND group_name = ANY($2) ORDER BY group_name, id`
+	return r.querySongs(ctx, "ошибка при получении песен по группам", query, pq.Array(groups))
+}
+
+// querySongs — выполнение запроса песен арендатора; первый параметр запроса ($1) — ID арендатора.
+func (r *PostgresMusicRepository) querySongs(ctx context.Context, errorMessage string, query string, args ...interface{}) ([]models.Song, error) {
+	var songs []models.Song
+	err := inTenant(ctx, r.db, func(ctx context.Context, tenantID int) error {
+		rows, err := conn(ctx, r.db).QueryContext(ctx, query, append([]interface{}{tenantID}, args...)...)
+		if err != nil {
+			return fmt.Errorf("%s: %w", errorMessage, err)
+		}
+		defer rows.Close()
+		songs, err = scanSongs(rows)
+		return err
+	})
+	return songs, err
+}
+
+// lyricsVector совпадает с выражением индекса idx_songs_text_search (миграция 000009): планировщик
+// использует индекс, только если выражение в запросе записано так же
+const lyricsVector = `to_tsvector('simple', COALESCE(text, ''))`
+
+// SearchLyrics — полнотекстовый поиск по текстам песен: песни, в тексте которых есть все слова запроса,
+// от наиболее релевантных. plainto_tsquery не разбирает операторы tsquery, поэтому запрос пользователя
+// не приводит к ошибке синтаксиса.
+func (r *PostgresMusicRepository) SearchLyrics(ctx context.Context, text string, pagination models.Pagination) ([]models.Song, error) {
+	defer metrics.ObserveQuery("SearchLyrics", time.Now())
+	query := `SELECT id, group_name, title, release_date, text, link
+		FROM songs, plainto_tsquery('simple', $2) AS q
+		WHERE tenant_id = $1 AND ` + lyricsVector + ` @@ q
+		ORDER BY ts_rank(` + lyricsVector + `, q) DESC, id LIMIT $3 OFFSET $4`
+
+	var songs []models.Song
+	db := r.reader(ctx)
+	err := inTenant(ctx, db, func(ctx context.Context, tenantID int) error {
+		rows, err := conn(ctx, db).QueryContext(ctx, query, tenantID, text, pagination.Limit, pagination.Offset)
+		if err != nil {
+			return fmt.Errorf("ошибка при поиске по текстам песен: %w", err)
+		}
+		defer rows.Close()
+		songs, err = scanSongs(rows)
+		return err
+	})
+	return songs, err
+}
+
+// GetGroups — получение списка групп с фильтрацией по названию и пагинацией.
+func (r *PostgresMusicRepository) GetGroups(ctx context.Context, name string, pagination models.Pagination) ([]string, error) {
+	defer metrics.ObserveQuery("GetGroups", time.Now())
+	query := `SELECT DISTINCT group_name FROM songs WHERE tenant_id = $1 AND group_name ILIKE $2 ORDER BY group_name LIMIT $3 OFFSET $4`
+	var groups []string
+	err := inTenant(ctx, r.db, func(ctx context.Context, tenantID int) error {
+		rows, err := conn(ctx, r.db).QueryContext(ctx, query, tenantID, "%"+name+"%", pagination.Limit, pagination.Offset)
+		if err != nil {
+			return fmt.Errorf("ошибка при получении списка групп: %w", err)
+		}
+		defer rows.Close()
 
-	// Парсинг результатов
+		for rows.Next() {
+			var group string
+			if err := rows.Scan(&group); err != nil {
+				return err
+			}
+			groups = append(groups, group)
+		}
+		return rows.Err()
+	})
+	return groups, err
+}
+
+// scanSongs — чтение песен из результата запроса с колонками id, group_name, title, release_date, text, link.
+func scanSongs(rows *sql.Rows) ([]models.Song, error) {
 	var songs []models.Song
 	for rows.Next() {
 		var song models.Song
@@ -66,14 +180,20 @@ func (r *PostgresMusicRepository) GetSongs(ctx context.Context, filters models.S
 		}
 		songs = append(songs, song)
 	}
-	return songs, nil
+	return songs, rows.Err()
 }
 
 // AddSong — добавление новой песни в базу данных.
 func (r *PostgresMusicRepository) AddSong(ctx context.Context, song models.Song) (int, error) {
+	defer metrics.ObserveQuery("AddSong", time.Now())
 	var id int
-	query := `INSERT INTO songs (group_name, title, text, link, release_date) VALUES ($1, $2, $3, $4, $5) RETURNING id`
-	err := r.db.QueryRowContext(ctx, query, song.Group, song.Title, song.Text, song.Link, song.ReleaseDate).Scan(&id)
+	query := `INSERT INTO songs (tenant_id, group_name, title, text, link, release_date) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+	err := inTenant(ctx, r.db, func(ctx context.Context, tenantID int) error {
+		return conn(ctx, r.db).QueryRowContext(ctx, query, tenantID, song.Group, song.Title, song.Text, song.Link, song.ReleaseDate).Scan(&id)
+	})
+	if isUniqueViolation(err) {
+		return 0, catalog_errors.ErrSongExists // песню с той же группой и названием успели добавить параллельно
+	}
 	if err != nil {
 		return 0, fmt.Errorf("ошибка при добавлении песни: %w", err)
 	}
@@ -82,9 +202,12 @@ func (r *PostgresMusicRepository) AddSong(ctx context.Context, song models.Song)
 
 // GetSongByID — получение песни по ID.
 func (r *PostgresMusicRepository) GetSongByID(ctx context.Context, id int) (models.Song, error) {
+	defer metrics.ObserveQuery("GetSongByID", time.Now())
 	var song models.Song
-	query := `SELECT id, group_name, title, text, link, release_date FROM songs WHERE id = $1`
-	err := r.db.QueryRowContext(ctx, query, id).Scan(&song.ID, &song.Group, &song.Title, &song.Text, &song.Link, &song.ReleaseDate)
+	query := `SELECT id, group_name, title, text, link, release_date FROM songs WHERE tenant_id = $1 AND id = $2`
+	err := inTenant(ctx, r.db, func(ctx context.Context, tenantID int) error {
+		return conn(ctx, r.db).QueryRowContext(ctx, query, tenantID, id).Scan(&song.ID, &song.Group, &song.Title, &song.Text, &song.Link, &song.ReleaseDate)
+	})
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return models.Song{}, nil // Вернем пустую песню, если запись не найдена
@@ -94,11 +217,32 @@ func (r *PostgresMusicRepository) GetSongByID(ctx context.Context, id int) (mode
 	return song, nil
 }
 
+// LockSongByID — получение песни по ID с блокировкой строки (FOR UPDATE) до конца транзакции:
+// параллельное изменение той же песни дождётся фиксации и прочитает уже изменённую строку.
+func (r *PostgresMusicRepository) LockSongByID(ctx context.Context, id int) (models.Song, error) {
+	defer metrics.ObserveQuery("LockSongByID", time.Now())
+	var song models.Song
+	query := `SELECT id, group_name, title, text, link, release_date FROM songs WHERE tenant_id = $1 AND id = $2 FOR UPDATE`
+	err := inTenant(ctx, r.db, func(ctx context.Context, tenantID int) error {
+		return conn(ctx, r.db).QueryRowContext(ctx, query, tenantID, id).Scan(&song.ID, &song.Group, &song.Title, &song.Text, &song.Link, &song.ReleaseDate)
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Song{}, nil
+		}
+		return models.Song{}, fmt.Errorf("ошибка при блокировке песни по ID: %w", err)
+	}
+	return song, nil
+}
+
 // GetSong — получение песни по group_name и title.
 func (r *PostgresMusicRepository) GetSong(ctx context.Context, group string, title string) (models.Song, error) {
+	defer metrics.ObserveQuery("GetSong", time.Now())
 	var song models.Song
-	query := `SELECT id, group_name, title, text, link, release_date FROM songs WHERE group_name = $1 AND title = $2`
-	err := r.db.QueryRowContext(ctx, query, group, title).Scan(&song.ID, &song.Group, &song.Title, &song.Text, &song.Link, &song.ReleaseDate)
+	query := `SELECT id, group_name, title, text, link, release_date FROM songs WHERE tenant_id = $1 AND group_name = $2 AND title = $3`
+	err := inTenant(ctx, r.db, func(ctx context.Context, tenantID int) error {
+		return conn(ctx, r.db).QueryRowContext(ctx, query, tenantID, group, title).Scan(&song.ID, &song.Group, &song.Title, &song.Text, &song.Link, &song.ReleaseDate)
+	})
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return models.Song{}, nil // Вернем пустую песню, если запись не найдена
@@ -110,8 +254,15 @@ func (r *PostgresMusicRepository) GetSong(ctx context.Context, group string, tit
 
 // UpdateSong — обновление данных песни.
 func (r *PostgresMusicRepository) UpdateSong(ctx context.Context, song models.Song) error {
-	query := `UPDATE songs SET group_name = $1, title = $2, text = $3, link = $4, release_date = $5, updated_at = NOW() WHERE id = $6`
-	_, err := r.db.ExecContext(ctx, query, song.Group, song.Title, song.Text, song.Link, song.ReleaseDate, song.ID)
+	defer metrics.ObserveQuery("UpdateSong", time.Now())
+	query := `UPDATE songs SET group_name = $1, title = $2, text = $3, link = $4, release_date = $5, updated_at = NOW() WHERE id = $6 AND tenant_id = $7`
+	err := inTenant(ctx, r.db, func(ctx context.Context, tenantID int) error {
+		_, err := conn(ctx, r.db).ExecContext(ctx, query, song.Group, song.Title, song.Text, song.Link, song.ReleaseDate, song.ID, tenantID)
+		return err
+	})
+	if isUniqueViolation(err) {
+		return catalog_errors.ErrSongExists
+	}
 	if err != nil {
 		return fmt.Errorf("ошибка при обновлении песни: %w", err)
 	}
@@ -120,8 +271,12 @@ func (r *PostgresMusicRepository) UpdateSong(ctx context.Context, song models.So
 
 // DeleteSong — удаление песни по ID.
 func (r *PostgresMusicRepository) DeleteSong(ctx context.Context, id int) error {
-	query := `DELETE FROM songs WHERE id = $1`
-	_, err := r.db.ExecContext(ctx, query, id)
+	defer metrics.ObserveQuery("DeleteSong", time.Now())
+	query := `DELETE FROM songs WHERE id = $1 AND tenant_id = $2`
+	err := inTenant(ctx, r.db, func(ctx context.Context, tenantID int) error {
+		_, err := conn(ctx, r.db).ExecContext(ctx, query, id, tenantID)
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("ошибка при удалении песни: %w", err)
 	}
@@ -130,13 +285,17 @@ func (r *PostgresMusicRepository) DeleteSong(ctx context.Context, id int) error
 
 // GetSongText retrieves song text with pagination (verse by verse)
 func (r *PostgresMusicRepository) GetSongText(ctx context.Context, songID int, page int) (string, error) {
+	defer metrics.ObserveQuery("GetSongText", time.Now())
 	// Query to get the text for the song
-	query := `SELECT text FROM songs WHERE id = $1`
+	query := `SELECT text FROM songs WHERE id = $1 AND tenant_id = $2`
 
 	var fullText string
 
 	// Execute query
-	err := r.db.QueryRowContext(ctx, query, songID).Scan(&fullText)
+	db := r.reader(ctx)
+	err := inTenant(ctx, db, func(ctx context.Context, tenantID int) error {
+		return conn(ctx, db).QueryRowContext(ctx, query, songID, tenantID).Scan(&fullText)
+	})
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return "", catalog_errors.ErrSongNotFound
@@ -148,8 +307,8 @@ func (r *PostgresMusicRepository) GetSongText(ctx context.Context, songID int, p
 		return fullText, nil
 	}
 
-	// Split the text into verses (assuming each verse is separated by a newline)
-	verses := strings.Split(fullText, "\n\n")
+	// Split the text into verses (assuming each verse is separated by a blank line)
+	verses := models.SplitVerses(fullText)
 	if page < 1 || page > len(verses) {
 		return "", catalog_errors.ErrInvalidPage
 	}
@@ -157,3 +316,49 @@ func (r *PostgresMusicRepository) GetSongText(ctx context.Context, songID int, p
 	// Return the specific verse for the given page
 	return verses[page-1], nil
 }
+
+// GetCatalogStats — число песен и песен без текста в каталоге каждого арендатора.
+// Политика RLS показывает только строки выбранного арендатора, поэтому арендаторы
+// перебираются в одной транзакции со сменой app.tenant_id.
+func (r *PostgresMusicRepository) GetCatalogStats(ctx context.Context) ([]models.CatalogStats, error) {
+	defer metrics.ObserveQuery("GetCatalogStats", time.Now())
+	var stats []models.CatalogStats
+	err := withinTransaction(ctx, r.db, func(ctx context.Context) error {
+		rows, err := conn(ctx, r.db).QueryContext(ctx, `SELECT id, slug FROM tenants ORDER BY id`)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		var ids []int
+		for rows.Next() {
+			var id int
+			var s models.CatalogStats
+			if err := rows.Scan(&id, &s.Tenant); err != nil {
+				return err
+			}
+			ids = append(ids, id)
+			stats = append(stats, s)
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		rows.Close()
+
+		for i, id := range ids {
+			if _, err := conn(ctx, r.db).ExecContext(ctx, `SELECT set_config('app.tenant_id', $1, true)`, strconv.Itoa(id)); err != nil {
+				return err
+			}
+			err := conn(ctx, r.db).QueryRowContext(ctx,
+				`SELECT COUNT(*), COUNT(*) FILTER (WHERE COALESCE(text, '') = '') FROM songs WHERE tenant_id = $1`, id,
+			).Scan(&stats[i].Songs, &stats[i].SongsMissingLyrics)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при подсчёте песен в каталогах: %w", err)
+	}
+	return stats, nil
+}
@@ -0,0 +1,68 @@
+package pg_repo_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"music_catalog/internal/db"
+	catalog_errors "music_catalog/internal/errors"
+	"music_catalog/internal/logger"
+	"music_catalog/internal/models"
+	"music_catalog/internal/repository/pg_repo"
+	"music_catalog/internal/repository/repotest"
+
+	_ "github.com/lib/pq"
+)
+
+// testDSNEnv — переменная окружения со строкой подключения к тестовой базе PostgreSQL.
+// Тесты очищают таблицу songs, поэтому база должна быть отдельной
+const testDSNEnv = "TEST_DATABASE_DSN"
+
+func TestPostgresSongRepository(t *testing.T) {
+	conn := openTestDB(t)
+	repotest.SongRepository(t, func(t *testing.T) (pg_repo.SongRepository, models.Tenant) {
+		if _, err := conn.Exec(`TRUNCATE songs RESTART IDENTITY CASCADE`); err != nil {
+			t.Fatal(err)
+		}
+		return pg_repo.NewPostgresSongRepository(conn), testTenant(t, conn)
+	})
+}
+
+// openTestDB подключается к базе из TEST_DATABASE_DSN и применяет миграции; без неё тест пропускается
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv(testDSNEnv)
+	if dsn == "" {
+		t.Skip(testDSNEnv + " is not set")
+	}
+	conn, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	log, _ := logger.New("error", "text", io.Discard)
+	if err := db.NewMigrator(conn, time.Minute, log).Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return conn
+}
+
+// testTenant — второй арендатор для проверок изоляции каталогов
+func testTenant(t *testing.T, conn *sql.DB) models.Tenant {
+	t.Helper()
+	tenants := pg_repo.NewPostgresTenantRepository(conn)
+	acme, err := tenants.GetTenantBySlug(context.Background(), "acme")
+	if errors.Is(err, catalog_errors.ErrTenantNotFound) {
+		acme, err = tenants.CreateTenant(context.Background(), models.Tenant{Slug: "acme", Name: "Acme Records"})
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return acme
+}
@@ -0,0 +1,79 @@
+package pg_repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"music_catalog/internal/ratelimit"
+)
+
+// rateLimitSweepInterval — как часто удалять корзины, которые успели наполниться
+const rateLimitSweepInterval = time.Minute
+
+// PostgresRateLimitStore — корзины ограничения частоты запросов в таблице rate_limit_buckets,
+// общие для всех экземпляров сервиса.
+type PostgresRateLimitStore struct {
+	db *sql.DB
+
+	mu        sync.Mutex
+	lastSweep time.Time
+	maxPeriod time.Duration
+}
+
+// NewPostgresRateLimitStore — конструктор для PostgresRateLimitStore.
+func NewPostgresRateLimitStore(db *sql.DB) *PostgresRateLimitStore {
+	return &PostgresRateLimitStore{db: db, lastSweep: time.Now()}
+}
+
+// Take — пополнение корзины и расход токена одним оператором. Все выражения SET вычисляются
+// по старой строке, а конкурентные запросы по одному ключу выполняются по очереди на блокировке строки.
+func (s *PostgresRateLimitStore) Take(ctx context.Context, key string, quota ratelimit.Quota) (ratelimit.Result, error) {
+	query := `INSERT INTO rate_limit_buckets AS b (key, tokens, allowed, updated_at)
+		VALUES ($1, $2::DOUBLE PRECISION - 1, TRUE, NOW())
+		ON CONFLICT (key) DO UPDATE SET
+			tokens = LEAST($2, b.tokens + EXTRACT(EPOCH FROM NOW() - b.updated_at) * $3)
+				- CASE WHEN LEAST($2, b.tokens + EXTRACT(EPOCH FROM NOW() - b.updated_at) * $3) >= 1 THEN 1 ELSE 0 END,
+			allowed = LEAST($2, b.tokens + EXTRACT(EPOCH FROM NOW() - b.updated_at) * $3) >= 1,
+			updated_at = NOW()
+		RETURNING tokens, allowed`
+	rate := float64(quota.Limit) / quota.Period.Seconds()
+
+	var tokens float64
+	var allowed bool
+	if err := s.db.QueryRowContext(ctx, query, key, quota.Limit, rate).Scan(&tokens, &allowed); err != nil {
+		return ratelimit.Result{}, fmt.Errorf("ошибка при обновлении корзины ограничения запросов: %w", err)
+	}
+	s.sweep(ctx, quota.Period)
+	return ratelimit.NewResult(allowed, tokens, quota), nil
+}
+
+// sweep не чаще раза в rateLimitSweepInterval удаляет корзины, не тронутые дольше самого длинного
+// периода квот: они уже полны, и новая корзина ничем от них не отличается
+func (s *PostgresRateLimitStore) sweep(ctx context.Context, period time.Duration) {
+	s.mu.Lock()
+	if period > s.maxPeriod {
+		s.maxPeriod = period
+	}
+	if time.Since(s.lastSweep) < rateLimitSweepInterval {
+		s.mu.Unlock()
+		return
+	}
+	s.lastSweep = time.Now()
+	maxPeriod := s.maxPeriod
+	s.mu.Unlock()
+
+	go s.PurgeIdle(context.WithoutCancel(ctx), maxPeriod)
+}
+
+// PurgeIdle — удаление корзин, не менявшихся дольше idle: за наибольший период квоты корзина
+// наполняется целиком и ничем не отличается от отсутствующей. Возвращает число удалённых.
+func (s *PostgresRateLimitStore) PurgeIdle(ctx context.Context, idle time.Duration) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM rate_limit_buckets WHERE updated_at < NOW() - $1 * INTERVAL '1 second'`, idle.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при удалении неактивных корзин: %w", err)
+	}
+	return result.RowsAffected()
+}
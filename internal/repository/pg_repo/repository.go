@@ -11,7 +11,65 @@ type SongRepository interface {
 	GetSongs(ctx context.Context, filters models.SongFilters, pagination models.Pagination) ([]models.Song, error) // Получить список песен с фильтрацией и пагинацией
 	AddSong(ctx context.Context, song models.Song) (int, error)                                                    // Добавить новую песню
 	GetSongByID(ctx context.Context, id int) (models.Song, error)                                                  // Получить песню по ID
+	LockSongByID(ctx context.Context, id int) (models.Song, error)                                                 // Получить песню по ID и заблокировать её до конца транзакции
+	GetSongsByIDs(ctx context.Context, ids []int) ([]models.Song, error)                                           // Получить песни по списку ID
+	GetSongsByGroups(ctx context.Context, groups []string) ([]models.Song, error)                                  // Получить все песни указанных групп
+	GetGroups(ctx context.Context, name string, pagination models.Pagination) ([]string, error)                    // Получить список групп
+	SearchLyrics(ctx context.Context, text string, pagination models.Pagination) ([]models.Song, error)            // Найти песни по словам текста
 	GetSong(ctx context.Context, group string, title string) (models.Song, error)                                  // Получить песню по group_name и title                                          // Получить песню по ID
 	UpdateSong(ctx context.Context, song models.Song) error                                                        // Обновить песню
 	DeleteSong(ctx context.Context, id int) error                                                                  // Удалить песню
 }
+
+// Transactor — выполнение операций нескольких репозиториев в одной транзакции.
+type Transactor interface {
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// WebhookRepository — интерфейс для работы с подписками на вебхуки и их доставками.
+type WebhookRepository interface {
+	CreateWebhook(ctx context.Context, webhook models.Webhook) (models.Webhook, error)                                               // Добавить подписку
+	GetWebhooks(ctx context.Context, pagination models.Pagination) ([]models.Webhook, error)                                         // Получить список подписок
+	GetWebhookByID(ctx context.Context, id int) (models.Webhook, error)                                                              // Получить подписку по ID
+	UpdateWebhook(ctx context.Context, webhook models.Webhook) (models.Webhook, error)                                               // Обновить подписку
+	DeleteWebhook(ctx context.Context, id int) error                                                                                 // Удалить подписку
+	GetDeliveries(ctx context.Context, webhookID int, status string, pagination models.Pagination) ([]models.WebhookDelivery, error) // Получить доставки подписки
+	RequeueDelivery(ctx context.Context, webhookID int, deliveryID int64) error                                                      // Повторно поставить доставку в очередь
+}
+
+// APIKeyRepository — интерфейс для работы с API-ключами.
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, key models.APIKey) (models.APIKey, error)                          // Добавить ключ
+	GetAPIKeys(ctx context.Context, tenantID int, pagination models.Pagination) ([]models.APIKey, error) // Получить список ключей арендатора
+	GetAPIKeyByHash(ctx context.Context, hash string) (models.APIKey, error)                             // Получить действующий ключ по хэшу
+	TouchAPIKey(ctx context.Context, id int) error                                                       // Отметить использование ключа
+	RevokeAPIKey(ctx context.Context, tenantID int, id int) error                                        // Отозвать ключ арендатора
+}
+
+// LibraryRepository — интерфейс для работы с пользователями, избранным и плейлистами.
+type LibraryRepository interface {
+	EnsureUser(ctx context.Context, subject string, displayName string) (models.User, error)                // Получить или создать пользователя
+	GetFavorites(ctx context.Context, userID int, pagination models.Pagination) ([]models.Favorite, error)  // Получить избранное
+	AddFavorite(ctx context.Context, userID int, songID int) error                                          // Добавить песню в избранное
+	RemoveFavorite(ctx context.Context, userID int, songID int) error                                       // Удалить песню из избранного
+	CreatePlaylist(ctx context.Context, playlist models.Playlist) (models.Playlist, error)                  // Создать плейлист
+	GetPlaylists(ctx context.Context, ownerID int, pagination models.Pagination) ([]models.Playlist, error) // Получить плейлисты пользователя
+	GetPlaylist(ctx context.Context, id int) (models.Playlist, error)                                       // Получить плейлист по ID
+	GetPlaylistByShareToken(ctx context.Context, token string) (models.Playlist, error)                     // Получить опубликованный плейлист
+	GetPlaylistItems(ctx context.Context, playlistID int) ([]models.PlaylistItem, error)                    // Получить треки плейлиста
+	UpdatePlaylist(ctx context.Context, playlist models.Playlist) error                                     // Обновить плейлист
+	DeletePlaylist(ctx context.Context, id int) error                                                       // Удалить плейлист
+	TouchPlaylist(ctx context.Context, id int) error                                                        // Отметить изменение и заблокировать плейлист
+	CompactPlaylistItems(ctx context.Context, playlistID int) (int, error)                                  // Уплотнить позиции треков
+	InsertPlaylistItem(ctx context.Context, playlistID int, songID int, position int) (int64, error)        // Вставить трек
+	MovePlaylistItem(ctx context.Context, playlistID int, itemID int64, position int) error                 // Переместить трек
+	DeletePlaylistItem(ctx context.Context, playlistID int, itemID int64) error                             // Удалить трек
+	ReorderPlaylistItems(ctx context.Context, playlistID int, itemIDs []int64) error                        // Задать порядок треков
+}
+
+// TenantRepository — интерфейс для работы с арендаторами.
+type TenantRepository interface {
+	CreateTenant(ctx context.Context, tenant models.Tenant) (models.Tenant, error)         // Добавить арендатора
+	GetTenants(ctx context.Context, pagination models.Pagination) ([]models.Tenant, error) // Получить список арендаторов
+	GetTenantBySlug(ctx context.Context, slug string) (models.Tenant, error)               // Получить арендатора по slug
+}
@@ -0,0 +1,77 @@
+package pg_repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	catalog_errors "music_catalog/internal/errors"
+	"music_catalog/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// PostgresTenantRepository — справочник арендаторов.
+type PostgresTenantRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresTenantRepository — конструктор для PostgresTenantRepository.
+func NewPostgresTenantRepository(db *sql.DB) *PostgresTenantRepository {
+	return &PostgresTenantRepository{db: db}
+}
+
+// CreateTenant — добавление арендатора; занятый slug даёт ErrTenantExists.
+func (r *PostgresTenantRepository) CreateTenant(ctx context.Context, tenant models.Tenant) (models.Tenant, error) {
+	query := `INSERT INTO tenants (slug, name) VALUES ($1, $2) RETURNING id, slug, name, created_at`
+	var created models.Tenant
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, tenant.Slug, tenant.Name).Scan(&created.ID, &created.Slug, &created.Name, &created.CreatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return models.Tenant{}, catalog_errors.ErrTenantExists
+		}
+		return models.Tenant{}, fmt.Errorf("ошибка при добавлении арендатора: %w", err)
+	}
+	return created, nil
+}
+
+// GetTenants — получение списка арендаторов с пагинацией.
+func (r *PostgresTenantRepository) GetTenants(ctx context.Context, pagination models.Pagination) ([]models.Tenant, error) {
+	query := `SELECT id, slug, name, created_at FROM tenants ORDER BY id LIMIT $1 OFFSET $2`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, pagination.Limit, pagination.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении арендаторов: %w", err)
+	}
+	defer rows.Close()
+
+	var tenants []models.Tenant
+	for rows.Next() {
+		var t models.Tenant
+		if err := rows.Scan(&t.ID, &t.Slug, &t.Name, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		tenants = append(tenants, t)
+	}
+	return tenants, rows.Err()
+}
+
+// GetTenantBySlug — получение арендатора по slug.
+func (r *PostgresTenantRepository) GetTenantBySlug(ctx context.Context, slug string) (models.Tenant, error) {
+	query := `SELECT id, slug, name, created_at FROM tenants WHERE slug = $1`
+	var t models.Tenant
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, slug).Scan(&t.ID, &t.Slug, &t.Name, &t.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Tenant{}, catalog_errors.ErrTenantNotFound
+		}
+		return models.Tenant{}, fmt.Errorf("ошибка при получении арендатора: %w", err)
+	}
+	return t, nil
+}
+
+// isUniqueViolation — нарушение ограничения уникальности.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
@@ -0,0 +1,63 @@
+package pg_repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+
+	"music_catalog/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracedExecutor оборачивает каждый SQL-запрос в спан с текстом запроса (без аргументов)
+type tracedExecutor struct {
+	executor
+}
+
+func (e tracedExecutor) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+	result, err := e.executor.ExecContext(ctx, query, args...)
+	if err != nil {
+		tracing.Fail(span, err)
+	}
+	return result, err
+}
+
+// QueryContext: спан покрывает выполнение запроса до получения первых строк
+func (e tracedExecutor) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+	rows, err := e.executor.QueryContext(ctx, query, args...)
+	if err != nil {
+		tracing.Fail(span, err)
+	}
+	return rows, err
+}
+
+func (e tracedExecutor) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	ctx, span := startQuerySpan(ctx, query)
+	defer span.End()
+	row := e.executor.QueryRowContext(ctx, query, args...)
+	if err := row.Err(); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		tracing.Fail(span, err)
+	}
+	return row
+}
+
+// startQuerySpan называет спан по SQL-команде (SELECT, INSERT, ...), как принято в семантических соглашениях
+func startQuerySpan(ctx context.Context, query string) (context.Context, trace.Span) {
+	statement := strings.Join(strings.Fields(query), " ")
+	operation, _, _ := strings.Cut(statement, " ")
+	operation = strings.ToUpper(operation)
+	return tracing.Start(ctx, operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.operation", operation),
+			attribute.String("db.statement", statement),
+		))
+}
@@ -0,0 +1,78 @@
+package pg_repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+
+	"music_catalog/internal/tenant"
+)
+
+// executor — общий интерфейс *sql.DB и *sql.Tx для выполнения запросов
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+type txKey struct{}
+
+// conn возвращает транзакцию из контекста, если она открыта через WithinTransaction, иначе пул соединений.
+// Каждый запрос через него попадает в трассировку отдельным спаном.
+func conn(ctx context.Context, db *sql.DB) executor {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tracedExecutor{tx}
+	}
+	return tracedExecutor{db}
+}
+
+// PostgresTransactor — выполнение нескольких операций репозиториев в одной транзакции.
+type PostgresTransactor struct {
+	db *sql.DB
+}
+
+// NewPostgresTransactor — конструктор для PostgresTransactor.
+func NewPostgresTransactor(db *sql.DB) *PostgresTransactor {
+	return &PostgresTransactor{db: db}
+}
+
+// WithinTransaction выполняет fn в транзакции: репозитории, получившие контекст fn, пишут в неё же.
+// Вложенный вызов переиспользует уже открытую транзакцию.
+func (t *PostgresTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return withinTransaction(ctx, t.db, fn)
+}
+
+func withinTransaction(ctx context.Context, db *sql.DB, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка при открытии транзакции: %w", err)
+	}
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка при фиксации транзакции: %w", err)
+	}
+	return nil
+}
+
+// inTenant выполняет fn в транзакции, в которой app.tenant_id указывает на арендатора из контекста.
+// Политики row-level security (миграция 000006) пропускают только строки этого арендатора;
+// запросы всё равно фильтруют по tenant_id явно, т.к. на суперпользователя RLS не действует.
+func inTenant(ctx context.Context, db *sql.DB, fn func(ctx context.Context, tenantID int) error) error {
+	id := tenant.IDFromContext(ctx)
+	return withinTransaction(ctx, db, func(ctx context.Context) error {
+		if _, err := conn(ctx, db).ExecContext(ctx, `SELECT set_config('app.tenant_id', $1, true)`, strconv.Itoa(id)); err != nil {
+			return fmt.Errorf("ошибка при выборе арендатора: %w", err)
+		}
+		return fn(ctx, id)
+	})
+}
@@ -0,0 +1,277 @@
+package pg_repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	catalog_errors "music_catalog/internal/errors"
+	"music_catalog/internal/models"
+	"music_catalog/internal/tenant"
+	"music_catalog/internal/webhooks"
+
+	"github.com/lib/pq"
+)
+
+// PostgresWebhookRepository — подписки на вебхуки и очередь их доставок.
+// Источником событий служит журнал song_events, который пишется в одной транзакции с изменением песни.
+type PostgresWebhookRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresWebhookRepository — конструктор для PostgresWebhookRepository.
+func NewPostgresWebhookRepository(db *sql.DB) *PostgresWebhookRepository {
+	return &PostgresWebhookRepository{db: db}
+}
+
+// CreateWebhook — добавление подписки.
+func (r *PostgresWebhookRepository) CreateWebhook(ctx context.Context, webhook models.Webhook) (models.Webhook, error) {
+	query := `INSERT INTO webhooks (target_url, event_types, secret, active, tenant_id) VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, target_url, event_types, secret, active, created_at, updated_at`
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, webhook.TargetURL, pq.Array(webhook.EventTypes), webhook.Secret, webhook.Active, tenant.IDFromContext(ctx))
+	created, err := scanWebhook(row)
+	if err != nil {
+		return models.Webhook{}, fmt.Errorf("ошибка при добавлении вебхука: %w", err)
+	}
+	return created, nil
+}
+
+// GetWebhooks — получение списка подписок арендатора с пагинацией.
+func (r *PostgresWebhookRepository) GetWebhooks(ctx context.Context, pagination models.Pagination) ([]models.Webhook, error) {
+	query := `SELECT id, target_url, event_types, secret, active, created_at, updated_at FROM webhooks WHERE tenant_id = $1 ORDER BY id LIMIT $2 OFFSET $3`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, tenant.IDFromContext(ctx), pagination.Limit, pagination.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении вебхуков: %w", err)
+	}
+	defer rows.Close()
+
+	var result []models.Webhook
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, webhook)
+	}
+	return result, rows.Err()
+}
+
+// GetWebhookByID — получение подписки по ID.
+func (r *PostgresWebhookRepository) GetWebhookByID(ctx context.Context, id int) (models.Webhook, error) {
+	query := `SELECT id, target_url, event_types, secret, active, created_at, updated_at FROM webhooks WHERE id = $1 AND tenant_id = $2`
+	webhook, err := scanWebhook(conn(ctx, r.db).QueryRowContext(ctx, query, id, tenant.IDFromContext(ctx)))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Webhook{}, catalog_errors.ErrWebhookNotFound
+		}
+		return models.Webhook{}, fmt.Errorf("ошибка при получении вебхука: %w", err)
+	}
+	return webhook, nil
+}
+
+// UpdateWebhook — обновление подписки; пустой secret оставляет прежний.
+func (r *PostgresWebhookRepository) UpdateWebhook(ctx context.Context, webhook models.Webhook) (models.Webhook, error) {
+	query := `UPDATE webhooks SET target_url = $1, event_types = $2, secret = COALESCE(NULLIF($3, ''), secret), active = $4, updated_at = NOW()
+		WHERE id = $5 AND tenant_id = $6
+		RETURNING id, target_url, event_types, secret, active, created_at, updated_at`
+	row := conn(ctx, r.db).QueryRowContext(ctx, query, webhook.TargetURL, pq.Array(webhook.EventTypes), webhook.Secret, webhook.Active, webhook.ID, tenant.IDFromContext(ctx))
+	updated, err := scanWebhook(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return models.Webhook{}, catalog_errors.ErrWebhookNotFound
+		}
+		return models.Webhook{}, fmt.Errorf("ошибка при обновлении вебхука: %w", err)
+	}
+	return updated, nil
+}
+
+// DeleteWebhook — удаление подписки вместе с её доставками.
+func (r *PostgresWebhookRepository) DeleteWebhook(ctx context.Context, id int) error {
+	result, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM webhooks WHERE id = $1 AND tenant_id = $2`, id, tenant.IDFromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("ошибка при удалении вебхука: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return catalog_errors.ErrWebhookNotFound
+	}
+	return nil
+}
+
+// GetDeliveries — получение доставок подписки (новые первыми) вместе с журналом попыток.
+// Пустой status означает доставки в любом статусе.
+func (r *PostgresWebhookRepository) GetDeliveries(ctx context.Context, webhookID int, status string, pagination models.Pagination) ([]models.WebhookDelivery, error) {
+	query := `SELECT d.id, d.webhook_id, d.event_id, e.type, d.status, d.attempts, d.next_attempt_at, d.delivered_at, d.created_at
+		FROM webhook_deliveries d JOIN song_events e ON e.id = d.event_id
+		WHERE d.webhook_id = $1 AND ($2 = '' OR d.status = $2)
+		ORDER BY d.id DESC LIMIT $3 OFFSET $4`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, webhookID, status, pagination.Limit, pagination.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении доставок: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []models.WebhookDelivery
+	index := map[int64]int{}
+	var ids []int64
+	for rows.Next() {
+		var d models.WebhookDelivery
+		var nextAttemptAt, deliveredAt sql.NullTime
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.EventID, &d.EventType, &d.Status, &d.Attempts, &nextAttemptAt, &deliveredAt, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		if d.Status == models.DeliveryPending && nextAttemptAt.Valid {
+			d.NextAttemptAt = &nextAttemptAt.Time
+		}
+		if deliveredAt.Valid {
+			d.DeliveredAt = &deliveredAt.Time
+		}
+		d.AttemptLog = []models.WebhookDeliveryAttempt{}
+		index[d.ID] = len(deliveries)
+		ids = append(ids, d.ID)
+		deliveries = append(deliveries, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return deliveries, nil
+	}
+
+	// Журнал попыток всех доставок страницы одним запросом
+	attemptsQuery := `SELECT delivery_id, attempt, COALESCE(status_code, 0), COALESCE(error, ''), duration_ms, attempted_at
+		FROM webhook_delivery_attempts WHERE delivery_id = ANY($1) ORDER BY delivery_id, attempt`
+	attemptRows, err := conn(ctx, r.db).QueryContext(ctx, attemptsQuery, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении попыток доставки: %w", err)
+	}
+	defer attemptRows.Close()
+
+	for attemptRows.Next() {
+		var deliveryID int64
+		var a models.WebhookDeliveryAttempt
+		if err := attemptRows.Scan(&deliveryID, &a.Attempt, &a.StatusCode, &a.Error, &a.DurationMs, &a.AttemptedAt); err != nil {
+			return nil, err
+		}
+		i := index[deliveryID]
+		deliveries[i].AttemptLog = append(deliveries[i].AttemptLog, a)
+	}
+	return deliveries, attemptRows.Err()
+}
+
+// RequeueDelivery — повторная постановка доставки в очередь (в том числе из dead) с обнулением счётчика попыток.
+func (r *PostgresWebhookRepository) RequeueDelivery(ctx context.Context, webhookID int, deliveryID int64) error {
+	query := `UPDATE webhook_deliveries SET status = 'pending', attempts = 0, next_attempt_at = NOW()
+		WHERE id = $1 AND webhook_id = $2 AND status <> 'delivered'
+			AND webhook_id IN (SELECT id FROM webhooks WHERE tenant_id = $3)`
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, deliveryID, webhookID, tenant.IDFromContext(ctx))
+	if err != nil {
+		return fmt.Errorf("ошибка при повторной постановке доставки: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return catalog_errors.ErrDeliveryNotFound
+	}
+	return nil
+}
+
+// EnqueueDeliveries — разбор необработанной части outbox: события без отметки webhooks_enqueued_at
+// раскладываются по активным подпискам своего арендатора и помечаются одним оператором. SKIP LOCKED позволяет
+// нескольким экземплярам сервиса работать параллельно, а отметка на каждой строке (а не курсор по ID)
+// не теряет события транзакций, зафиксированных не в порядке выдачи ID.
+func (r *PostgresWebhookRepository) EnqueueDeliveries(ctx context.Context, batchSize int) (int, error) {
+	query := `WITH batch AS (
+			SELECT id, type, tenant_id FROM song_events WHERE webhooks_enqueued_at IS NULL ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED
+		), marked AS (
+			UPDATE song_events e SET webhooks_enqueued_at = NOW() FROM batch WHERE e.id = batch.id
+		)
+		INSERT INTO webhook_deliveries (webhook_id, event_id)
+		SELECT w.id, batch.id FROM batch
+		JOIN webhooks w ON w.tenant_id = batch.tenant_id AND w.active AND (cardinality(w.event_types) = 0 OR batch.type = ANY (w.event_types))
+		ON CONFLICT (webhook_id, event_id) DO NOTHING`
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, batchSize)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при постановке доставок в очередь: %w", err)
+	}
+	affected, _ := result.RowsAffected()
+	return int(affected), nil
+}
+
+// ClaimDueDeliveries — выбор доставок, время которых подошло. Следующая попытка сдвигается на lease,
+// так что при падении экземпляра доставка будет повторена после истечения аренды.
+func (r *PostgresWebhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]webhooks.Job, error) {
+	query := `WITH due AS (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at LIMIT $1 FOR UPDATE SKIP LOCKED
+		), claimed AS (
+			UPDATE webhook_deliveries d SET next_attempt_at = NOW() + make_interval(secs => $2)
+			FROM due WHERE d.id = due.id
+			RETURNING d.id, d.webhook_id, d.event_id, d.attempts
+		)
+		SELECT c.id, c.attempts, w.id, w.target_url, w.secret, e.id, e.type, e.song_id, e.payload, e.created_at
+		FROM claimed c
+		JOIN webhooks w ON w.id = c.webhook_id
+		JOIN song_events e ON e.id = c.event_id
+		ORDER BY c.id`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при выборе доставок: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []webhooks.Job
+	for rows.Next() {
+		var job webhooks.Job
+		var payload []byte
+		if err := rows.Scan(&job.DeliveryID, &job.Attempts, &job.WebhookID, &job.TargetURL, &job.Secret,
+			&job.Event.ID, &job.Event.Type, &job.Event.SongID, &payload, &job.Event.OccurredAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(payload, &job.Event.Song); err != nil {
+			return nil, fmt.Errorf("ошибка при разборе события %d: %w", job.Event.ID, err)
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// RecordAttempt — запись попытки в журнал и обновление состояния доставки одним оператором.
+func (r *PostgresWebhookRepository) RecordAttempt(ctx context.Context, deliveryID int64, attempt models.WebhookDeliveryAttempt, status string, nextAttemptAt time.Time) error {
+	query := `WITH logged AS (
+			INSERT INTO webhook_delivery_attempts (delivery_id, attempt, status_code, error, duration_ms, attempted_at)
+			VALUES ($1, $2, NULLIF($3, 0), NULLIF($4, ''), $5, $6)
+		)
+		UPDATE webhook_deliveries SET
+			status = $7,
+			attempts = $2,
+			next_attempt_at = $8,
+			delivered_at = CASE WHEN $7 = 'delivered' THEN NOW() ELSE delivered_at END
+		WHERE id = $1`
+	_, err := conn(ctx, r.db).ExecContext(ctx, query, deliveryID, attempt.Attempt, attempt.StatusCode, attempt.Error,
+		attempt.DurationMs, attempt.AttemptedAt, status, nextAttemptAt)
+	if err != nil {
+		return fmt.Errorf("ошибка при сохранении попытки доставки: %w", err)
+	}
+	return nil
+}
+
+// rowScanner — общий интерфейс *sql.Row и *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanWebhook — чтение подписки с колонками id, target_url, event_types, secret, active, created_at, updated_at.
+func scanWebhook(row rowScanner) (models.Webhook, error) {
+	var webhook models.Webhook
+	var eventTypes pq.StringArray
+	err := row.Scan(&webhook.ID, &webhook.TargetURL, &eventTypes, &webhook.Secret, &webhook.Active, &webhook.CreatedAt, &webhook.UpdatedAt)
+	webhook.EventTypes = []string(eventTypes)
+	if webhook.EventTypes == nil {
+		webhook.EventTypes = []string{}
+	}
+	return webhook, err
+}
+
+// Проверка соответствия интерфейсу диспетчера на этапе компиляции
+var _ webhooks.Repository = (*PostgresWebhookRepository)(nil)
@@ -0,0 +1,320 @@
+// Package repotest — общие проверки реализаций репозиториев: каждое хранилище (PostgreSQL, SQLite,
+// память) прогоняется через одни и те же сценарии и должно вести себя одинаково
+package repotest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	catalog_errors "music_catalog/internal/errors"
+	"music_catalog/internal/models"
+	"music_catalog/internal/repository/pg_repo"
+	"music_catalog/internal/tenant"
+)
+
+// SongFactory возвращает пустое хранилище песен и арендатора, отличного от арендатора по умолчанию,
+// с каталогом которого хранилище может работать (например, уже записанного в таблицу tenants)
+type SongFactory func(t *testing.T) (pg_repo.SongRepository, models.Tenant)
+
+// SongRepository прогоняет реализацию pg_repo.SongRepository через общие сценарии
+func SongRepository(t *testing.T, factory SongFactory) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, repo pg_repo.SongRepository, other context.Context)
+	}{
+		{"AddAndGet", testAddAndGet},
+		{"FiltersAreCaseInsensitive", testFilters},
+		{"Pagination", testPagination},
+		{"GroupAndTitleAreUniquePerTenant", testUniqueness},
+		{"TenantsAreIsolated", testTenantIsolation},
+		{"UpdateAndDelete", testUpdateAndDelete},
+		{"Groups", testGroups},
+		{"SongText", testSongText},
+		{"SearchLyrics", testSearchLyrics},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, other := factory(t)
+			tt.run(t, repo, tenant.WithTenant(context.Background(), other))
+		})
+	}
+}
+
+// songDate — дата выпуска в том виде, в каком её возвращают все хранилища (DATE через lib/pq)
+const songDate = "2006-07-16T00:00:00Z"
+
+func testAddAndGet(t *testing.T, repo pg_repo.SongRepository, _ context.Context) {
+	ctx := context.Background()
+	song := models.Song{Group: "Muse", Title: "Supermassive Black Hole", ReleaseDate: "2006-07-16", Text: "Ooh baby", Link: "https://example.com/muse"}
+	id := mustAdd(t, ctx, repo, song)
+	song.ID, song.ReleaseDate = id, songDate
+
+	got, err := repo.GetSongByID(ctx, id)
+	check(t, err)
+	equal(t, "GetSongByID", got, song)
+
+	got, err = repo.GetSong(ctx, "Muse", "Supermassive Black Hole")
+	check(t, err)
+	equal(t, "GetSong", got, song)
+
+	// Отсутствующая песня — пустая песня без ошибки
+	got, err = repo.GetSongByID(ctx, id+1000)
+	check(t, err)
+	equal(t, "GetSongByID(missing)", got, models.Song{})
+	got, err = repo.GetSong(ctx, "Muse", "supermassive black hole")
+	check(t, err)
+	equal(t, "GetSong(other case)", got, models.Song{})
+
+	songs, err := repo.GetSongsByIDs(ctx, []int{id, id + 1000})
+	check(t, err)
+	equal(t, "GetSongsByIDs", songs, []models.Song{song})
+}
+
+func testFilters(t *testing.T, repo pg_repo.SongRepository, _ context.Context) {
+	ctx := context.Background()
+	muse := mustAdd(t, ctx, repo, models.Song{Group: "Muse", Title: "Uprising", ReleaseDate: "2009-09-07"})
+	kino := mustAdd(t, ctx, repo, models.Song{Group: "Кино", Title: "Группа крови", ReleaseDate: "1988-01-05"})
+	mustAdd(t, ctx, repo, models.Song{Group: "Museum", Title: "Night Shift", ReleaseDate: "2009-09-07"})
+
+	tests := []struct {
+		filters models.SongFilters
+		want    []int
+	}{
+		{models.SongFilters{Group: "muse", Title: "RISING"}, []int{muse}},
+		{models.SongFilters{Group: "кИнО"}, []int{kino}},
+		{models.SongFilters{Title: "крови"}, []int{kino}},
+		{models.SongFilters{Group: "use", ReleaseDate: "1988-01-05"}, nil},
+		{models.SongFilters{ReleaseDate: "1988-01-05"}, []int{kino}},
+		{models.SongFilters{Group: "nothing"}, nil},
+	}
+	for _, tt := range tests {
+		songs, err := repo.GetSongs(ctx, tt.filters, models.Pagination{Limit: 10})
+		check(t, err)
+		equal(t, fmt.Sprintf("GetSongs(%+v)", tt.filters), ids(songs), tt.want)
+	}
+}
+
+func testPagination(t *testing.T, repo pg_repo.SongRepository, _ context.Context) {
+	ctx := context.Background()
+	var all []int
+	for i := 1; i <= 5; i++ {
+		all = append(all, mustAdd(t, ctx, repo, models.Song{Group: "Radiohead", Title: fmt.Sprintf("Track %d", i), ReleaseDate: "1997-05-21"}))
+	}
+
+	tests := []struct {
+		pagination models.Pagination
+		want       []int
+	}{
+		{models.Pagination{Limit: 2}, all[:2]},
+		{models.Pagination{Limit: 2, Offset: 2}, all[2:4]},
+		{models.Pagination{Limit: 2, Offset: 4}, all[4:]},
+		{models.Pagination{Limit: 2, Offset: 5}, nil},
+		{models.Pagination{Limit: 10}, all},
+	}
+	for _, tt := range tests {
+		songs, err := repo.GetSongs(ctx, models.SongFilters{}, tt.pagination)
+		check(t, err)
+		equal(t, fmt.Sprintf("GetSongs(%+v)", tt.pagination), ids(songs), tt.want)
+	}
+}
+
+func testUniqueness(t *testing.T, repo pg_repo.SongRepository, other context.Context) {
+	ctx := context.Background()
+	song := models.Song{Group: "Queen", Title: "Bohemian Rhapsody", ReleaseDate: "1975-10-31"}
+	id := mustAdd(t, ctx, repo, song)
+
+	if _, err := repo.AddSong(ctx, song); !errors.Is(err, catalog_errors.ErrSongExists) {
+		t.Fatalf("AddSong(duplicate) error = %v, want ErrSongExists", err)
+	}
+	// Та же песня в каталоге другого арендатора — другая песня
+	mustAdd(t, other, repo, song)
+
+	second := mustAdd(t, ctx, repo, models.Song{Group: "Queen", Title: "Innuendo", ReleaseDate: "1991-01-14"})
+	err := repo.UpdateSong(ctx, models.Song{ID: second, Group: "Queen", Title: "Bohemian Rhapsody", ReleaseDate: "1991-01-14"})
+	if !errors.Is(err, catalog_errors.ErrSongExists) {
+		t.Fatalf("UpdateSong(duplicate) error = %v, want ErrSongExists", err)
+	}
+	got, err := repo.GetSongByID(ctx, id)
+	check(t, err)
+	equal(t, "GetSongByID after rejected update", got.Title, song.Title)
+}
+
+func testTenantIsolation(t *testing.T, repo pg_repo.SongRepository, other context.Context) {
+	ctx := context.Background()
+	id := mustAdd(t, ctx, repo, models.Song{Group: "Björk", Title: "Jóga", ReleaseDate: "1997-09-15", Text: "All these accidents"})
+	otherID := mustAdd(t, other, repo, models.Song{Group: "Björk", Title: "Hyperballad", ReleaseDate: "1996-02-12"})
+
+	got, err := repo.GetSongByID(other, id)
+	check(t, err)
+	equal(t, "GetSongByID(other tenant)", got, models.Song{})
+	if _, err := repo.GetSongText(other, id, 0); !errors.Is(err, catalog_errors.ErrSongNotFound) {
+		t.Fatalf("GetSongText(other tenant) error = %v, want ErrSongNotFound", err)
+	}
+
+	songs, err := repo.GetSongs(other, models.SongFilters{}, models.Pagination{Limit: 10})
+	check(t, err)
+	equal(t, "GetSongs(other tenant)", ids(songs), []int{otherID})
+	songs, err = repo.GetSongsByIDs(other, []int{id, otherID})
+	check(t, err)
+	equal(t, "GetSongsByIDs(other tenant)", ids(songs), []int{otherID})
+
+	// Изменение и удаление чужой песни ничего не делают
+	check(t, repo.UpdateSong(other, models.Song{ID: id, Group: "Björk", Title: "Changed", ReleaseDate: "1997-09-15"}))
+	check(t, repo.DeleteSong(other, id))
+	got, err = repo.GetSongByID(ctx, id)
+	check(t, err)
+	equal(t, "GetSongByID after foreign update", got.Title, "Jóga")
+}
+
+func testUpdateAndDelete(t *testing.T, repo pg_repo.SongRepository, _ context.Context) {
+	ctx := context.Background()
+	id := mustAdd(t, ctx, repo, models.Song{Group: "Nirvana", Title: "Lithium", ReleaseDate: "1992-07-13"})
+
+	updated := models.Song{ID: id, Group: "Nirvana", Title: "Lithium (Live)", ReleaseDate: "2006-07-16", Text: "Yeah", Link: "https://example.com/lithium"}
+	check(t, repo.UpdateSong(ctx, updated))
+	got, err := repo.GetSongByID(ctx, id)
+	check(t, err)
+	updated.ReleaseDate = songDate
+	equal(t, "GetSongByID after update", got, updated)
+
+	// Отсутствующая песня не считается ошибкой
+	check(t, repo.UpdateSong(ctx, models.Song{ID: id + 1000, Group: "Nirvana", Title: "Missing", ReleaseDate: "1992-07-13"}))
+	check(t, repo.DeleteSong(ctx, id+1000))
+
+	check(t, repo.DeleteSong(ctx, id))
+	got, err = repo.GetSongByID(ctx, id)
+	check(t, err)
+	equal(t, "GetSongByID after delete", got, models.Song{})
+	if _, err := repo.GetSongText(ctx, id, 0); !errors.Is(err, catalog_errors.ErrSongNotFound) {
+		t.Fatalf("GetSongText(deleted) error = %v, want ErrSongNotFound", err)
+	}
+}
+
+func testGroups(t *testing.T, repo pg_repo.SongRepository, _ context.Context) {
+	ctx := context.Background()
+	for _, song := range []models.Song{
+		{Group: "The Beatles", Title: "Help!"},
+		{Group: "The Beatles", Title: "Yesterday"},
+		{Group: "The Doors", Title: "Riders on the Storm"},
+		{Group: "Abba", Title: "Waterloo"},
+	} {
+		song.ReleaseDate = "1970-01-01"
+		mustAdd(t, ctx, repo, song)
+	}
+
+	groups, err := repo.GetGroups(ctx, "THE", models.Pagination{Limit: 10})
+	check(t, err)
+	equal(t, "GetGroups(THE)", groups, []string{"The Beatles", "The Doors"})
+	groups, err = repo.GetGroups(ctx, "", models.Pagination{Limit: 2, Offset: 1})
+	check(t, err)
+	equal(t, "GetGroups(page 2)", groups, []string{"The Beatles", "The Doors"})
+
+	songs, err := repo.GetSongsByGroups(ctx, []string{"The Doors", "The Beatles", "Missing"})
+	check(t, err)
+	var titles []string
+	for _, song := range songs {
+		titles = append(titles, song.Title)
+	}
+	equal(t, "GetSongsByGroups", titles, []string{"Help!", "Yesterday", "Riders on the Storm"})
+}
+
+func testSongText(t *testing.T, repo pg_repo.SongRepository, _ context.Context) {
+	ctx := context.Background()
+	text := "Is this the real life?\nIs this just fantasy?\n\nCaught in a landslide\n\nNo escape from reality"
+	id := mustAdd(t, ctx, repo, models.Song{Group: "Queen", Title: "Bohemian Rhapsody", ReleaseDate: "1975-10-31", Text: text})
+
+	tests := []struct {
+		page int
+		want string
+	}{
+		{0, text},
+		{1, "Is this the real life?\nIs this just fantasy?"},
+		{3, "No escape from reality"},
+	}
+	for _, tt := range tests {
+		got, err := repo.GetSongText(ctx, id, tt.page)
+		check(t, err)
+		equal(t, fmt.Sprintf("GetSongText(page %d)", tt.page), got, tt.want)
+	}
+	for _, page := range []int{-1, 4} {
+		if _, err := repo.GetSongText(ctx, id, page); !errors.Is(err, catalog_errors.ErrInvalidPage) {
+			t.Fatalf("GetSongText(page %d) error = %v, want ErrInvalidPage", page, err)
+		}
+	}
+	if _, err := repo.GetSongText(ctx, id+1000, 1); !errors.Is(err, catalog_errors.ErrSongNotFound) {
+		t.Fatalf("GetSongText(missing) error = %v, want ErrSongNotFound", err)
+	}
+}
+
+func testSearchLyrics(t *testing.T, repo pg_repo.SongRepository, other context.Context) {
+	ctx := context.Background()
+	often := mustAdd(t, ctx, repo, models.Song{Group: "The Beatles", Title: "All You Need Is Love", ReleaseDate: "1967-07-07",
+		Text: "Love, love, love.\nAll you need is love."})
+	once := mustAdd(t, ctx, repo, models.Song{Group: "Whitney Houston", Title: "I Will Always Love You", ReleaseDate: "1992-11-03",
+		Text: "And I will always love you"})
+	kino := mustAdd(t, ctx, repo, models.Song{Group: "Кино", Title: "Группа крови", ReleaseDate: "1988-01-05",
+		Text: "Группа крови на рукаве"})
+	mustAdd(t, ctx, repo, models.Song{Group: "Bill Withers", Title: "Lovely Day", ReleaseDate: "1977-12-01", Text: "Then I look at you and the world's alright with me, lovely day"})
+	foreign := mustAdd(t, other, repo, models.Song{Group: "The Beatles", Title: "Love Me Do", ReleaseDate: "1962-10-05", Text: "Love, love me do"})
+
+	tests := []struct {
+		query      string
+		pagination models.Pagination
+		want       []int
+	}{
+		{"LOVE", models.Pagination{Limit: 10}, []int{often, once}},
+		{"love", models.Pagination{Limit: 1, Offset: 1}, []int{once}},
+		{"you love", models.Pagination{Limit: 10}, []int{often, once}},
+		{"always love you", models.Pagination{Limit: 10}, []int{once}},
+		{"КРОВИ", models.Pagination{Limit: 10}, []int{kino}},
+		{"lov", models.Pagination{Limit: 10}, nil},
+		{`"love" OR *`, models.Pagination{Limit: 10}, nil},
+		{"!!!", models.Pagination{Limit: 10}, nil},
+	}
+	for _, tt := range tests {
+		songs, err := repo.SearchLyrics(ctx, tt.query, tt.pagination)
+		check(t, err)
+		equal(t, fmt.Sprintf("SearchLyrics(%q, %+v)", tt.query, tt.pagination), ids(songs), tt.want)
+	}
+
+	songs, err := repo.SearchLyrics(other, "love", models.Pagination{Limit: 10})
+	check(t, err)
+	equal(t, "SearchLyrics(other tenant)", ids(songs), []int{foreign})
+}
+
+func mustAdd(t *testing.T, ctx context.Context, repo pg_repo.SongRepository, song models.Song) int {
+	t.Helper()
+	id, err := repo.AddSong(ctx, song)
+	if err != nil {
+		t.Fatalf("AddSong(%s - %s) error = %v", song.Group, song.Title, err)
+	}
+	if id <= 0 {
+		t.Fatalf("AddSong(%s - %s) id = %d", song.Group, song.Title, id)
+	}
+	return id
+}
+
+func ids(songs []models.Song) []int {
+	var result []int
+	for _, song := range songs {
+		result = append(result, song.ID)
+	}
+	return result
+}
+
+func check(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func equal(t *testing.T, what string, got, want any) {
+	t.Helper()
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("%s = %#v, want %#v", what, got, want)
+	}
+}
@@ -0,0 +1,95 @@
+package sqlite_repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"music_catalog/internal/events"
+	"music_catalog/internal/models"
+	"music_catalog/internal/tenant"
+)
+
+// SQLiteEventRepository — журнал событий изменения каталога в таблице song_events (миграция sqlite 000002).
+// Уведомлений, как у PostgreSQL, у SQLite нет: о событиях, зафиксированных этим процессом, брокер
+// узнаёт через Changes, а записанные другим процессом (catalogctl) находит, перечитывая журнал.
+type SQLiteEventRepository struct {
+	db      *sql.DB
+	changed chan struct{}
+}
+
+// NewSQLiteEventRepository — конструктор для SQLiteEventRepository.
+func NewSQLiteEventRepository(db *sql.DB) *SQLiteEventRepository {
+	return &SQLiteEventRepository{db: db, changed: make(chan struct{}, 1)}
+}
+
+// Changes — уведомления о событиях, зафиксированных этим процессом, для events.Broker.RunLocal.
+func (r *SQLiteEventRepository) Changes() <-chan struct{} {
+	return r.changed
+}
+
+// Publish — запись события в журнал (в транзакции изменения, если она открыта).
+// Пишущие транзакции SQLite выполняются по одной, поэтому ID событий выдаются в порядке фиксации.
+func (r *SQLiteEventRepository) Publish(ctx context.Context, event events.Event) error {
+	payload, err := json.Marshal(event.Song)
+	if err != nil {
+		return fmt.Errorf("ошибка при сериализации события: %w", err)
+	}
+	query := `INSERT INTO song_events (tenant_id, type, song_id, payload) VALUES (?, ?, ?, ?)`
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, tenant.IDFromContext(ctx), event.Type, event.SongID, string(payload)); err != nil {
+		return fmt.Errorf("ошибка при записи события: %w", err)
+	}
+	afterCommit(ctx, r.notify)
+	return nil
+}
+
+// notify будит брокер; если он ещё не забрал прошлое уведомление, то прочитает журнал и так
+func (r *SQLiteEventRepository) notify() {
+	select {
+	case r.changed <- struct{}{}:
+	default:
+	}
+}
+
+// GetEventsAfter — получение событий арендатора с ID больше afterID.
+func (r *SQLiteEventRepository) GetEventsAfter(ctx context.Context, tenantID int, afterID int64, limit int) ([]events.Event, error) {
+	query := `SELECT id, tenant_id, type, song_id, payload, created_at FROM song_events
+		WHERE tenant_id = ? AND id > ? ORDER BY id LIMIT ?`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, tenantID, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении событий: %w", err)
+	}
+	defer rows.Close()
+
+	var result []events.Event
+	for rows.Next() {
+		var event events.Event
+		var payload string
+		if err := rows.Scan(&event.ID, &event.TenantID, &event.Type, &event.SongID, &payload, &event.OccurredAt); err != nil {
+			return nil, err
+		}
+		var song models.Song
+		if err := json.Unmarshal([]byte(payload), &song); err != nil {
+			return nil, fmt.Errorf("ошибка при разборе события %d: %w", event.ID, err)
+		}
+		event.Song = song
+		result = append(result, event)
+	}
+	return result, rows.Err()
+}
+
+// GetLastEventID — получение ID последнего события арендатора.
+func (r *SQLiteEventRepository) GetLastEventID(ctx context.Context, tenantID int) (int64, error) {
+	var id int64
+	err := conn(ctx, r.db).QueryRowContext(ctx, `SELECT COALESCE(MAX(id), 0) FROM song_events WHERE tenant_id = ?`, tenantID).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при получении последнего события: %w", err)
+	}
+	return id, nil
+}
+
+var (
+	_ events.Publisher = (*SQLiteEventRepository)(nil)
+	_ events.Store     = (*SQLiteEventRepository)(nil)
+)
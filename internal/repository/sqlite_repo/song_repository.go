@@ -0,0 +1,376 @@
+// Package sqlite_repo хранит каталог песен в файле SQLite (STORAGE_BACKEND=sqlite): для установок
+// на одном узле, где отдельная база PostgreSQL для каталога избыточна
+package sqlite_repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	catalog_errors "music_catalog/internal/errors"
+	"music_catalog/internal/metrics"
+	"music_catalog/internal/models"
+	"music_catalog/internal/repository/pg_repo"
+	"music_catalog/internal/tenant"
+	"music_catalog/internal/validation"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// dateLayout — формат даты выпуска в колонке release_date
+const dateLayout = "2006-01-02"
+
+// tenantsPageSize — сколько арендаторов читается за один запрос при подсчёте статистики
+const tenantsPageSize = 100
+
+// TenantLister — список арендаторов для статистики каталогов
+type TenantLister interface {
+	GetTenants(ctx context.Context, pagination models.Pagination) ([]models.Tenant, error)
+}
+
+// SQLiteSongRepository — реализация pg_repo.SongRepository поверх SQLite (схема migrations/sqlite)
+// с тем же поведением, что у PostgresMusicRepository. Фильтры ILIKE выполняются через LIKE над
+// casefold() (см. db.NewSQLiteDB), т.к. LIKE в SQLite не учитывает регистр только для ASCII.
+// Запросы в контексте SQLiteTransactor.WithinTransaction выполняются в его транзакции.
+type SQLiteSongRepository struct {
+	db      *sql.DB
+	tenants TenantLister // nil — статистика помечается ID арендаторов
+}
+
+// NewSQLiteSongRepository — конструктор для SQLiteSongRepository.
+func NewSQLiteSongRepository(db *sql.DB) *SQLiteSongRepository {
+	return &SQLiteSongRepository{db: db}
+}
+
+// WithTenants — арендаторы для GetCatalogStats: статистика помечается их slug и включает пустые каталоги.
+func (r *SQLiteSongRepository) WithTenants(tenants TenantLister) *SQLiteSongRepository {
+	r.tenants = tenants
+	return r
+}
+
+// GetSongs — получение списка песен с фильтрацией и пагинацией.
+func (r *SQLiteSongRepository) GetSongs(ctx context.Context, filters models.SongFilters, pagination models.Pagination) ([]models.Song, error) {
+	defer metrics.ObserveQuery("GetSongs", time.Now())
+	query := "SELECT id, group_name, title, release_date, text, link FROM songs WHERE tenant_id = ?"
+	args := []interface{}{tenant.IDFromContext(ctx)}
+
+	if filters.Group != "" {
+		query += ` AND casefold(group_name) LIKE casefold(?) ESCAPE '\'`
+		args = append(args, "%"+filters.Group+"%")
+	}
+	if filters.Title != "" {
+		query += ` AND casefold(title) LIKE casefold(?) ESCAPE '\'`
+		args = append(args, "%"+filters.Title+"%")
+	}
+	if filters.ReleaseDate != "" {
+		releaseDate, err := parseDate(filters.ReleaseDate)
+		if err != nil {
+			return nil, err
+		}
+		query += " AND release_date = ?"
+		args = append(args, releaseDate)
+	}
+
+	query += " ORDER BY id LIMIT ? OFFSET ?"
+	args = append(args, pagination.Limit, pagination.Offset)
+	return r.querySongs(ctx, "ошибка при получении списка песен", query, args...)
+}
+
+// GetSongsByIDs — получение песен по списку ID одним запросом.
+func (r *SQLiteSongRepository) GetSongsByIDs(ctx context.Context, ids []int) ([]models.Song, error) {
+	defer metrics.ObserveQuery("GetSongsByIDs", time.Now())
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	args := []interface{}{tenant.IDFromContext(ctx)}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	query := `SELECT id, group_name, title, release_date, text, link FROM songs WHERE tenant_id = ? AND id IN (` + placeholders(len(ids)) + `) ORDER BY id`
+	return r.querySongs(ctx, "ошибка при получении песен по ID", query, args...)
+}
+
+// GetSongsByGroups — получение всех песен указанных групп одним запросом.
+func (r *SQLiteSongRepository) GetSongsByGroups(ctx context.Context, groups []string) ([]models.Song, error) {
+	defer metrics.ObserveQuery("GetSongsByGroups", time.Now())
+	if len(groups) == 0 {
+		return nil, nil
+	}
+	args := []interface{}{tenant.IDFromContext(ctx)}
+	for _, group := range groups {
+		args = append(args, group)
+	}
+	query := `SELECT id, group_name, title, release_date, text, link FROM songs WHERE tenant_id = ? AND group_name IN (` + placeholders(len(groups)) + `) ORDER BY group_name, id`
+	return r.querySongs(ctx, "ошибка при получении песен по группам", query, args...)
+}
+
+// GetGroups — получение списка групп с фильтрацией по названию и пагинацией.
+func (r *SQLiteSongRepository) GetGroups(ctx context.Context, name string, pagination models.Pagination) ([]string, error) {
+	defer metrics.ObserveQuery("GetGroups", time.Now())
+	query := `SELECT DISTINCT group_name FROM songs WHERE tenant_id = ? AND casefold(group_name) LIKE casefold(?) ESCAPE '\' ORDER BY group_name LIMIT ? OFFSET ?`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, tenant.IDFromContext(ctx), "%"+name+"%", pagination.Limit, pagination.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении списка групп: %w", err)
+	}
+	defer rows.Close()
+
+	var groups []string
+	for rows.Next() {
+		var group string
+		if err := rows.Scan(&group); err != nil {
+			return nil, err
+		}
+		groups = append(groups, group)
+	}
+	return groups, rows.Err()
+}
+
+// SearchLyrics — полнотекстовый поиск по текстам песен (FTS5, таблица songs_fts): песни, в тексте
+// которых есть все слова запроса, от наиболее релевантных. Слова ищутся как есть, без синтаксиса
+// запросов FTS5 (как plainto_tsquery), поэтому запрос пользователя не приводит к ошибке разбора.
+func (r *SQLiteSongRepository) SearchLyrics(ctx context.Context, text string, pagination models.Pagination) ([]models.Song, error) {
+	defer metrics.ObserveQuery("SearchLyrics", time.Now())
+	match := ftsQuery(text)
+	if match == "" {
+		return nil, nil
+	}
+	query := `SELECT s.id, s.group_name, s.title, s.release_date, s.text, s.link
+		FROM songs_fts JOIN songs s ON s.id = songs_fts.rowid
+		WHERE songs_fts MATCH ? AND s.tenant_id = ?
+		ORDER BY songs_fts.rank, s.id LIMIT ? OFFSET ?`
+	return r.querySongs(ctx, "ошибка при поиске по текстам песен", query, match, tenant.IDFromContext(ctx), pagination.Limit, pagination.Offset)
+}
+
+// querySongs — выполнение запроса с колонками id, group_name, title, release_date, text, link.
+func (r *SQLiteSongRepository) querySongs(ctx context.Context, errorMessage string, query string, args ...interface{}) ([]models.Song, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", errorMessage, err)
+	}
+	defer rows.Close()
+
+	var songs []models.Song
+	for rows.Next() {
+		var song models.Song
+		if err := rows.Scan(&song.ID, &song.Group, &song.Title, &song.ReleaseDate, &song.Text, &song.Link); err != nil {
+			return nil, err
+		}
+		song.ReleaseDate = formatDate(song.ReleaseDate)
+		songs = append(songs, song)
+	}
+	return songs, rows.Err()
+}
+
+// AddSong — добавление новой песни в базу данных.
+func (r *SQLiteSongRepository) AddSong(ctx context.Context, song models.Song) (int, error) {
+	defer metrics.ObserveQuery("AddSong", time.Now())
+	releaseDate, err := parseDate(song.ReleaseDate)
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при добавлении песни: %w", err)
+	}
+	var id int
+	query := `INSERT INTO songs (tenant_id, group_name, title, text, link, release_date) VALUES (?, ?, ?, ?, ?, ?) RETURNING id`
+	err = conn(ctx, r.db).QueryRowContext(ctx, query, tenant.IDFromContext(ctx), song.Group, song.Title, song.Text, song.Link, releaseDate).Scan(&id)
+	if isUniqueViolation(err) {
+		return 0, catalog_errors.ErrSongExists
+	}
+	if err != nil {
+		return 0, fmt.Errorf("ошибка при добавлении песни: %w", err)
+	}
+	return id, nil
+}
+
+// GetSongByID — получение песни по ID.
+func (r *SQLiteSongRepository) GetSongByID(ctx context.Context, id int) (models.Song, error) {
+	defer metrics.ObserveQuery("GetSongByID", time.Now())
+	query := `SELECT id, group_name, title, text, link, release_date FROM songs WHERE tenant_id = ? AND id = ?`
+	song, err := scanSong(conn(ctx, r.db).QueryRowContext(ctx, query, tenant.IDFromContext(ctx), id))
+	if err != nil {
+		return models.Song{}, fmt.Errorf("ошибка при получении песни по ID: %w", err)
+	}
+	return song, nil
+}
+
+// LockSongByID — получение песни по ID для изменения в транзакции. Транзакции SQLite захватывают
+// блокировку записи при открытии (см. SQLiteTransactor), поэтому строку отдельно блокировать не нужно.
+func (r *SQLiteSongRepository) LockSongByID(ctx context.Context, id int) (models.Song, error) {
+	return r.GetSongByID(ctx, id)
+}
+
+// GetSong — получение песни по group_name и title.
+func (r *SQLiteSongRepository) GetSong(ctx context.Context, group string, title string) (models.Song, error) {
+	defer metrics.ObserveQuery("GetSong", time.Now())
+	query := `SELECT id, group_name, title, text, link, release_date FROM songs WHERE tenant_id = ? AND group_name = ? AND title = ?`
+	song, err := scanSong(conn(ctx, r.db).QueryRowContext(ctx, query, tenant.IDFromContext(ctx), group, title))
+	if err != nil {
+		return models.Song{}, fmt.Errorf("ошибка при получении песни: %w", err)
+	}
+	return song, nil
+}
+
+// scanSong — чтение песни с колонками id, group_name, title, text, link, release_date;
+// пустая песня, если запись не найдена
+func scanSong(row *sql.Row) (models.Song, error) {
+	var song models.Song
+	err := row.Scan(&song.ID, &song.Group, &song.Title, &song.Text, &song.Link, &song.ReleaseDate)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Song{}, nil
+	}
+	if err != nil {
+		return models.Song{}, err
+	}
+	song.ReleaseDate = formatDate(song.ReleaseDate)
+	return song, nil
+}
+
+// UpdateSong — обновление данных песни.
+func (r *SQLiteSongRepository) UpdateSong(ctx context.Context, song models.Song) error {
+	defer metrics.ObserveQuery("UpdateSong", time.Now())
+	releaseDate, err := parseDate(song.ReleaseDate)
+	if err != nil {
+		return fmt.Errorf("ошибка при обновлении песни: %w", err)
+	}
+	query := `UPDATE songs SET group_name = ?, title = ?, text = ?, link = ?, release_date = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ? AND tenant_id = ?`
+	_, err = conn(ctx, r.db).ExecContext(ctx, query, song.Group, song.Title, song.Text, song.Link, releaseDate, song.ID, tenant.IDFromContext(ctx))
+	if isUniqueViolation(err) {
+		return catalog_errors.ErrSongExists
+	}
+	if err != nil {
+		return fmt.Errorf("ошибка при обновлении песни: %w", err)
+	}
+	return nil
+}
+
+// DeleteSong — удаление песни по ID.
+func (r *SQLiteSongRepository) DeleteSong(ctx context.Context, id int) error {
+	defer metrics.ObserveQuery("DeleteSong", time.Now())
+	query := `DELETE FROM songs WHERE id = ? AND tenant_id = ?`
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, id, tenant.IDFromContext(ctx)); err != nil {
+		return fmt.Errorf("ошибка при удалении песни: %w", err)
+	}
+	return nil
+}
+
+// GetSongText retrieves song text with pagination (verse by verse)
+func (r *SQLiteSongRepository) GetSongText(ctx context.Context, songID int, page int) (string, error) {
+	defer metrics.ObserveQuery("GetSongText", time.Now())
+	query := `SELECT text FROM songs WHERE id = ? AND tenant_id = ?`
+
+	var fullText string
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, songID, tenant.IDFromContext(ctx)).Scan(&fullText)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", catalog_errors.ErrSongNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("error fetching song text: %w", err)
+	}
+
+	if page == 0 {
+		return fullText, nil
+	}
+
+	verses := models.SplitVerses(fullText)
+	if page < 1 || page > len(verses) {
+		return "", catalog_errors.ErrInvalidPage
+	}
+	return verses[page-1], nil
+}
+
+// GetCatalogStats — число песен и песен без текста в каталоге каждого арендатора.
+func (r *SQLiteSongRepository) GetCatalogStats(ctx context.Context) ([]models.CatalogStats, error) {
+	defer metrics.ObserveQuery("GetCatalogStats", time.Now())
+	rows, err := conn(ctx, r.db).QueryContext(ctx,
+		`SELECT tenant_id, COUNT(*), SUM(COALESCE(text, '') = '') FROM songs GROUP BY tenant_id ORDER BY tenant_id`)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при подсчёте песен в каталогах: %w", err)
+	}
+	defer rows.Close()
+	counts := make(map[int]models.CatalogStats)
+	var ids []int
+	for rows.Next() {
+		var id int
+		var s models.CatalogStats
+		if err := rows.Scan(&id, &s.Songs, &s.SongsMissingLyrics); err != nil {
+			return nil, err
+		}
+		s.Tenant = strconv.Itoa(id)
+		counts[id] = s
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("ошибка при подсчёте песен в каталогах: %w", err)
+	}
+
+	if r.tenants == nil {
+		stats := make([]models.CatalogStats, 0, len(ids))
+		for _, id := range ids {
+			stats = append(stats, counts[id])
+		}
+		return stats, nil
+	}
+
+	var stats []models.CatalogStats
+	for offset := 0; ; offset += tenantsPageSize {
+		tenants, err := r.tenants.GetTenants(ctx, models.Pagination{Limit: tenantsPageSize, Offset: offset})
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range tenants {
+			s := counts[t.ID]
+			s.Tenant = t.Slug
+			stats = append(stats, s)
+		}
+		if len(tenants) < tenantsPageSize {
+			return stats, nil
+		}
+	}
+}
+
+// placeholders — список из n параметров запроса: ?, ?, ?
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// ftsQuery переводит текст в запрос FTS5 из слов в кавычках: все слова должны встретиться в тексте,
+// а символы синтаксиса FTS5 (кавычки, *, ^, NEAR, AND/OR/NOT) теряют специальное значение
+func ftsQuery(text string) string {
+	words := strings.FieldsFunc(text, func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsNumber(c)
+	})
+	for i, word := range words {
+		words[i] = `"` + word + `"`
+	}
+	return strings.Join(words, " ")
+}
+
+// isUniqueViolation — нарушение уникального индекса (группа и название песни арендатора)
+func isUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE
+}
+
+// parseDate приводит дату выпуска в любом формате, который принимает сервис, к формату колонки release_date
+func parseDate(value string) (string, error) {
+	date, err := validation.ParseDate(value)
+	if err != nil {
+		return "", err
+	}
+	return date.Format(dateLayout), nil
+}
+
+// formatDate форматирует дату выпуска из базы так же, как её возвращает PostgreSQL (DATE через lib/pq)
+func formatDate(value string) string {
+	date, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return value
+	}
+	return date.Format(time.RFC3339Nano)
+}
+
+var _ pg_repo.SongRepository = (*SQLiteSongRepository)(nil)
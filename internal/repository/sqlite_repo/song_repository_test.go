@@ -0,0 +1,38 @@
+package sqlite_repo
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"music_catalog/config"
+	"music_catalog/internal/db"
+	"music_catalog/internal/logger"
+	"music_catalog/internal/models"
+	"music_catalog/internal/repository/pg_repo"
+	"music_catalog/internal/repository/repotest"
+)
+
+func TestSQLiteSongRepository(t *testing.T) {
+	repotest.SongRepository(t, func(t *testing.T) (pg_repo.SongRepository, models.Tenant) {
+		return NewSQLiteSongRepository(openTestDB(t)), models.Tenant{ID: 2, Slug: "acme"}
+	})
+}
+
+// openTestDB создаёт базу во временном каталоге теста и применяет к ней миграции
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	log, _ := logger.New("error", "text", io.Discard)
+	path := filepath.Join(t.TempDir(), "catalog.db")
+	if err := db.NewSQLiteMigrator(path, log).Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	conn, err := db.NewSQLiteDB(context.Background(), &config.Config{SQLitePath: path}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
@@ -0,0 +1,72 @@
+package sqlite_repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	catalog_errors "music_catalog/internal/errors"
+	"music_catalog/internal/models"
+	"music_catalog/internal/repository/pg_repo"
+)
+
+// SQLiteTenantRepository — справочник арендаторов в таблице tenants (миграция sqlite 000002).
+type SQLiteTenantRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteTenantRepository — конструктор для SQLiteTenantRepository.
+func NewSQLiteTenantRepository(db *sql.DB) *SQLiteTenantRepository {
+	return &SQLiteTenantRepository{db: db}
+}
+
+// CreateTenant — добавление арендатора; занятый slug даёт ErrTenantExists.
+func (r *SQLiteTenantRepository) CreateTenant(ctx context.Context, tenant models.Tenant) (models.Tenant, error) {
+	query := `INSERT INTO tenants (slug, name) VALUES (?, ?) RETURNING id, slug, name, created_at`
+	var created models.Tenant
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, tenant.Slug, tenant.Name).Scan(&created.ID, &created.Slug, &created.Name, &created.CreatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return models.Tenant{}, catalog_errors.ErrTenantExists
+		}
+		return models.Tenant{}, fmt.Errorf("ошибка при добавлении арендатора: %w", err)
+	}
+	return created, nil
+}
+
+// GetTenants — получение списка арендаторов с пагинацией.
+func (r *SQLiteTenantRepository) GetTenants(ctx context.Context, pagination models.Pagination) ([]models.Tenant, error) {
+	query := `SELECT id, slug, name, created_at FROM tenants ORDER BY id LIMIT ? OFFSET ?`
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, pagination.Limit, pagination.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при получении арендаторов: %w", err)
+	}
+	defer rows.Close()
+
+	var tenants []models.Tenant
+	for rows.Next() {
+		var t models.Tenant
+		if err := rows.Scan(&t.ID, &t.Slug, &t.Name, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		tenants = append(tenants, t)
+	}
+	return tenants, rows.Err()
+}
+
+// GetTenantBySlug — получение арендатора по slug.
+func (r *SQLiteTenantRepository) GetTenantBySlug(ctx context.Context, slug string) (models.Tenant, error) {
+	query := `SELECT id, slug, name, created_at FROM tenants WHERE slug = ?`
+	var t models.Tenant
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, slug).Scan(&t.ID, &t.Slug, &t.Name, &t.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.Tenant{}, catalog_errors.ErrTenantNotFound
+		}
+		return models.Tenant{}, fmt.Errorf("ошибка при получении арендатора: %w", err)
+	}
+	return t, nil
+}
+
+var _ pg_repo.TenantRepository = (*SQLiteTenantRepository)(nil)
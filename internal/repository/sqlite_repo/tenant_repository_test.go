@@ -0,0 +1,68 @@
+package sqlite_repo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	catalog_errors "music_catalog/internal/errors"
+	"music_catalog/internal/models"
+)
+
+func TestSQLiteTenantsAndAPIKeys(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+	tenants := NewSQLiteTenantRepository(conn)
+	keys := NewSQLiteAPIKeyRepository(conn)
+
+	if _, err := tenants.GetTenantBySlug(ctx, "default"); err != nil {
+		t.Fatalf("GetTenantBySlug(default) error = %v", err)
+	}
+	acme, err := tenants.CreateTenant(ctx, models.Tenant{Slug: "acme", Name: "Acme Records"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acme.ID != 2 || acme.CreatedAt.IsZero() {
+		t.Fatalf("CreateTenant() = %+v, want ID 2 with created_at", acme)
+	}
+	if _, err := tenants.CreateTenant(ctx, models.Tenant{Slug: "acme", Name: "Again"}); !errors.Is(err, catalog_errors.ErrTenantExists) {
+		t.Fatalf("CreateTenant(duplicate) error = %v, want ErrTenantExists", err)
+	}
+	if _, err := tenants.GetTenantBySlug(ctx, "missing"); !errors.Is(err, catalog_errors.ErrTenantNotFound) {
+		t.Fatalf("GetTenantBySlug(missing) error = %v, want ErrTenantNotFound", err)
+	}
+
+	key, err := keys.CreateAPIKey(ctx, models.APIKey{Name: "ci", Role: "editor", Prefix: "mc_abc", KeyHash: "hash", TenantID: acme.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.Tenant != "acme" || key.CreatedAt.IsZero() {
+		t.Fatalf("CreateAPIKey() = %+v, want a key of tenant acme", key)
+	}
+	if _, err := keys.CreateAPIKey(ctx, models.APIKey{Name: "ops", Role: "admin", Prefix: "mc_def", KeyHash: "other"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := keys.TouchAPIKey(ctx, key.ID); err != nil {
+		t.Fatal(err)
+	}
+	found, err := keys.GetAPIKeyByHash(ctx, "hash")
+	if err != nil || found.ID != key.ID || found.LastUsedAt == nil {
+		t.Fatalf("GetAPIKeyByHash() = %+v, %v, want the touched key", found, err)
+	}
+	if list, _ := keys.GetAPIKeys(ctx, acme.ID, models.Pagination{Limit: 10}); len(list) != 1 {
+		t.Fatalf("GetAPIKeys(acme) = %+v, want one key", list)
+	}
+	if list, _ := keys.GetAPIKeys(ctx, 0, models.Pagination{Limit: 10}); len(list) != 2 {
+		t.Fatalf("GetAPIKeys(all) = %+v, want two keys", list)
+	}
+
+	if err := keys.RevokeAPIKey(ctx, 1, key.ID); !errors.Is(err, catalog_errors.ErrAPIKeyNotFound) {
+		t.Fatalf("RevokeAPIKey(other tenant) error = %v, want ErrAPIKeyNotFound", err)
+	}
+	if err := keys.RevokeAPIKey(ctx, acme.ID, key.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keys.GetAPIKeyByHash(ctx, "hash"); !errors.Is(err, catalog_errors.ErrAPIKeyNotFound) {
+		t.Fatalf("GetAPIKeyByHash(revoked) error = %v, want ErrAPIKeyNotFound", err)
+	}
+}
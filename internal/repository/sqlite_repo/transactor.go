@@ -0,0 +1,83 @@
+package sqlite_repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// executor — общий интерфейс *sql.DB и *sql.Tx для выполнения запросов
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+type txKey struct{}
+
+// sqliteTx — открытая транзакция и действия, которые выполняются после её фиксации
+// (так брокер узнаёт о событиях транзакции)
+type sqliteTx struct {
+	tx       *sql.Tx
+	onCommit []func()
+}
+
+// conn возвращает транзакцию из контекста, если она открыта через WithinTransaction, иначе пул соединений
+func conn(ctx context.Context, db *sql.DB) executor {
+	if tx, ok := ctx.Value(txKey{}).(*sqliteTx); ok {
+		return tx.tx
+	}
+	return db
+}
+
+// afterCommit выполняет fn после фиксации открытой транзакции или сразу, если транзакции нет
+func afterCommit(ctx context.Context, fn func()) {
+	if tx, ok := ctx.Value(txKey{}).(*sqliteTx); ok {
+		tx.onCommit = append(tx.onCommit, fn)
+		return
+	}
+	fn()
+}
+
+// SQLiteTransactor — выполнение нескольких операций репозиториев sqlite_repo в одной транзакции.
+// Транзакции сразу захватывают блокировку записи (см. db.NewSQLiteDB), поэтому пишущие транзакции
+// выполняются по одной, в том числе из разных процессов.
+type SQLiteTransactor struct {
+	db *sql.DB
+}
+
+// NewSQLiteTransactor — конструктор для SQLiteTransactor.
+func NewSQLiteTransactor(db *sql.DB) *SQLiteTransactor {
+	return &SQLiteTransactor{db: db}
+}
+
+// WithinTransaction выполняет fn в транзакции: репозитории, получившие контекст fn, пишут в неё же.
+// Вложенный вызов переиспользует уже открытую транзакцию.
+func (t *SQLiteTransactor) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return withinTransaction(ctx, t.db, fn)
+}
+
+func withinTransaction(ctx context.Context, db *sql.DB, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sqliteTx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("ошибка при открытии транзакции: %w", err)
+	}
+
+	current := &sqliteTx{tx: tx}
+	if err := fn(context.WithValue(ctx, txKey{}, current)); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("ошибка при фиксации транзакции: %w", err)
+	}
+	for _, commit := range current.onCommit {
+		commit()
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package sqlite_repo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"music_catalog/internal/events"
+	"music_catalog/internal/models"
+	"music_catalog/internal/tenant"
+)
+
+// Откат транзакции отменяет изменения песен вместе с событиями о них
+func TestSQLiteTransactorRollsBackSongsWithEvents(t *testing.T) {
+	ctx := context.Background()
+	conn := openTestDB(t)
+	songs := NewSQLiteSongRepository(conn)
+	journal := NewSQLiteEventRepository(conn)
+	transactor := NewSQLiteTransactor(conn)
+
+	kept, err := songs.AddSong(ctx, models.Song{Group: "Muse", Title: "Uprising", ReleaseDate: "2009-09-07"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	failure := errors.New("publish failed")
+	err = transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		id, err := songs.AddSong(ctx, models.Song{Group: "Muse", Title: "Resistance", ReleaseDate: "2009-09-07"})
+		if err != nil {
+			return err
+		}
+		if err := journal.Publish(ctx, events.Event{Type: events.SongCreated, SongID: id}); err != nil {
+			return err
+		}
+		if err := songs.DeleteSong(ctx, kept); err != nil {
+			return err
+		}
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("WithinTransaction() error = %v, want %v", err, failure)
+	}
+
+	all, err := songs.GetSongs(ctx, models.SongFilters{}, models.Pagination{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 1 || all[0].ID != kept {
+		t.Fatalf("songs after rollback = %+v, want only the original Uprising", all)
+	}
+	if last, _ := journal.GetLastEventID(ctx, tenant.DefaultID); last != 0 {
+		t.Fatalf("GetLastEventID() after rollback = %d, want 0", last)
+	}
+	select {
+	case <-journal.Changes():
+		t.Fatal("change notification after rollback")
+	default:
+	}
+
+	err = transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		return journal.Publish(ctx, events.Event{Type: events.SongDeleted, SongID: kept, Song: models.Song{ID: kept, Title: "Uprising"}})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	published, err := journal.GetEventsAfter(ctx, tenant.DefaultID, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(published) != 1 || published[0].SongID != kept || published[0].Song.Title != "Uprising" || published[0].OccurredAt.IsZero() {
+		t.Fatalf("GetEventsAfter() = %+v, want one event for song %d", published, kept)
+	}
+	select {
+	case <-journal.Changes():
+	default:
+		t.Fatal("no change notification after commit")
+	}
+}
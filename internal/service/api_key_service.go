@@ -0,0 +1,97 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"music_catalog/internal/auth"
+	catalog_errors "music_catalog/internal/errors"
+	"music_catalog/internal/logger"
+	"music_catalog/internal/models"
+	"music_catalog/internal/repository/pg_repo"
+	"music_catalog/internal/tenant"
+	"music_catalog/internal/validation"
+)
+
+// apiKeyService issues and revokes API keys. Admins bound to a tenant manage the keys of
+// their tenant only; platform admins manage all keys and may issue platform-level keys.
+type apiKeyService struct {
+	repo    pg_repo.APIKeyRepository
+	tenants tenant.Store
+	logger  logger.Logger
+}
+
+// NewAPIKeyService creates a new instance of the APIKeyService
+func NewAPIKeyService(repo pg_repo.APIKeyRepository, tenants tenant.Store, logger logger.Logger) *apiKeyService {
+	return &apiKeyService{repo: repo, tenants: tenants, logger: logger}
+}
+
+// CreateAPIKey issues a new key with the given role, bound to tenantSlug (empty for a platform-level
+// key). The returned key is the only place the plaintext value appears; only its hash is stored.
+func (s *apiKeyService) CreateAPIKey(ctx context.Context, name string, role string, tenantSlug string) (models.APIKey, error) {
+	v := validation.New()
+	v.Check("name", &name, validation.APIKeyName)
+	if _, err := auth.ParseRole(role); err != nil {
+		v.AddError("role", fmt.Sprintf("must be one of %s, %s, %s", auth.RoleViewer, auth.RoleEditor, auth.RoleAdmin))
+	}
+	if err := v.Err(); err != nil {
+		return models.APIKey{}, err
+	}
+
+	var tenantID int
+	if scope := s.scope(ctx); scope != 0 {
+		// Ключи администратора арендатора всегда привязаны к его арендатору
+		if t, _ := tenant.FromContext(ctx); tenantSlug != "" && tenantSlug != t.Slug {
+			return models.APIKey{}, fmt.Errorf("%w: keys can only be issued for tenant %s", catalog_errors.ErrForbidden, t.Slug)
+		}
+		tenantID = scope
+	} else if tenantSlug != "" {
+		t, err := s.tenants.GetTenantBySlug(ctx, tenantSlug)
+		if err != nil {
+			return models.APIKey{}, err
+		}
+		tenantID = t.ID
+	}
+
+	plaintext, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		return models.APIKey{}, fmt.Errorf("error generating api key: %w", err)
+	}
+
+	created, err := s.repo.CreateAPIKey(ctx, models.APIKey{Name: name, Role: role, Prefix: plaintext[:10], KeyHash: hash, TenantID: tenantID})
+	if err != nil {
+		s.logger.WithContext(ctx).Error("Error creating api key: ", err)
+		return models.APIKey{}, err
+	}
+	created.Key = plaintext
+
+	s.logger.WithContext(ctx).Info("API key created: ", created.ID, created.Name, created.Role, created.Tenant, "by", auth.Actor(ctx))
+	return created, nil
+}
+
+// GetAPIKeys lists issued keys, including revoked ones
+func (s *apiKeyService) GetAPIKeys(ctx context.Context, pagination models.Pagination) ([]models.APIKey, error) {
+	keys, err := s.repo.GetAPIKeys(ctx, s.scope(ctx), pagination)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("Error getting api keys: ", err)
+		return nil, err
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey revokes a key; requests with it are rejected from then on
+func (s *apiKeyService) RevokeAPIKey(ctx context.Context, id int) error {
+	if err := s.repo.RevokeAPIKey(ctx, s.scope(ctx), id); err != nil {
+		return err
+	}
+	s.logger.WithContext(ctx).Info("API key revoked: ", id, "by", auth.Actor(ctx))
+	return nil
+}
+
+// scope returns the tenant whose keys the caller manages, or 0 for a platform admin
+func (s *apiKeyService) scope(ctx context.Context) int {
+	if p, ok := auth.FromContext(ctx); ok && p.Tenant == "" {
+		return 0
+	}
+	return tenant.IDFromContext(ctx)
+}
@@ -0,0 +1,336 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"music_catalog/internal/auth"
+	catalog_errors "music_catalog/internal/errors"
+	"music_catalog/internal/logger"
+	"music_catalog/internal/models"
+	"music_catalog/internal/repository/pg_repo"
+	"music_catalog/internal/tenant"
+	"music_catalog/internal/validation"
+)
+
+// MaxPlaylistItems limits the number of tracks in a single playlist
+const MaxPlaylistItems = 1000
+
+// libraryService manages personal favorites and playlists of the authenticated user
+type libraryService struct {
+	repo       pg_repo.LibraryRepository
+	transactor pg_repo.Transactor
+	logger     logger.Logger
+}
+
+// NewLibraryService creates a new instance of the LibraryService
+func NewLibraryService(repo pg_repo.LibraryRepository, transactor pg_repo.Transactor, logger logger.Logger) *libraryService {
+	return &libraryService{repo: repo, transactor: transactor, logger: logger}
+}
+
+// CurrentUser returns the user behind the authenticated principal, creating it on first use
+func (s *libraryService) CurrentUser(ctx context.Context) (models.User, error) {
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return models.User{}, catalog_errors.ErrUnauthenticated
+	}
+	user, err := s.repo.EnsureUser(ctx, principal.ID, principal.Name)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("Error resolving user: ", err)
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+// GetFavorites returns the favorite songs of the current user, most recently added first
+func (s *libraryService) GetFavorites(ctx context.Context, pagination models.Pagination) ([]models.Favorite, error) {
+	user, err := s.CurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetFavorites(ctx, user.ID, pagination)
+}
+
+// AddFavorite adds a song to the favorites of the current user; adding it twice is a no-op
+func (s *libraryService) AddFavorite(ctx context.Context, songID int) error {
+	user, err := s.CurrentUser(ctx)
+	if err != nil {
+		return err
+	}
+	return s.repo.AddFavorite(ctx, user.ID, songID)
+}
+
+// RemoveFavorite removes a song from the favorites of the current user
+func (s *libraryService) RemoveFavorite(ctx context.Context, songID int) error {
+	user, err := s.CurrentUser(ctx)
+	if err != nil {
+		return err
+	}
+	return s.repo.RemoveFavorite(ctx, user.ID, songID)
+}
+
+// CreatePlaylist creates a playlist owned by the current user
+func (s *libraryService) CreatePlaylist(ctx context.Context, playlist models.Playlist) (models.Playlist, error) {
+	if err := validatePlaylist(&playlist); err != nil {
+		return models.Playlist{}, err
+	}
+	user, err := s.CurrentUser(ctx)
+	if err != nil {
+		return models.Playlist{}, err
+	}
+	playlist.OwnerID = user.ID
+	if playlist.Public {
+		if playlist.ShareToken, err = generateShareToken(); err != nil {
+			return models.Playlist{}, err
+		}
+	}
+
+	created, err := s.repo.CreatePlaylist(ctx, playlist)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("Error creating playlist: ", err)
+		return models.Playlist{}, err
+	}
+	s.logger.WithContext(ctx).Info("Playlist created: ", created.ID, "by", auth.Actor(ctx))
+	created.Items = []models.PlaylistItem{}
+	return created, nil
+}
+
+// GetPlaylists returns the playlists of the current user
+func (s *libraryService) GetPlaylists(ctx context.Context, pagination models.Pagination) ([]models.Playlist, error) {
+	user, err := s.CurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetPlaylists(ctx, user.ID, pagination)
+}
+
+// GetPlaylist returns a playlist with its tracks. Private playlists of other users are reported as not found.
+func (s *libraryService) GetPlaylist(ctx context.Context, id int) (models.Playlist, error) {
+	user, err := s.CurrentUser(ctx)
+	if err != nil {
+		return models.Playlist{}, err
+	}
+	playlist, err := s.repo.GetPlaylist(ctx, id)
+	if err != nil {
+		return models.Playlist{}, err
+	}
+	if playlist.OwnerID != user.ID {
+		if !playlist.Public {
+			return models.Playlist{}, catalog_errors.ErrPlaylistNotFound
+		}
+		playlist.ShareToken = ""
+	}
+	return s.withItems(ctx, playlist)
+}
+
+// GetSharedPlaylist returns a public playlist with its tracks by its share token; no authentication required
+func (s *libraryService) GetSharedPlaylist(ctx context.Context, token string) (models.Playlist, error) {
+	playlist, err := s.repo.GetPlaylistByShareToken(ctx, token)
+	if err != nil {
+		return models.Playlist{}, err
+	}
+	playlist.ShareToken = ""
+	// Ссылка работает независимо от арендатора запроса: треки читаются в арендаторе владельца
+	return s.withItems(tenant.WithTenant(ctx, models.Tenant{ID: playlist.TenantID}), playlist)
+}
+
+// UpdatePlaylist changes the name, description or visibility of a playlist of the current user.
+// Publishing a playlist for the first time issues its share token.
+func (s *libraryService) UpdatePlaylist(ctx context.Context, id int, patch models.PlaylistPatch) (models.Playlist, error) {
+	v := validation.New()
+	v.CheckOptional("name", patch.Name, validation.PlaylistName)
+	v.CheckOptional("description", patch.Description, validation.PlaylistDescription)
+	if err := v.Err(); err != nil {
+		return models.Playlist{}, err
+	}
+
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		playlist, err := s.ownPlaylist(ctx, id)
+		if err != nil {
+			return err
+		}
+		if patch.Name != nil {
+			playlist.Name = *patch.Name
+		}
+		if patch.Description != nil {
+			playlist.Description = *patch.Description
+		}
+		if patch.Public != nil {
+			playlist.Public = *patch.Public
+		}
+		if playlist.Public && playlist.ShareToken == "" {
+			if playlist.ShareToken, err = generateShareToken(); err != nil {
+				return err
+			}
+		}
+		return s.repo.UpdatePlaylist(ctx, playlist)
+	})
+	if err != nil {
+		return models.Playlist{}, err
+	}
+	s.logger.WithContext(ctx).Info("Playlist updated: ", id, "by", auth.Actor(ctx))
+	return s.GetPlaylist(ctx, id)
+}
+
+// DeletePlaylist deletes a playlist of the current user
+func (s *libraryService) DeletePlaylist(ctx context.Context, id int) error {
+	err := s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := s.ownPlaylist(ctx, id); err != nil {
+			return err
+		}
+		return s.repo.DeletePlaylist(ctx, id)
+	})
+	if err != nil {
+		return err
+	}
+	s.logger.WithContext(ctx).Info("Playlist deleted: ", id, "by", auth.Actor(ctx))
+	return nil
+}
+
+// AddPlaylistItem inserts a song at the given 1-based position; position 0 appends it to the end
+func (s *libraryService) AddPlaylistItem(ctx context.Context, playlistID int, songID int, position int) (models.Playlist, error) {
+	err := s.modifyItems(ctx, playlistID, func(ctx context.Context, count int) error {
+		if count >= MaxPlaylistItems {
+			return positionError("song_id", fmt.Sprintf("playlist cannot contain more than %d songs", MaxPlaylistItems))
+		}
+		if position == 0 {
+			position = count + 1
+		}
+		if position < 1 || position > count+1 {
+			return positionError("position", fmt.Sprintf("must be between 1 and %d", count+1))
+		}
+		_, err := s.repo.InsertPlaylistItem(ctx, playlistID, songID, position)
+		return err
+	})
+	if err != nil {
+		return models.Playlist{}, err
+	}
+	return s.GetPlaylist(ctx, playlistID)
+}
+
+// MovePlaylistItem moves a track to the given 1-based position
+func (s *libraryService) MovePlaylistItem(ctx context.Context, playlistID int, itemID int64, position int) (models.Playlist, error) {
+	err := s.modifyItems(ctx, playlistID, func(ctx context.Context, count int) error {
+		if position < 1 || position > count {
+			return positionError("position", fmt.Sprintf("must be between 1 and %d", max(count, 1)))
+		}
+		return s.repo.MovePlaylistItem(ctx, playlistID, itemID, position)
+	})
+	if err != nil {
+		return models.Playlist{}, err
+	}
+	return s.GetPlaylist(ctx, playlistID)
+}
+
+// RemovePlaylistItem removes a track from a playlist
+func (s *libraryService) RemovePlaylistItem(ctx context.Context, playlistID int, itemID int64) (models.Playlist, error) {
+	err := s.modifyItems(ctx, playlistID, func(ctx context.Context, count int) error {
+		return s.repo.DeletePlaylistItem(ctx, playlistID, itemID)
+	})
+	if err != nil {
+		return models.Playlist{}, err
+	}
+	return s.GetPlaylist(ctx, playlistID)
+}
+
+// ReorderPlaylistItems sets the order of all tracks at once; itemIDs must list every track exactly once
+func (s *libraryService) ReorderPlaylistItems(ctx context.Context, playlistID int, itemIDs []int64) (models.Playlist, error) {
+	err := s.modifyItems(ctx, playlistID, func(ctx context.Context, count int) error {
+		items, err := s.repo.GetPlaylistItems(ctx, playlistID)
+		if err != nil {
+			return err
+		}
+		pending := make(map[int64]bool, len(items))
+		for _, item := range items {
+			pending[item.ID] = true
+		}
+		for _, id := range itemIDs {
+			if !pending[id] {
+				return positionError("item_ids", fmt.Sprintf("item %d is unknown or listed twice", id))
+			}
+			delete(pending, id)
+		}
+		if len(pending) > 0 {
+			return positionError("item_ids", fmt.Sprintf("must list all %d items of the playlist", len(items)))
+		}
+		return s.repo.ReorderPlaylistItems(ctx, playlistID, itemIDs)
+	})
+	if err != nil {
+		return models.Playlist{}, err
+	}
+	return s.GetPlaylist(ctx, playlistID)
+}
+
+// modifyItems выполняет изменение треков плейлиста текущего пользователя в транзакции:
+// плейлист блокируется, позиции уплотняются, fn получает текущее число треков
+func (s *libraryService) modifyItems(ctx context.Context, playlistID int, fn func(ctx context.Context, count int) error) error {
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := s.repo.TouchPlaylist(ctx, playlistID); err != nil {
+			return err
+		}
+		if _, err := s.ownPlaylist(ctx, playlistID); err != nil {
+			return err
+		}
+		count, err := s.repo.CompactPlaylistItems(ctx, playlistID)
+		if err != nil {
+			return err
+		}
+		return fn(ctx, count)
+	})
+}
+
+// ownPlaylist возвращает плейлист, если он принадлежит текущему пользователю. Чужой публичный
+// плейлист можно только читать, чужой приватный для клиента не существует.
+func (s *libraryService) ownPlaylist(ctx context.Context, id int) (models.Playlist, error) {
+	user, err := s.CurrentUser(ctx)
+	if err != nil {
+		return models.Playlist{}, err
+	}
+	playlist, err := s.repo.GetPlaylist(ctx, id)
+	if err != nil {
+		return models.Playlist{}, err
+	}
+	if playlist.OwnerID != user.ID {
+		if playlist.Public {
+			return models.Playlist{}, fmt.Errorf("%w: playlist belongs to another user", catalog_errors.ErrForbidden)
+		}
+		return models.Playlist{}, catalog_errors.ErrPlaylistNotFound
+	}
+	return playlist, nil
+}
+
+// withItems дополняет плейлист треками
+func (s *libraryService) withItems(ctx context.Context, playlist models.Playlist) (models.Playlist, error) {
+	items, err := s.repo.GetPlaylistItems(ctx, playlist.ID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("Error getting playlist items: ", err)
+		return models.Playlist{}, err
+	}
+	playlist.Items = items
+	playlist.ItemCount = len(items)
+	return playlist, nil
+}
+
+// validatePlaylist проверяет и нормализует название и описание плейлиста
+func validatePlaylist(playlist *models.Playlist) error {
+	v := validation.New()
+	v.Check("name", &playlist.Name, validation.PlaylistName)
+	v.Check("description", &playlist.Description, validation.PlaylistDescription)
+	return v.Err()
+}
+
+// positionError — ошибка валидации позиции или состава треков
+func positionError(field string, message string) error {
+	return catalog_errors.NewValidationError(catalog_errors.FieldError{Field: field, Message: message})
+}
+
+// generateShareToken генерирует токен публичной ссылки на плейлист
+func generateShareToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("error generating share token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -3,62 +3,72 @@ package service
 import (
 	"context"
 	"fmt"
-	"log"
-	"time"
 
+	"music_catalog/internal/auth"
 	catalog_errors "music_catalog/internal/errors"
+	"music_catalog/internal/events"
 	"music_catalog/internal/logger"
 	"music_catalog/internal/models"
 	"music_catalog/internal/repository/external_api"
 	"music_catalog/internal/repository/pg_repo"
+	"music_catalog/internal/tracing"
+	"music_catalog/internal/validation"
 )
 
 // musicService is the implementation of the service layer
 type musicService struct {
-	repo      pg_repo.SongRepository
-	apiClient external_api.APIClient
-	logger    logger.Logger
+	repo       pg_repo.SongRepository
+	apiClient  external_api.APIClient
+	publisher  events.Publisher
+	transactor pg_repo.Transactor
+	logger     logger.Logger
 }
 
-// NewMusicService creates a new instance of the MusicService
-func NewMusicService(repo pg_repo.SongRepository, apiClient external_api.APIClient, logger logger.Logger) *musicService {
+// NewMusicService creates a new instance of the MusicService.
+// Changes to songs and the events describing them are written in one transaction,
+// so the event journal doubles as a transactional outbox.
+func NewMusicService(repo pg_repo.SongRepository, apiClient external_api.APIClient, publisher events.Publisher, transactor pg_repo.Transactor, logger logger.Logger) *musicService {
 	return &musicService{
-		repo:      repo,
-		apiClient: apiClient,
-		logger:    logger,
+		repo:       repo,
+		apiClient:  apiClient,
+		publisher:  publisher,
+		transactor: transactor,
+		logger:     logger,
 	}
 }
 
-// AddSong adds a new song to the library and fetches additional song details
-func (s *musicService) AddSong(ctx context.Context, group string, title string) error {
+// AddSong adds a new song to the library, fetches additional song details and returns the stored song
+func (s *musicService) AddSong(ctx context.Context, group string, title string) (models.Song, error) {
+	ctx, span := tracing.Start(ctx, "musicService.AddSong")
+	defer span.End()
 
 	// Fetch song details from external API
-	songDetail, err := s.apiClient.FetchSongDetails(group, title)
+	songDetail, err := s.apiClient.FetchSongDetails(ctx, group, title)
 	if err != nil {
-		log.Printf("[ERROR] Error fetching song details from external API: %v", err)
-		return fmt.Errorf("error fetching song details: %w", err)
+		s.logger.WithContext(ctx).Error("Error fetching song details from external API: ", err)
+		return models.Song{}, fmt.Errorf("%w: error fetching song details: %v", catalog_errors.ErrUpstream, err)
 	}
 
-	s.logger.Info("Song found in external API")
-	s.logger.Debug(fmt.Sprintf("Song details from external API: %+v", *songDetail))
+	s.logger.WithContext(ctx).Info("Song found in external API")
+	s.logger.WithContext(ctx).Debug(fmt.Sprintf("Song details from external API: %+v", *songDetail))
 
 	song, err := s.repo.GetSong(ctx, group, title)
 	if err != nil {
-		s.logger.Error("Error getting song from repository: ", err)
-		return err
+		s.logger.WithContext(ctx).Error("Error getting song from repository: ", err)
+		return models.Song{}, err
 	}
 
 	if song.Title != "" {
 		// Песня найдена
-		s.logger.Info("Song found in library: ", song.Title)
-		return catalog_errors.ErrSongExists
+		s.logger.WithContext(ctx).Info("Song found in library: ", song.Title)
+		return models.Song{}, catalog_errors.ErrSongExists
 	}
 
 	// Parse release date
-	releaseDate, err := ParseDate(songDetail.ReleaseDate)
+	releaseDate, err := validation.ParseDate(songDetail.ReleaseDate)
 	if err != nil {
-		s.logger.Error("Error parsing release date: ", err)
-		return fmt.Errorf("error parsing release date: %w", err)
+		s.logger.WithContext(ctx).Error("Error parsing release date: ", err)
+		return models.Song{}, fmt.Errorf("%w: error parsing release date: %v", catalog_errors.ErrUpstream, err)
 	}
 	songDetail.ReleaseDate = releaseDate.Format("2006-01-02")
 
@@ -71,72 +81,303 @@ func (s *musicService) AddSong(ctx context.Context, group string, title string)
 		Link:        songDetail.Link,
 	}
 
-	// Save to repository
-	if _, err := s.repo.AddSong(ctx, newSong); err != nil {
-		s.logger.Error("Error saving song: ", err)
-		return fmt.Errorf("error saving song: %w", err)
+	// Save to repository together with the song.created event
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		id, err := s.repo.AddSong(ctx, newSong)
+		if err != nil {
+			return err
+		}
+		newSong.ID = id
+		return s.publish(ctx, events.SongCreated, newSong)
+	})
+	if err != nil {
+		s.logger.WithContext(ctx).Error("Error saving song: ", err)
+		return models.Song{}, fmt.Errorf("error saving song: %w", err)
 	}
 
-	return nil
+	return newSong, nil
+}
+
+// GetSong retrieves a single song by its ID
+func (s *musicService) GetSong(ctx context.Context, songID int) (models.Song, error) {
+	ctx, span := tracing.Start(ctx, "musicService.GetSong")
+	defer span.End()
+
+	song, err := s.repo.GetSongByID(ctx, songID)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("Error getting song from repository: ", err)
+		return models.Song{}, err
+	}
+	if song.ID == 0 {
+		return models.Song{}, catalog_errors.ErrSongNotFound
+	}
+	return song, nil
 }
 
 // GetSongs retrieves songs with optional filtering and pagination
 func (s *musicService) GetSongs(ctx context.Context, filters models.SongFilters, pagination models.Pagination) ([]models.Song, error) {
+	ctx, span := tracing.Start(ctx, "musicService.GetSongs")
+	defer span.End()
 
 	if filters.ReleaseDate != "" {
-		releaseDate, err := ParseDate(filters.ReleaseDate)
+		releaseDate, err := validation.ParseDate(filters.ReleaseDate)
 		if err != nil {
-			s.logger.Error("Error parsing release date: ", err)
-			return []models.Song{}, fmt.Errorf("error parsing release date: %w", err)
+			s.logger.WithContext(ctx).Error("Error parsing release date: ", err)
+			return []models.Song{}, catalog_errors.NewValidationError(catalog_errors.FieldError{Field: "release_date", Message: err.Error()})
 		}
 		filters.ReleaseDate = releaseDate.Format("2006-01-02")
 	}
 	return s.repo.GetSongs(ctx, filters, pagination)
 }
 
+// GetSongsByIDs retrieves songs by a list of IDs in a single repository call
+func (s *musicService) GetSongsByIDs(ctx context.Context, ids []int) ([]models.Song, error) {
+	ctx, span := tracing.Start(ctx, "musicService.GetSongsByIDs")
+	defer span.End()
+	return s.repo.GetSongsByIDs(ctx, ids)
+}
+
+// GetSongsByGroups retrieves all songs of the given groups in a single repository call
+func (s *musicService) GetSongsByGroups(ctx context.Context, groups []string) ([]models.Song, error) {
+	ctx, span := tracing.Start(ctx, "musicService.GetSongsByGroups")
+	defer span.End()
+	return s.repo.GetSongsByGroups(ctx, groups)
+}
+
+// GetArtists retrieves group names matching the name filter with pagination
+func (s *musicService) GetArtists(ctx context.Context, name string, pagination models.Pagination) ([]string, error) {
+	ctx, span := tracing.Start(ctx, "musicService.GetArtists")
+	defer span.End()
+	return s.repo.GetGroups(ctx, name, pagination)
+}
+
+// SearchLyrics finds songs whose lyrics contain every word of the query, most relevant first
+func (s *musicService) SearchLyrics(ctx context.Context, query string, pagination models.Pagination) ([]models.Song, error) {
+	ctx, span := tracing.Start(ctx, "musicService.SearchLyrics")
+	defer span.End()
+	return s.repo.SearchLyrics(ctx, query, pagination)
+}
+
 // GetSongText retrieves song text with pagination (verse by verse)
 func (s *musicService) GetSongText(ctx context.Context, songID int, page int) (string, error) {
+	ctx, span := tracing.Start(ctx, "musicService.GetSongText")
+	defer span.End()
 	return s.repo.GetSongText(ctx, songID, page)
 }
 
-// UpdateSong updates the details of an existing song
+// UpdateSong updates the details of an existing song; a missing song is reported as ErrSongNotFound
 func (s *musicService) UpdateSong(ctx context.Context, song models.Song) error {
-	releaseDate, err := ParseDate(song.ReleaseDate)
+	ctx, span := tracing.Start(ctx, "musicService.UpdateSong")
+	defer span.End()
+
+	releaseDate, err := validation.ParseDate(song.ReleaseDate)
 	if err != nil {
-		s.logger.Error("Error parsing release date: ", err)
-		return fmt.Errorf("error parsing release date: %w", err)
+		s.logger.WithContext(ctx).Error("Error parsing release date: ", err)
+		return catalog_errors.NewValidationError(catalog_errors.FieldError{Field: "release_date", Message: err.Error()})
 	}
 	song.ReleaseDate = releaseDate.Format("2006-01-02")
-	return s.repo.UpdateSong(ctx, song)
+
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		existing, err := s.repo.GetSongByID(ctx, song.ID)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("Error getting song from repository: ", err)
+			return err
+		}
+		if existing.ID == 0 {
+			return catalog_errors.ErrSongNotFound
+		}
+
+		if err := s.repo.UpdateSong(ctx, song); err != nil {
+			return err
+		}
+		return s.publish(ctx, events.SongUpdated, song)
+	})
 }
 
-// DeleteSong deletes a song from the library
+// PatchSong applies a partial update to an existing song; a missing song is reported as ErrSongNotFound.
+// The song is read, merged and written in one transaction with its row locked, so concurrent patches
+// of different fields do not overwrite each other.
+func (s *musicService) PatchSong(ctx context.Context, songID int, patch models.SongPatch) error {
+	ctx, span := tracing.Start(ctx, "musicService.PatchSong")
+	defer span.End()
+
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		song, err := s.repo.LockSongByID(ctx, songID)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("Error getting song from repository: ", err)
+			return err
+		}
+		if song.ID == 0 {
+			return catalog_errors.ErrSongNotFound
+		}
+
+		// Переносим в песню только переданные поля
+		for _, field := range []struct {
+			dst *string
+			src *string
+		}{
+			{&song.Group, patch.Group},
+			{&song.Title, patch.Title},
+			{&song.Text, patch.Text},
+			{&song.Link, patch.Link},
+			{&song.ReleaseDate, patch.ReleaseDate},
+		} {
+			if field.src != nil {
+				*field.dst = *field.src
+			}
+		}
+
+		releaseDate, err := validation.ParseDate(song.ReleaseDate)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("Error parsing release date: ", err)
+			return catalog_errors.NewValidationError(catalog_errors.FieldError{Field: "release_date", Message: err.Error()})
+		}
+		song.ReleaseDate = releaseDate.Format("2006-01-02")
+
+		if err := s.repo.UpdateSong(ctx, song); err != nil {
+			return err
+		}
+		return s.publish(ctx, events.SongUpdated, song)
+	})
+}
+
+// DeleteSong deletes a song from the library; a missing song is reported as ErrSongNotFound
 func (s *musicService) DeleteSong(ctx context.Context, songID int) error {
-	return s.repo.DeleteSong(ctx, songID)
+	ctx, span := tracing.Start(ctx, "musicService.DeleteSong")
+	defer span.End()
+	return s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		existing, err := s.repo.GetSongByID(ctx, songID)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("Error getting song from repository: ", err)
+			return err
+		}
+		if existing.ID == 0 {
+			return catalog_errors.ErrSongNotFound
+		}
+
+		if err := s.repo.DeleteSong(ctx, songID); err != nil {
+			return err
+		}
+		return s.publish(ctx, events.SongDeleted, existing)
+	})
 }
 
-// Список возможных форматов дат
-var dateFormats = []string{
-	"02.01.2006",      // Формат "DD.MM.YYYY"
-	"2006-01-02",      // Формат "YYYY-MM-DD"
-	"January 2, 2006", // Формат "January 2, 2006"
-	time.RFC3339,      // ISO 8601 формат (например, "2006-01-02T15:04:05Z07:00")
+// ImportResult describes what ImportSong did with a song
+type ImportResult string
+
+const (
+	ImportCreated ImportResult = "created" // песня добавлена
+	ImportUpdated ImportResult = "updated" // существующая песня перезаписана
+	ImportSkipped ImportResult = "skipped" // песня уже есть и не перезаписывается или не изменилась
+)
+
+// ImportSong stores a song as given, without consulting the external API: used to seed and import
+// catalogs. A song with the same group and title is overwritten only when overwrite is set.
+// Changes are published like any other catalog change.
+func (s *musicService) ImportSong(ctx context.Context, song models.Song, overwrite bool) (ImportResult, error) {
+	ctx, span := tracing.Start(ctx, "musicService.ImportSong")
+	defer span.End()
+
+	releaseDate, err := validation.ParseDate(song.ReleaseDate)
+	if err != nil {
+		return "", catalog_errors.NewValidationError(catalog_errors.FieldError{Field: "release_date", Message: err.Error()})
+	}
+	song.ReleaseDate = releaseDate.Format("2006-01-02")
+
+	var result ImportResult
+	err = s.transactor.WithinTransaction(ctx, func(ctx context.Context) error {
+		existing, err := s.repo.GetSong(ctx, song.Group, song.Title)
+		if err != nil {
+			return err
+		}
+		switch {
+		case existing.ID == 0:
+			id, err := s.repo.AddSong(ctx, song)
+			if err != nil {
+				return err
+			}
+			song.ID = id
+			result = ImportCreated
+			return s.publish(ctx, events.SongCreated, song)
+		case !overwrite:
+			result = ImportSkipped
+			return nil
+		}
+
+		song.ID = existing.ID
+		existing.ReleaseDate = validation.NormalizeDate(existing.ReleaseDate)
+		if song == existing {
+			result = ImportSkipped
+			return nil
+		}
+		if err := s.repo.UpdateSong(ctx, song); err != nil {
+			return err
+		}
+		result = ImportUpdated
+		return s.publish(ctx, events.SongUpdated, song)
+	})
+	if err != nil {
+		s.logger.WithContext(ctx).Error("Error importing song: ", err)
+		return "", err
+	}
+	return result, nil
 }
 
-// ParseDate пытается разобрать дату в одном из поддерживаемых форматов
-func ParseDate(dateStr string) (time.Time, error) {
-	var parsedDate time.Time
-	var err error
+// EnrichSong fetches the song details from the external API again. With onlyMissing set only
+// the empty text and link are filled in; otherwise text, link and release date are replaced.
+// Reports whether the song changed.
+func (s *musicService) EnrichSong(ctx context.Context, songID int, onlyMissing bool) (bool, error) {
+	ctx, span := tracing.Start(ctx, "musicService.EnrichSong")
+	defer span.End()
+
+	song, err := s.GetSong(ctx, songID)
+	if err != nil {
+		return false, err
+	}
+	song.ReleaseDate = validation.NormalizeDate(song.ReleaseDate)
+
+	songDetail, err := s.apiClient.FetchSongDetails(ctx, song.Group, song.Title)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("Error fetching song details from external API: ", err)
+		return false, fmt.Errorf("%w: error fetching song details: %v", catalog_errors.ErrUpstream, err)
+	}
 
-	// Перебор всех возможных форматов
-	for _, layout := range dateFormats {
-		parsedDate, err = time.Parse(layout, dateStr)
-		if err == nil {
-			// Если дата успешно разобрана в одном из форматов — возвращаем её
-			return parsedDate, nil
+	enriched := song
+	for _, field := range []struct {
+		dst     *string
+		src     string
+		missing bool // поле заполняется в режиме onlyMissing
+	}{
+		{&enriched.Text, songDetail.Text, true},
+		{&enriched.Link, songDetail.Link, true},
+		{&enriched.ReleaseDate, validation.NormalizeDate(songDetail.ReleaseDate), false},
+	} {
+		if field.src == "" {
+			continue
+		}
+		if !onlyMissing || (field.missing && *field.dst == "") {
+			*field.dst = field.src
 		}
 	}
+	if enriched == song {
+		return false, nil
+	}
+	if err := s.UpdateSong(ctx, enriched); err != nil {
+		return false, err
+	}
+	return true, nil
+}
 
-	// Если ни один формат не подошел, возвращаем ошибку
-	return time.Time{}, fmt.Errorf("не удалось распознать формат даты: %s", dateStr)
+// publish записывает событие изменения каталога в журнал (в транзакции изменения, если она открыта)
+// и фиксирует в логе, от чьего имени сделано изменение. Журнал PostgreSQL держит блокировку арендатора
+// от записи события до фиксации, поэтому publish — последний шаг транзакции, а внешний API вызывается
+// до её начала.
+func (s *musicService) publish(ctx context.Context, eventType string, song models.Song) error {
+	event := events.Event{Type: eventType, SongID: song.ID, Song: song}
+	if err := s.publisher.Publish(ctx, event); err != nil {
+		s.logger.WithContext(ctx).Error("Error publishing event: ", eventType, song.ID, err)
+		return err
+	}
+	s.logger.WithContext(ctx).Info("Catalog change: ", eventType, song.ID, "by", auth.Actor(ctx))
+	return nil
 }
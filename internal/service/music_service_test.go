@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"music_catalog/internal/logger"
+	"music_catalog/internal/models"
+	"music_catalog/internal/repository/memory_repo"
+)
+
+// Параллельные PATCH разных полей одной песни не затирают друг друга
+func TestPatchSongConcurrentFields(t *testing.T) {
+	ctx := context.Background()
+	repo := memory_repo.NewMemorySongRepository()
+	s := NewMusicService(repo, nil, memory_repo.NewMemoryEventRepository(), memory_repo.NewMemoryTransactor(), logger.NewLogger("error"))
+
+	id, err := repo.AddSong(ctx, models.Song{Group: "Muse", Title: "Uprising", Text: "verse", ReleaseDate: "2009-09-07"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const rounds = 50
+	for i := 0; i < rounds; i++ {
+		title, link := fmt.Sprintf("Uprising %d", i), fmt.Sprintf("https://example.com/%d", i)
+		var wg sync.WaitGroup
+		errs := make([]error, 2)
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			errs[0] = s.PatchSong(ctx, id, models.SongPatch{Title: &title})
+		}()
+		go func() {
+			defer wg.Done()
+			errs[1] = s.PatchSong(ctx, id, models.SongPatch{Link: &link})
+		}()
+		wg.Wait()
+		for _, err := range errs {
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		song, err := repo.GetSongByID(ctx, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if song.Title != title || song.Link != link {
+			t.Fatalf("round %d: song = %q, %q; want %q, %q", i, song.Title, song.Link, title, link)
+		}
+	}
+}
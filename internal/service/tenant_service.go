@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+
+	"music_catalog/internal/auth"
+	"music_catalog/internal/logger"
+	"music_catalog/internal/models"
+	"music_catalog/internal/repository/pg_repo"
+	"music_catalog/internal/validation"
+)
+
+// tenantService manages tenants; only platform admins (not bound to a tenant) may use it
+type tenantService struct {
+	repo   pg_repo.TenantRepository
+	logger logger.Logger
+}
+
+// NewTenantService creates a new instance of the TenantService
+func NewTenantService(repo pg_repo.TenantRepository, logger logger.Logger) *tenantService {
+	return &tenantService{repo: repo, logger: logger}
+}
+
+// CreateTenant registers a new tenant with an empty catalog
+func (s *tenantService) CreateTenant(ctx context.Context, tenant models.Tenant) (models.Tenant, error) {
+	if err := auth.AuthorizePlatformAdmin(ctx); err != nil {
+		return models.Tenant{}, err
+	}
+	v := validation.New()
+	v.Check("slug", &tenant.Slug, validation.TenantSlug)
+	v.Check("name", &tenant.Name, validation.TenantName)
+	if err := v.Err(); err != nil {
+		return models.Tenant{}, err
+	}
+
+	created, err := s.repo.CreateTenant(ctx, tenant)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("Error creating tenant: ", err)
+		return models.Tenant{}, err
+	}
+	s.logger.WithContext(ctx).Info("Tenant created: ", created.ID, created.Slug, "by", auth.Actor(ctx))
+	return created, nil
+}
+
+// GetTenants lists all tenants
+func (s *tenantService) GetTenants(ctx context.Context, pagination models.Pagination) ([]models.Tenant, error) {
+	if err := auth.AuthorizePlatformAdmin(ctx); err != nil {
+		return nil, err
+	}
+	tenants, err := s.repo.GetTenants(ctx, pagination)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("Error getting tenants: ", err)
+		return nil, err
+	}
+	return tenants, nil
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"music_catalog/internal/auth"
+	"music_catalog/internal/events"
+	"music_catalog/internal/logger"
+	"music_catalog/internal/models"
+	"music_catalog/internal/repository/pg_repo"
+	"music_catalog/internal/validation"
+)
+
+// webhookService manages webhook subscriptions and exposes their delivery log
+type webhookService struct {
+	repo   pg_repo.WebhookRepository
+	logger logger.Logger
+}
+
+// NewWebhookService creates a new instance of the WebhookService
+func NewWebhookService(repo pg_repo.WebhookRepository, logger logger.Logger) *webhookService {
+	return &webhookService{repo: repo, logger: logger}
+}
+
+// CreateWebhook validates and stores a subscription. A signing secret is generated when none is given;
+// the created webhook is the only response that contains the secret.
+func (s *webhookService) CreateWebhook(ctx context.Context, webhook models.Webhook) (models.Webhook, error) {
+	if err := validateWebhook(&webhook); err != nil {
+		return models.Webhook{}, err
+	}
+	if webhook.Secret == "" {
+		secret, err := generateSecret()
+		if err != nil {
+			return models.Webhook{}, fmt.Errorf("error generating webhook secret: %w", err)
+		}
+		webhook.Secret = secret
+	}
+
+	created, err := s.repo.CreateWebhook(ctx, webhook)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("Error creating webhook: ", err)
+		return models.Webhook{}, err
+	}
+	s.logger.WithContext(ctx).Info("Webhook created: ", created.ID, created.TargetURL, "by", auth.Actor(ctx))
+	return created, nil
+}
+
+// GetWebhooks returns subscriptions without their secrets
+func (s *webhookService) GetWebhooks(ctx context.Context, pagination models.Pagination) ([]models.Webhook, error) {
+	webhooks, err := s.repo.GetWebhooks(ctx, pagination)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("Error getting webhooks: ", err)
+		return nil, err
+	}
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+	return webhooks, nil
+}
+
+// GetWebhook returns a subscription without its secret
+func (s *webhookService) GetWebhook(ctx context.Context, id int) (models.Webhook, error) {
+	webhook, err := s.repo.GetWebhookByID(ctx, id)
+	if err != nil {
+		return models.Webhook{}, err
+	}
+	webhook.Secret = ""
+	return webhook, nil
+}
+
+// UpdateWebhook replaces a subscription; an empty secret keeps the current one
+func (s *webhookService) UpdateWebhook(ctx context.Context, webhook models.Webhook) (models.Webhook, error) {
+	if err := validateWebhook(&webhook); err != nil {
+		return models.Webhook{}, err
+	}
+	updated, err := s.repo.UpdateWebhook(ctx, webhook)
+	if err != nil {
+		return models.Webhook{}, err
+	}
+	s.logger.WithContext(ctx).Info("Webhook updated: ", updated.ID, updated.TargetURL, "by", auth.Actor(ctx))
+	updated.Secret = ""
+	return updated, nil
+}
+
+// DeleteWebhook removes a subscription together with its deliveries
+func (s *webhookService) DeleteWebhook(ctx context.Context, id int) error {
+	if err := s.repo.DeleteWebhook(ctx, id); err != nil {
+		return err
+	}
+	s.logger.WithContext(ctx).Info("Webhook deleted: ", id, "by", auth.Actor(ctx))
+	return nil
+}
+
+// GetDeliveries returns the delivery log of a subscription, optionally filtered by status
+func (s *webhookService) GetDeliveries(ctx context.Context, webhookID int, status string, pagination models.Pagination) ([]models.WebhookDelivery, error) {
+	if _, err := s.repo.GetWebhookByID(ctx, webhookID); err != nil {
+		return nil, err
+	}
+	switch status {
+	case "", models.DeliveryPending, models.DeliveryDelivered, models.DeliveryDead:
+	default:
+		v := validation.New()
+		v.AddError("status", fmt.Sprintf("must be one of %s, %s, %s", models.DeliveryPending, models.DeliveryDelivered, models.DeliveryDead))
+		return nil, v.Err()
+	}
+	return s.repo.GetDeliveries(ctx, webhookID, status, pagination)
+}
+
+// RetryDelivery puts a failed or dead-lettered delivery back into the queue
+func (s *webhookService) RetryDelivery(ctx context.Context, webhookID int, deliveryID int64) error {
+	if err := s.repo.RequeueDelivery(ctx, webhookID, deliveryID); err != nil {
+		return err
+	}
+	s.logger.WithContext(ctx).Info("Webhook delivery requeued: ", webhookID, deliveryID, "by", auth.Actor(ctx))
+	return nil
+}
+
+// validateWebhook проверяет адрес получателя и типы событий подписки
+func validateWebhook(webhook *models.Webhook) error {
+	v := validation.New()
+	v.Check("target_url", &webhook.TargetURL, validation.WebhookTargetURL)
+	for _, t := range webhook.EventTypes {
+		switch t {
+		case events.SongCreated, events.SongUpdated, events.SongDeleted:
+		default:
+			v.AddError("event_types", fmt.Sprintf("unknown event type %q", t))
+		}
+	}
+	if webhook.Secret != "" && len(webhook.Secret) < 16 {
+		v.AddError("secret", "must be at least 16 characters long")
+	}
+	if webhook.EventTypes == nil {
+		webhook.EventTypes = []string{}
+	}
+	return v.Err()
+}
+
+// generateSecret генерирует случайный секрет подписи
+func generateSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
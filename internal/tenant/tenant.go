@@ -0,0 +1,96 @@
+// Package tenant resolves the tenant (label catalog) a request operates on
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"music_catalog/internal/auth"
+	catalog_errors "music_catalog/internal/errors"
+	"music_catalog/internal/models"
+)
+
+const (
+	// DefaultSlug — арендатор, к которому относятся запросы без явно указанного арендатора
+	DefaultSlug = "default"
+	// DefaultID — ID арендатора по умолчанию (создаётся миграцией 000006)
+	DefaultID = 1
+	// Header — заголовок, которым клиент уровня платформы выбирает арендатора
+	Header = "X-Tenant-ID"
+)
+
+type tenantKey struct{}
+
+// WithTenant кладёт арендатора в контекст запроса
+func WithTenant(ctx context.Context, t models.Tenant) context.Context {
+	return context.WithValue(ctx, tenantKey{}, t)
+}
+
+// FromContext возвращает арендатора из контекста
+func FromContext(ctx context.Context) (models.Tenant, bool) {
+	t, ok := ctx.Value(tenantKey{}).(models.Tenant)
+	return t, ok
+}
+
+// IDFromContext возвращает ID арендатора из контекста; запросы без арендатора (фоновые задачи, CLI)
+// относятся к арендатору по умолчанию
+func IDFromContext(ctx context.Context) int {
+	if t, ok := FromContext(ctx); ok {
+		return t.ID
+	}
+	return DefaultID
+}
+
+// Store — справочник арендаторов
+type Store interface {
+	GetTenantBySlug(ctx context.Context, slug string) (models.Tenant, error)
+}
+
+// Resolver определяет арендатора запроса. Клиент, привязанный к арендатору (claim токена
+// или арендатор API-ключа), работает только с ним; клиент уровня платформы и анонимный клиент
+// выбирают арендатора заголовком X-Tenant-ID, по умолчанию — DefaultSlug.
+type Resolver struct {
+	store Store
+	mu    sync.RWMutex
+	cache map[string]models.Tenant // арендаторы не удаляются и не переименовываются, поэтому кэш не устаревает
+}
+
+// NewResolver creates a new Resolver
+func NewResolver(store Store) *Resolver {
+	return &Resolver{store: store, cache: map[string]models.Tenant{}}
+}
+
+// Resolve возвращает арендатора для клиента из контекста и запрошенного заголовком slug
+func (r *Resolver) Resolve(ctx context.Context, requested string) (models.Tenant, error) {
+	slug := requested
+	if principal, ok := auth.FromContext(ctx); ok && principal.Tenant != "" {
+		if requested != "" && requested != principal.Tenant {
+			return models.Tenant{}, fmt.Errorf("%w: credentials are bound to tenant %q", catalog_errors.ErrForbidden, principal.Tenant)
+		}
+		slug = principal.Tenant
+	}
+	if slug == "" {
+		slug = DefaultSlug
+	}
+	return r.Lookup(ctx, slug)
+}
+
+// Lookup возвращает арендатора по slug
+func (r *Resolver) Lookup(ctx context.Context, slug string) (models.Tenant, error) {
+	r.mu.RLock()
+	t, ok := r.cache[slug]
+	r.mu.RUnlock()
+	if ok {
+		return t, nil
+	}
+
+	t, err := r.store.GetTenantBySlug(ctx, slug)
+	if err != nil {
+		return models.Tenant{}, err
+	}
+	r.mu.Lock()
+	r.cache[slug] = t
+	r.mu.Unlock()
+	return t, nil
+}
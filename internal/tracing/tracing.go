@@ -0,0 +1,120 @@
+// Package tracing configures OpenTelemetry tracing and provides helpers for creating spans
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName — имя трейсера, которым создаются спаны сервиса
+const instrumentationName = "music_catalog"
+
+// Экспортёры спанов
+const (
+	ExporterNone   = "none"   // спаны создаются (trace ID есть в ответах), но никуда не отправляются
+	ExporterStdout = "stdout" // JSON в стандартный вывод — для локальной отладки без коллектора
+	ExporterFile   = "file"   // JSON в файл
+	ExporterOTLP   = "otlp"   // OTLP/HTTP; адрес и заголовки — из стандартных переменных OTEL_EXPORTER_OTLP_*
+)
+
+// Config — настройки трассировки
+type Config struct {
+	Exporter    string
+	File        string  // путь для ExporterFile
+	ServiceName string  // service.name в ресурсе
+	SampleRatio float64 // доля трассировок, начатых этим сервисом; решение вызывающего сервиса соблюдается
+}
+
+// Setup настраивает глобальный TracerProvider и распространение контекста W3C Trace Context.
+// Возвращённая функция отправляет накопленные спаны и освобождает экспортёр.
+func Setup(ctx context.Context, config Config) (func(ctx context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	// OTEL_SERVICE_NAME и OTEL_RESOURCE_ATTRIBUTES переопределяют имя сервиса по умолчанию
+	res, err := resource.New(ctx,
+		resource.WithAttributes(attribute.String("service.name", config.ServiceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("tracing resource: %w", err)
+	}
+	options := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(config.SampleRatio))),
+	}
+
+	var file io.Closer
+	switch config.Exporter {
+	case ExporterNone:
+	case ExporterStdout:
+		exporter, err := stdouttrace.New()
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, sdktrace.WithBatcher(exporter))
+	case ExporterFile:
+		f, err := os.OpenFile(config.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("tracing file: %w", err)
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		file = f
+		options = append(options, sdktrace.WithBatcher(exporter))
+	case ExporterOTLP:
+		exporter, err := otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("otlp exporter: %w", err)
+		}
+		options = append(options, sdktrace.WithBatcher(exporter))
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", config.Exporter)
+	}
+
+	provider := sdktrace.NewTracerProvider(options...)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if file != nil {
+			file.Close()
+		}
+		return err
+	}, nil
+}
+
+// Start начинает дочерний спан с указанным именем
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentationName).Start(ctx, name, opts...)
+}
+
+// Fail отмечает спан как завершившийся ошибкой
+func Fail(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// TraceID возвращает идентификатор трассировки из контекста или пустую строку
+func TraceID(ctx context.Context) string {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.HasTraceID() {
+		return ""
+	}
+	return sc.TraceID().String()
+}
@@ -0,0 +1,7 @@
+package validation
+
+// APIKeyName — правила для названия API-ключа
+var APIKeyName = FieldSpec{
+	Normalize: NormalizeLine,
+	Rules:     []Rule{Required(), MaxLength(songFieldMaxLength)},
+}
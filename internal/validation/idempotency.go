@@ -0,0 +1,7 @@
+package validation
+
+// IdempotencyKey — правила для заголовка Idempotency-Key
+var IdempotencyKey = FieldSpec{
+	Normalize: NormalizeLine,
+	Rules:     []Rule{Required(), MaxLength(songFieldMaxLength)},
+}
@@ -0,0 +1,16 @@
+package validation
+
+// playlistDescriptionMaxLength — ограничение длины описания плейлиста
+const playlistDescriptionMaxLength = 2000
+
+// Правила для полей плейлиста
+var (
+	PlaylistName = FieldSpec{
+		Normalize: NormalizeLine,
+		Rules:     []Rule{Required(), MaxLength(songFieldMaxLength)},
+	}
+	PlaylistDescription = FieldSpec{
+		Normalize: NormalizeText,
+		Rules:     []Rule{MaxLength(playlistDescriptionMaxLength)},
+	}
+)
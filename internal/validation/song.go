@@ -0,0 +1,56 @@
+package validation
+
+import "time"
+
+// songFieldMaxLength соответствует VARCHAR(255) в таблице songs
+const songFieldMaxLength = 255
+
+// earliestReleaseDate — нижняя граница правдоподобной даты выпуска
+var earliestReleaseDate = time.Date(1860, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// latestReleaseDate — верхняя граница: релизы, анонсированные не более чем на год вперёд
+func latestReleaseDate() time.Time {
+	return time.Now().UTC().AddDate(1, 0, 0)
+}
+
+// Правила для полей песни, общие для создания, полного и частичного обновления
+var (
+	SongGroup = FieldSpec{
+		Normalize: NormalizeLine,
+		Rules:     []Rule{Required(), MaxLength(songFieldMaxLength)},
+	}
+	SongTitle = FieldSpec{
+		Normalize: NormalizeLine,
+		Rules:     []Rule{Required(), MaxLength(songFieldMaxLength)},
+	}
+	SongText = FieldSpec{
+		Normalize: NormalizeText,
+		Rules:     []Rule{Required()},
+	}
+	SongLink = FieldSpec{
+		Normalize: NormalizeLine,
+		Rules:     []Rule{Required(), MaxLength(songFieldMaxLength), URL()},
+	}
+	SongReleaseDate = FieldSpec{
+		Normalize: NormalizeDate,
+		Rules:     []Rule{Required(), Date(earliestReleaseDate, latestReleaseDate)},
+	}
+)
+
+// Правила для фильтров списка песен: поля необязательны
+var (
+	FilterText = FieldSpec{
+		Normalize: NormalizeLine,
+		Rules:     []Rule{MaxLength(songFieldMaxLength)},
+	}
+	FilterDate = FieldSpec{
+		Normalize: NormalizeDate,
+		Rules:     []Rule{Date(earliestReleaseDate, latestReleaseDate)},
+	}
+)
+
+// LyricsQuery — слова для поиска по текстам песен
+var LyricsQuery = FieldSpec{
+	Normalize: NormalizeLine,
+	Rules:     []Rule{Required(), MaxLength(songFieldMaxLength)},
+}
@@ -0,0 +1,18 @@
+package validation
+
+import "regexp"
+
+// tenantSlugPattern — slug арендатора передаётся в заголовке X-Tenant-ID и claim токена
+var tenantSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,62}$`)
+
+// Правила для полей арендатора
+var (
+	TenantSlug = FieldSpec{
+		Normalize: NormalizeLine,
+		Rules:     []Rule{Required(), Pattern(tenantSlugPattern, "2-63 lowercase letters, digits or hyphens, starting with a letter or digit")},
+	}
+	TenantName = FieldSpec{
+		Normalize: NormalizeLine,
+		Rules:     []Rule{Required(), MaxLength(songFieldMaxLength)},
+	}
+)
@@ -0,0 +1,176 @@
+// Package validation provides declarative validation and normalization of request payloads
+package validation
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	catalog_errors "music_catalog/internal/errors"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Rule проверяет нормализованное значение и возвращает текст нарушения или пустую строку
+type Rule func(value string) string
+
+// FieldSpec — декларативное описание поля: нормализация и набор правил
+type FieldSpec struct {
+	Normalize func(string) string
+	Rules     []Rule
+}
+
+// Validator накапливает нарушения по всем проверенным полям
+type Validator struct {
+	errors []catalog_errors.FieldError
+}
+
+// New creates an empty Validator
+func New() *Validator {
+	return &Validator{}
+}
+
+// Check нормализует значение на месте и применяет к нему правила спецификации.
+// Для каждого поля фиксируется первое найденное нарушение.
+func (v *Validator) Check(field string, value *string, spec FieldSpec) {
+	if spec.Normalize != nil {
+		*value = spec.Normalize(*value)
+	}
+	for _, rule := range spec.Rules {
+		if message := rule(*value); message != "" {
+			v.errors = append(v.errors, catalog_errors.FieldError{Field: field, Message: message})
+			return
+		}
+	}
+}
+
+// CheckOptional работает как Check, но пропускает поля, которые не были переданы (nil)
+func (v *Validator) CheckOptional(field string, value *string, spec FieldSpec) {
+	if value == nil {
+		return
+	}
+	v.Check(field, value, spec)
+}
+
+// AddError добавляет нарушение, найденное вне декларативных правил
+func (v *Validator) AddError(field string, message string) {
+	v.errors = append(v.errors, catalog_errors.FieldError{Field: field, Message: message})
+}
+
+// Err returns a *catalog_errors.ValidationError with all violations, or nil if there are none
+func (v *Validator) Err() error {
+	if len(v.errors) == 0 {
+		return nil
+	}
+	return catalog_errors.NewValidationError(v.errors...)
+}
+
+// NormalizeLine приводит строку к NFC, обрезает края и схлопывает пробельные символы в один пробел
+func NormalizeLine(s string) string {
+	return strings.Join(strings.Fields(norm.NFC.String(s)), " ")
+}
+
+// NormalizeText приводит многострочный текст к NFC, унифицирует переводы строк
+// и убирает концевые пробелы, сохраняя пустые строки между куплетами
+func NormalizeText(s string) string {
+	s = norm.NFC.String(s)
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRightFunc(line, unicode.IsSpace)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// NormalizeDate нормализует строку и, если дата распознана, приводит её к формату YYYY-MM-DD
+func NormalizeDate(s string) string {
+	s = NormalizeLine(s)
+	if parsed, err := ParseDate(s); err == nil {
+		return parsed.Format("2006-01-02")
+	}
+	return s
+}
+
+// Required запрещает пустые значения
+func Required() Rule {
+	return func(value string) string {
+		if value == "" {
+			return "is required"
+		}
+		return ""
+	}
+}
+
+// MaxLength ограничивает длину значения в символах (как VARCHAR(n) в PostgreSQL)
+func MaxLength(n int) Rule {
+	return func(value string) string {
+		if utf8.RuneCountInString(value) > n {
+			return fmt.Sprintf("must be at most %d characters long", n)
+		}
+		return ""
+	}
+}
+
+// URL требует абсолютный http(s) адрес
+func URL() Rule {
+	return func(value string) string {
+		if value == "" {
+			return ""
+		}
+		u, err := url.ParseRequestURI(value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "must be a valid http or https URL"
+		}
+		return ""
+	}
+}
+
+// Pattern требует, чтобы значение целиком соответствовало регулярному выражению;
+// description описывает допустимый формат в тексте нарушения
+func Pattern(re *regexp.Regexp, description string) Rule {
+	return func(value string) string {
+		if value != "" && !re.MatchString(value) {
+			return "must be " + description
+		}
+		return ""
+	}
+}
+
+// Date требует дату в одном из поддерживаемых форматов в диапазоне [min, max]
+func Date(min time.Time, max func() time.Time) Rule {
+	return func(value string) string {
+		if value == "" {
+			return ""
+		}
+		parsed, err := ParseDate(value)
+		if err != nil {
+			return err.Error()
+		}
+		if parsed.Before(min) || parsed.After(max()) {
+			return fmt.Sprintf("must be between %s and %s", min.Format("2006-01-02"), max().Format("2006-01-02"))
+		}
+		return ""
+	}
+}
+
+// Список возможных форматов дат
+var dateFormats = []string{
+	"02.01.2006",      // Формат "DD.MM.YYYY"
+	"2006-01-02",      // Формат "YYYY-MM-DD"
+	"January 2, 2006", // Формат "January 2, 2006"
+	time.RFC3339,      // ISO 8601 формат (например, "2006-01-02T15:04:05Z07:00")
+}
+
+// ParseDate пытается разобрать дату в одном из поддерживаемых форматов
+func ParseDate(dateStr string) (time.Time, error) {
+	for _, layout := range dateFormats {
+		if parsedDate, err := time.Parse(layout, dateStr); err == nil {
+			return parsedDate, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date format %q, expected DD.MM.YYYY, YYYY-MM-DD, \"January 2, 2006\" or RFC 3339", dateStr)
+}
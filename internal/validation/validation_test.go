@@ -0,0 +1,135 @@
+package validation
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	catalog_errors "music_catalog/internal/errors"
+)
+
+func TestNormalizeLine(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"trims and collapses whitespace", "  Muse \t and\n\nfriends  ", "Muse and friends"},
+		{"decomposed to NFC", "Beyonce\u0301", "Beyoncé"},
+		{"already NFC", "Beyoncé", "Beyoncé"},
+		{"only whitespace", " \t\n ", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeLine(tt.input); got != tt.want {
+				t.Errorf("NormalizeLine(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeText(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"CRLF to LF", "line one\r\nline two", "line one\nline two"},
+		{"trailing spaces removed", "line one  \nline two\t", "line one\nline two"},
+		{"blank line between verses kept", "verse one\n\nverse two", "verse one\n\nverse two"},
+		{"leading indentation kept", "verse\n  indented", "verse\n  indented"},
+		{"outer blank lines trimmed", "\n\n verse \n\n", "verse"},
+		{"decomposed to NFC", "cafe\u0301", "café"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeText(tt.input); got != tt.want {
+				t.Errorf("NormalizeText(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaxLength(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		ok    bool
+	}{
+		{"empty", "", true},
+		{"ascii at limit", "abcde", true},
+		{"ascii over limit", "abcdef", false},
+		{"multibyte at limit", "приве", true},
+		{"multibyte over limit", "привет", false},
+	}
+	rule := MaxLength(5)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rule(tt.value); (got == "") != tt.ok {
+				t.Errorf("MaxLength(5)(%q) = %q, want ok=%v", tt.value, got, tt.ok)
+			}
+		})
+	}
+}
+
+func TestReleaseDateRange(t *testing.T) {
+	now := time.Now().UTC()
+	tests := []struct {
+		name  string
+		value string
+		ok    bool
+	}{
+		{"empty is left to Required", "", true},
+		{"earliest date", "1860-01-01", true},
+		{"before earliest date", "31.12.1859", false},
+		{"today", now.Format("2006-01-02"), true},
+		{"announced within a year", now.AddDate(0, 11, 0).Format("2006-01-02"), true},
+		{"more than a year ahead", now.AddDate(1, 0, 2).Format("2006-01-02"), false},
+		{"long format", "July 16, 2006", true},
+		{"unrecognized format", "2006/07/16", false},
+	}
+	rule := Date(earliestReleaseDate, latestReleaseDate)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rule(tt.value); (got == "") != tt.ok {
+				t.Errorf("Date()(%q) = %q, want ok=%v", tt.value, got, tt.ok)
+			}
+		})
+	}
+}
+
+func TestCheckOptional(t *testing.T) {
+	empty := ""
+	long := strings.Repeat("a", songFieldMaxLength+1)
+	padded := "  Muse  "
+	tests := []struct {
+		name      string
+		value     *string
+		spec      FieldSpec
+		wantValue string
+		wantErrs  []catalog_errors.FieldError
+	}{
+		{"nil is skipped", nil, SongTitle, "", nil},
+		{"empty required value", &empty, SongTitle, "", []catalog_errors.FieldError{{Field: "title", Message: "is required"}}},
+		{"too long", &long, SongTitle, long, []catalog_errors.FieldError{{Field: "title", Message: "must be at most 255 characters long"}}},
+		{"normalized in place", &padded, SongTitle, "Muse", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := New()
+			v.CheckOptional("title", tt.value, tt.spec)
+
+			var gotErrs []catalog_errors.FieldError
+			if err := v.Err(); err != nil {
+				gotErrs = err.(*catalog_errors.ValidationError).Errors
+			}
+			if !reflect.DeepEqual(gotErrs, tt.wantErrs) {
+				t.Errorf("errors = %v, want %v", gotErrs, tt.wantErrs)
+			}
+			if tt.value != nil && *tt.value != tt.wantValue {
+				t.Errorf("value = %q, want %q", *tt.value, tt.wantValue)
+			}
+		})
+	}
+}
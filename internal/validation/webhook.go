@@ -0,0 +1,10 @@
+package validation
+
+// webhookURLMaxLength — размер колонки webhooks.target_url
+const webhookURLMaxLength = 2048
+
+// WebhookTargetURL — правила для адреса получателя вебхука
+var WebhookTargetURL = FieldSpec{
+	Normalize: NormalizeLine,
+	Rules:     []Rule{Required(), MaxLength(webhookURLMaxLength), URL()},
+}
@@ -0,0 +1,199 @@
+// Package webhooks delivers catalog change events to subscribed partner systems
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"sync"
+	"time"
+
+	"music_catalog/internal/events"
+	"music_catalog/internal/logger"
+	"music_catalog/internal/models"
+)
+
+// Job — доставка, взятая диспетчером в работу
+type Job struct {
+	DeliveryID int64
+	Attempts   int // число уже выполненных попыток
+	WebhookID  int
+	TargetURL  string
+	Secret     string
+	Event      events.Event
+}
+
+// Repository — хранилище доставок, с которым работает диспетчер
+type Repository interface {
+	// EnqueueDeliveries раскладывает необработанные события журнала по активным подпискам
+	EnqueueDeliveries(ctx context.Context, batchSize int) (int, error)
+	// ClaimDueDeliveries берёт в работу доставки, время которых подошло, продлевая их на lease,
+	// чтобы другие экземпляры сервиса не взяли их одновременно
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]Job, error)
+	// RecordAttempt сохраняет результат попытки и новое состояние доставки
+	RecordAttempt(ctx context.Context, deliveryID int64, attempt models.WebhookDeliveryAttempt, status string, nextAttemptAt time.Time) error
+}
+
+// Config — параметры доставки
+type Config struct {
+	PollInterval   time.Duration // период опроса outbox и очереди доставок
+	BatchSize      int           // сколько событий/доставок обрабатывать за один опрос
+	Workers        int           // число параллельных HTTP-запросов
+	RequestTimeout time.Duration // таймаут одного запроса к получателю
+	MaxAttempts    int           // после стольких неудач доставка переходит в dead
+	BaseBackoff    time.Duration // задержка перед второй попыткой, далее удваивается
+	MaxBackoff     time.Duration // верхняя граница задержки
+	// Сети, доставка в которые разрешена, хотя адреса в них внутренние (см. checkTarget):
+	// например, 127.0.0.1 для локального получателя при тестировании
+	AllowedNetworks []netip.Prefix
+}
+
+// DefaultConfig — параметры доставки по умолчанию
+var DefaultConfig = Config{
+	PollInterval:   2 * time.Second,
+	BatchSize:      100,
+	Workers:        4,
+	RequestTimeout: 10 * time.Second,
+	MaxAttempts:    8,
+	BaseBackoff:    30 * time.Second,
+	MaxBackoff:     time.Hour,
+}
+
+// Dispatcher доставляет события подписчикам с подписью HMAC-SHA256, повторяя неудачные
+// попытки с экспоненциальной задержкой и переводя исчерпавшие попытки доставки в dead.
+// Во внутреннюю сеть сервиса доставки не отправляются (см. newHTTPClient).
+type Dispatcher struct {
+	repo   Repository
+	client *http.Client
+	config Config
+	logger logger.Logger
+}
+
+// NewDispatcher creates a new Dispatcher
+func NewDispatcher(repo Repository, config Config, logger logger.Logger) *Dispatcher {
+	return &Dispatcher{
+		repo:   repo,
+		client: newHTTPClient(config.RequestTimeout, config.AllowedNetworks),
+		config: config,
+		logger: logger,
+	}
+}
+
+// Run обрабатывает outbox и очередь доставок до отмены ctx
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.config.PollInterval)
+	defer ticker.Stop()
+	d.logger.Info("Webhook dispatcher started")
+
+	for {
+		d.tick(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (d *Dispatcher) tick(ctx context.Context) {
+	if _, err := d.repo.EnqueueDeliveries(ctx, d.config.BatchSize); err != nil {
+		d.logger.Error("Error enqueueing webhook deliveries:", err)
+	}
+
+	// Аренда покрывает все попытки пачки с запасом на случай медленных получателей
+	lease := d.config.RequestTimeout*time.Duration(d.config.BatchSize/d.config.Workers+1) + time.Minute
+	jobs, err := d.repo.ClaimDueDeliveries(ctx, d.config.BatchSize, lease)
+	if err != nil {
+		d.logger.Error("Error claiming webhook deliveries:", err)
+		return
+	}
+
+	queue := make(chan Job)
+	var wg sync.WaitGroup
+	for i := 0; i < d.config.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				d.deliver(ctx, job)
+			}
+		}()
+	}
+	for _, job := range jobs {
+		queue <- job
+	}
+	close(queue)
+	wg.Wait()
+}
+
+// deliver выполняет одну попытку доставки и сохраняет её результат
+func (d *Dispatcher) deliver(ctx context.Context, job Job) {
+	attempt := models.WebhookDeliveryAttempt{Attempt: job.Attempts + 1, AttemptedAt: time.Now()}
+	statusCode, err := d.send(ctx, job)
+	attempt.DurationMs = time.Since(attempt.AttemptedAt).Milliseconds()
+	attempt.StatusCode = statusCode
+
+	status, next := models.DeliveryDelivered, time.Now()
+	if err != nil {
+		attempt.Error = err.Error()
+		status, next = models.DeliveryPending, time.Now().Add(d.backoff(attempt.Attempt))
+		if attempt.Attempt >= d.config.MaxAttempts {
+			status = models.DeliveryDead
+			d.logger.Error("Webhook delivery dead-lettered:", job.DeliveryID, job.TargetURL, err)
+		} else {
+			d.logger.Info("Webhook delivery failed, will retry:", job.DeliveryID, job.TargetURL, err)
+		}
+	}
+
+	// Результат сохраняется даже при остановке сервиса, чтобы не повторять успешные доставки
+	if err := d.repo.RecordAttempt(context.WithoutCancel(ctx), job.DeliveryID, attempt, status, next); err != nil {
+		d.logger.Error("Error recording webhook delivery attempt:", job.DeliveryID, err)
+	}
+}
+
+// send отправляет событие получателю; любой ответ вне 2xx считается неудачей
+func (d *Dispatcher) send(ctx context.Context, job Job) (int, error) {
+	body, err := json.Marshal(job.Event)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.TargetURL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "music-catalog-webhooks/1.0")
+	req.Header.Set(EventHeader, job.Event.Type)
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(job.DeliveryID, 10))
+	req.Header.Set(SignatureHeader, Sign(job.Secret, body, time.Now()))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// backoff — задержка перед следующей попыткой: BaseBackoff * 2^(attempt-1), не больше MaxBackoff
+func (d *Dispatcher) backoff(attempt int) time.Duration {
+	delay := d.config.BaseBackoff
+	for i := 1; i < attempt && delay < d.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > d.config.MaxBackoff {
+		delay = d.config.MaxBackoff
+	}
+	return delay
+}
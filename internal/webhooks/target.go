@@ -0,0 +1,83 @@
+package webhooks
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrTargetNotAllowed — адрес получателя ведёт во внутреннюю сеть
+var ErrTargetNotAllowed = errors.New("webhook target address is not allowed")
+
+// blockedNetworks — сети, закрытые для доставки помимо loopback, частных, link-local и multicast адресов
+var blockedNetworks = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),     // «этот» хост
+	netip.MustParsePrefix("100.64.0.0/10"), // shared address space: CGNAT и metadata некоторых облаков
+}
+
+// ParseNetworks разбирает список сетей в нотации CIDR или отдельных адресов
+func ParseNetworks(values []string) ([]netip.Prefix, error) {
+	networks := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if prefix, err := netip.ParsePrefix(value); err == nil {
+			networks = append(networks, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network %q: expected CIDR or IP address", value)
+		}
+		networks = append(networks, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return networks, nil
+}
+
+// checkTarget запрещает доставку на loopback, частные, link-local (в том числе metadata облаков
+// 169.254.169.254), multicast и прочие внутренние адреса, кроме сетей allowed
+func checkTarget(addr netip.Addr, allowed []netip.Prefix) error {
+	addr = addr.Unmap()
+	for _, network := range allowed {
+		if network.Contains(addr) {
+			return nil
+		}
+	}
+	internal := addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() || addr.IsMulticast()
+	for _, network := range blockedNetworks {
+		internal = internal || network.Contains(addr)
+	}
+	if internal {
+		return fmt.Errorf("%w: %s", ErrTargetNotAllowed, addr)
+	}
+	return nil
+}
+
+// newHTTPClient создаёт клиент доставки. Адрес проверяется при каждом соединении, уже после разрешения
+// имени, поэтому запрет не обходится DNS-записью, сменившей адрес после создания подписки (DNS rebinding),
+// или перенаправлением. Прокси из окружения не используется: через него проверка бы не работала.
+func newHTTPClient(timeout time.Duration, allowed []netip.Prefix) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			addr, err := netip.ParseAddr(host)
+			if err != nil {
+				return err
+			}
+			return checkTarget(addr, allowed)
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
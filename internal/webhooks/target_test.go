@@ -0,0 +1,70 @@
+package webhooks
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestCheckTarget(t *testing.T) {
+	allowed, err := ParseNetworks([]string{"127.0.0.1", "10.20.0.0/16"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		addr    string
+		allowed []netip.Prefix
+		wantErr bool
+	}{
+		{"93.184.216.34", nil, false},
+		{"2606:2800:220:1::", nil, false},
+		{"127.0.0.1", nil, true},
+		{"::1", nil, true},
+		{"::ffff:127.0.0.1", nil, true},
+		{"10.1.2.3", nil, true},
+		{"172.16.0.1", nil, true},
+		{"192.168.1.1", nil, true},
+		{"169.254.169.254", nil, true},
+		{"fe80::1", nil, true},
+		{"fd00::1", nil, true},
+		{"100.100.100.200", nil, true},
+		{"0.0.0.0", nil, true},
+		{"224.0.0.1", nil, true},
+		{"127.0.0.1", allowed, false},
+		{"127.0.0.2", allowed, true},
+		{"10.20.5.6", allowed, false},
+		{"10.21.5.6", allowed, true},
+	}
+	for _, tt := range tests {
+		err := checkTarget(netip.MustParseAddr(tt.addr), tt.allowed)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("checkTarget(%s, %v) = %v, wantErr %v", tt.addr, tt.allowed, err, tt.wantErr)
+		}
+	}
+}
+
+func TestParseNetworksInvalid(t *testing.T) {
+	if _, err := ParseNetworks([]string{"localhost"}); err == nil {
+		t.Error("ParseNetworks accepted a host name")
+	}
+}
+
+func TestClientRefusesLoopback(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer receiver.Close()
+
+	_, err := newHTTPClient(time.Second, nil).Get(receiver.URL)
+	if !errors.Is(err, ErrTargetNotAllowed) {
+		t.Fatalf("delivery to %s: err = %v, want ErrTargetNotAllowed", receiver.URL, err)
+	}
+
+	allowed, _ := ParseNetworks([]string{"127.0.0.0/8", "::1"})
+	resp, err := newHTTPClient(time.Second, allowed).Get(receiver.URL)
+	if err != nil {
+		t.Fatalf("delivery to an allowed receiver: %v", err)
+	}
+	resp.Body.Close()
+}
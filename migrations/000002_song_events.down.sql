@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS song_events_notify ON song_events;
+DROP FUNCTION IF EXISTS notify_song_event();
+DROP TABLE IF EXISTS song_events;
@@ -0,0 +1,22 @@
+-- Журнал изменений каталога: источник для SSE/WebSocket и возобновления по Last-Event-ID
+CREATE TABLE IF NOT EXISTS song_events (
+    id BIGSERIAL PRIMARY KEY,
+    type VARCHAR(32) NOT NULL,
+    song_id INTEGER NOT NULL,
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_song_events_created_at ON song_events (created_at);
+
+-- Уведомляем все экземпляры сервиса о новом событии (доставляется после COMMIT)
+CREATE OR REPLACE FUNCTION notify_song_event() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('song_events', NEW.id::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER song_events_notify
+    AFTER INSERT ON song_events
+    FOR EACH ROW EXECUTE FUNCTION notify_song_event();
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_song_events_webhooks_pending;
+ALTER TABLE song_events DROP COLUMN IF EXISTS webhooks_enqueued_at;
+DROP TABLE IF EXISTS webhook_delivery_attempts;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhooks;
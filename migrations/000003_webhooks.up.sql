@@ -0,0 +1,44 @@
+-- Подписки внешних систем на события каталога
+CREATE TABLE IF NOT EXISTS webhooks (
+    id SERIAL PRIMARY KEY,
+    target_url VARCHAR(2048) NOT NULL,
+    event_types TEXT[] NOT NULL DEFAULT '{}', -- пустой массив — все события
+    secret VARCHAR(255) NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Доставки событий из журнала song_events (он же transactional outbox)
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    webhook_id INTEGER NOT NULL REFERENCES webhooks (id) ON DELETE CASCADE,
+    event_id BIGINT NOT NULL REFERENCES song_events (id) ON DELETE CASCADE,
+    status VARCHAR(16) NOT NULL DEFAULT 'pending', -- pending | delivered | dead
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    delivered_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW(),
+    UNIQUE (webhook_id, event_id)
+);
+
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_webhook_deliveries_webhook ON webhook_deliveries (webhook_id, id);
+
+-- Журнал попыток доставки
+CREATE TABLE IF NOT EXISTS webhook_delivery_attempts (
+    id BIGSERIAL PRIMARY KEY,
+    delivery_id BIGINT NOT NULL REFERENCES webhook_deliveries (id) ON DELETE CASCADE,
+    attempt INTEGER NOT NULL,
+    status_code INTEGER,
+    error TEXT,
+    duration_ms BIGINT NOT NULL,
+    attempted_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_webhook_delivery_attempts_delivery ON webhook_delivery_attempts (delivery_id);
+
+-- Отметка о том, что событие уже разложено по доставкам; строки без отметки — необработанная часть outbox
+ALTER TABLE song_events ADD COLUMN webhooks_enqueued_at TIMESTAMP;
+UPDATE song_events SET webhooks_enqueued_at = NOW();
+CREATE INDEX idx_song_events_webhooks_pending ON song_events (id) WHERE webhooks_enqueued_at IS NULL;
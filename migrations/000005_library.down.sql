@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS playlist_items;
+DROP TABLE IF EXISTS playlists;
+DROP TABLE IF EXISTS favorites;
+DROP TABLE IF EXISTS users;
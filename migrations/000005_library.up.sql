@@ -0,0 +1,45 @@
+-- Пользователи каталога; subject — идентификатор клиента из аутентификации (sub токена или key:<id>)
+CREATE TABLE IF NOT EXISTS users (
+    id SERIAL PRIMARY KEY,
+    subject VARCHAR(255) NOT NULL UNIQUE,
+    display_name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Избранные песни; удаление песни удаляет её из избранного
+CREATE TABLE IF NOT EXISTS favorites (
+    user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    song_id INTEGER NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT NOW(),
+    PRIMARY KEY (user_id, song_id)
+);
+
+CREATE INDEX idx_favorites_song ON favorites (song_id);
+
+-- Плейлисты; share_token выдаётся при первой публикации и открывает доступ без аутентификации
+CREATE TABLE IF NOT EXISTS playlists (
+    id SERIAL PRIMARY KEY,
+    owner_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    public BOOLEAN NOT NULL DEFAULT FALSE,
+    share_token VARCHAR(64) UNIQUE,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_playlists_owner ON playlists (owner_id, id);
+
+-- Треки плейлиста; position задаёт порядок. После каскадного удаления песни в нумерации остаются пропуски,
+-- поэтому порядковый номер трека вычисляется при чтении, а позиции уплотняются при следующем изменении.
+-- Уникальность позиции проверяется в конце транзакции, чтобы сдвигать треки одним UPDATE.
+CREATE TABLE IF NOT EXISTS playlist_items (
+    id BIGSERIAL PRIMARY KEY,
+    playlist_id INTEGER NOT NULL REFERENCES playlists (id) ON DELETE CASCADE,
+    song_id INTEGER NOT NULL REFERENCES songs (id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    added_at TIMESTAMP DEFAULT NOW(),
+    CONSTRAINT playlist_items_position_key UNIQUE (playlist_id, position) DEFERRABLE INITIALLY DEFERRED
+);
+
+CREATE INDEX idx_playlist_items_song ON playlist_items (song_id);
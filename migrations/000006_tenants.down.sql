@@ -0,0 +1,20 @@
+DROP POLICY IF EXISTS songs_tenant_isolation ON songs;
+ALTER TABLE songs NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE songs DISABLE ROW LEVEL SECURITY;
+
+ALTER TABLE api_keys DROP COLUMN IF EXISTS tenant_id;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_tenant_subject_key;
+ALTER TABLE users DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE users ADD CONSTRAINT users_subject_key UNIQUE (subject);
+
+DROP INDEX IF EXISTS idx_webhooks_tenant;
+ALTER TABLE webhooks DROP COLUMN IF EXISTS tenant_id;
+
+ALTER TABLE song_events DROP COLUMN IF EXISTS tenant_id;
+
+DROP INDEX IF EXISTS idx_songs_tenant_group_title;
+ALTER TABLE songs DROP COLUMN IF EXISTS tenant_id;
+CREATE UNIQUE INDEX idx_group_title ON songs (group_name, title);
+
+DROP TABLE IF EXISTS tenants;
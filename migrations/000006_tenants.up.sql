@@ -0,0 +1,43 @@
+-- Арендаторы (лейблы), каталоги которых обслуживаются одним развёртыванием
+CREATE TABLE IF NOT EXISTS tenants (
+    id SERIAL PRIMARY KEY,
+    slug VARCHAR(63) NOT NULL UNIQUE,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Существующие данные переходят к арендатору по умолчанию
+INSERT INTO tenants (id, slug, name) VALUES (1, 'default', 'Default');
+SELECT setval(pg_get_serial_sequence('tenants', 'id'), 1);
+
+-- Песни: уникальность группы и названия — в пределах арендатора
+ALTER TABLE songs ADD COLUMN tenant_id INTEGER NOT NULL DEFAULT 1 REFERENCES tenants (id);
+ALTER TABLE songs ALTER COLUMN tenant_id DROP DEFAULT;
+DROP INDEX IF EXISTS idx_group_title;
+CREATE UNIQUE INDEX idx_songs_tenant_group_title ON songs (tenant_id, group_name, title);
+
+-- Журнал событий: подписчики и вебхуки получают события только своего арендатора
+ALTER TABLE song_events ADD COLUMN tenant_id INTEGER NOT NULL DEFAULT 1 REFERENCES tenants (id);
+ALTER TABLE song_events ALTER COLUMN tenant_id DROP DEFAULT;
+
+ALTER TABLE webhooks ADD COLUMN tenant_id INTEGER NOT NULL DEFAULT 1 REFERENCES tenants (id);
+ALTER TABLE webhooks ALTER COLUMN tenant_id DROP DEFAULT;
+CREATE INDEX idx_webhooks_tenant ON webhooks (tenant_id, id);
+
+-- Пользователи заводятся отдельно в каждом арендаторе; избранное и плейлисты принадлежат им
+ALTER TABLE users ADD COLUMN tenant_id INTEGER NOT NULL DEFAULT 1 REFERENCES tenants (id);
+ALTER TABLE users ALTER COLUMN tenant_id DROP DEFAULT;
+ALTER TABLE users DROP CONSTRAINT users_subject_key;
+ALTER TABLE users ADD CONSTRAINT users_tenant_subject_key UNIQUE (tenant_id, subject);
+
+-- Ключ без арендатора — ключ уровня платформы (выбирает арендатора заголовком X-Tenant-ID)
+ALTER TABLE api_keys ADD COLUMN tenant_id INTEGER REFERENCES tenants (id);
+
+-- Row-level security: запросы к songs видят и изменяют только строки арендатора из app.tenant_id,
+-- который приложение устанавливает в начале каждой транзакции. Без установленного арендатора
+-- не видна ни одна строка. Суперпользователи и роли с BYPASSRLS политики не применяют.
+ALTER TABLE songs ENABLE ROW LEVEL SECURITY;
+ALTER TABLE songs FORCE ROW LEVEL SECURITY;
+CREATE POLICY songs_tenant_isolation ON songs
+    USING (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::INTEGER)
+    WITH CHECK (tenant_id = NULLIF(current_setting('app.tenant_id', true), '')::INTEGER);
@@ -0,0 +1,10 @@
+-- Корзины ограничения частоты запросов, общие для всех экземпляров сервиса.
+-- UNLOGGED: после сбоя PostgreSQL корзины можно потерять — клиенты просто получат полную квоту.
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limit_buckets (
+    key VARCHAR(255) PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    allowed BOOLEAN NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_rate_limit_buckets_updated_at ON rate_limit_buckets (updated_at);
@@ -0,0 +1 @@
+DROP INDEX CONCURRENTLY IF EXISTS idx_songs_text_search;
@@ -0,0 +1,9 @@
+-- Полнотекстовый поиск по текстам песен (GET /api/v2/songs/search). Конфигурация simple не выполняет
+-- стемминг и не знает стоп-слов, поэтому тексты на любом языке ищутся по словам так же, как FTS5
+-- в SQLite (migrations/sqlite/000001): без учёта регистра, слово запроса совпадает только с целым словом.
+-- Индекс по выражению строится CONCURRENTLY и не блокирует запись в songs. CONCURRENTLY нельзя выполнить
+-- в транзакции, поэтому в файле одна команда: golang-migrate отправляет его одним запросом без BEGIN.
+-- Если построение прервалось, индекс остаётся INVALID: удалите его (DROP INDEX CONCURRENTLY) и примените
+-- миграцию заново после catalogctl migrate force 8.
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_songs_text_search
+    ON songs USING GIN (to_tsvector('simple', COALESCE(text, '')));
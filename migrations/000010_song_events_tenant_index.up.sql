@@ -0,0 +1,4 @@
+-- Поток событий читается по арендатору (Last-Event-ID и курсоры events.Broker): индекс по (tenant_id, id).
+-- Строится CONCURRENTLY, чтобы не блокировать запись событий, а с ней и изменения каталога; CONCURRENTLY нельзя
+-- выполнить в транзакции, поэтому в файле одна команда (см. 000009).
+CREATE INDEX CONCURRENTLY IF NOT EXISTS idx_song_events_tenant ON song_events (tenant_id, id);
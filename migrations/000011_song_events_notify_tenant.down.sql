@@ -0,0 +1,6 @@
+CREATE OR REPLACE FUNCTION notify_song_event() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('song_events', NEW.id::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
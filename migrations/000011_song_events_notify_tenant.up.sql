@@ -0,0 +1,7 @@
+-- Уведомление несёт ID арендатора: экземпляр сервиса перечитывает только поток этого арендатора
+CREATE OR REPLACE FUNCTION notify_song_event() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('song_events', NEW.tenant_id::text);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,20 @@
+// Package migrations встраивает SQL-миграции схемы в бинарный файл, чтобы сервис и catalogctl
+// не зависели от рабочего каталога
+package migrations
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// FS — миграции PostgreSQL в формате golang-migrate: NNNNNN_name.up.sql и NNNNNN_name.down.sql
+//
+//go:embed *.sql
+var FS embed.FS
+
+//go:embed sqlite/*.sql
+var sqliteFS embed.FS
+
+// SQLite — миграции базы SQLite (STORAGE_BACKEND=sqlite) в том же формате: каталог, арендаторы,
+// API-ключи и журнал событий; вебхуки, избранное и плейлисты есть только в PostgreSQL
+var SQLite, _ = fs.Sub(sqliteFS, "sqlite")
@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS songs_fts_update;
+DROP TRIGGER IF EXISTS songs_fts_delete;
+DROP TRIGGER IF EXISTS songs_fts_insert;
+DROP TABLE IF EXISTS songs_fts;
+DROP TABLE IF EXISTS songs;
@@ -0,0 +1,43 @@
+-- Схема каталога песен для STORAGE_BACKEND=sqlite: таблица songs из миграций PostgreSQL 000001
+-- с колонкой tenant_id из 000006. Дата выпуска хранится строкой YYYY-MM-DD.
+CREATE TABLE IF NOT EXISTS songs (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    tenant_id INTEGER NOT NULL,
+    group_name TEXT NOT NULL,
+    title TEXT NOT NULL,
+    text TEXT,
+    link TEXT,
+    release_date TEXT,
+    created_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Уникальный индекс для предотвращения дублирования песен в каталоге арендатора
+CREATE UNIQUE INDEX idx_songs_tenant_group_title ON songs (tenant_id, group_name, title);
+
+-- Индексы для ускорения запросов
+CREATE INDEX idx_group_name ON songs (tenant_id, group_name);
+CREATE INDEX idx_title ON songs (tenant_id, title);
+CREATE INDEX idx_release_date ON songs (tenant_id, release_date);
+
+-- Полнотекстовый индекс текстов песен. Таблица хранит только индекс (external content),
+-- сами тексты читаются из songs; триггеры поддерживают индекс при изменении песен.
+CREATE VIRTUAL TABLE songs_fts USING fts5 (
+    text,
+    content = 'songs',
+    content_rowid = 'id',
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+CREATE TRIGGER songs_fts_insert AFTER INSERT ON songs BEGIN
+    INSERT INTO songs_fts (rowid, text) VALUES (new.id, new.text);
+END;
+
+CREATE TRIGGER songs_fts_delete AFTER DELETE ON songs BEGIN
+    INSERT INTO songs_fts (songs_fts, rowid, text) VALUES ('delete', old.id, old.text);
+END;
+
+CREATE TRIGGER songs_fts_update AFTER UPDATE OF text ON songs BEGIN
+    INSERT INTO songs_fts (songs_fts, rowid, text) VALUES ('delete', old.id, old.text);
+    INSERT INTO songs_fts (rowid, text) VALUES (new.id, new.text);
+END;
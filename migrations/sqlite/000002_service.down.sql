@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS song_events;
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS tenants;
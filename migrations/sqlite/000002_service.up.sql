@@ -0,0 +1,39 @@
+-- Служебные таблицы сервиса для STORAGE_BACKEND=sqlite, чтобы ему не требовался PostgreSQL:
+-- арендаторы (000006), API-ключи (000004) и журнал событий (000002) из миграций PostgreSQL.
+-- Время хранится строкой в UTC (CURRENT_TIMESTAMP).
+CREATE TABLE IF NOT EXISTS tenants (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    slug TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Песни, добавленные до этой миграции, принадлежат арендатору по умолчанию
+INSERT INTO tenants (id, slug, name) VALUES (1, 'default', 'Default');
+
+-- Ключи доступа к API; сам ключ не хранится, только его SHA-256.
+-- Ключ без арендатора — ключ уровня платформы (выбирает арендатора заголовком X-Tenant-ID)
+CREATE TABLE IF NOT EXISTS api_keys (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    role TEXT NOT NULL CHECK (role IN ('viewer', 'editor', 'admin')),
+    prefix TEXT NOT NULL,
+    key_hash TEXT NOT NULL UNIQUE,
+    tenant_id INTEGER REFERENCES tenants (id),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at TIMESTAMP,
+    revoked_at TIMESTAMP
+);
+
+-- Журнал изменений каталога: источник для SSE/WebSocket и возобновления по Last-Event-ID.
+-- Пишущие транзакции SQLite выполняются по одной, поэтому ID событий идут в порядке фиксации
+CREATE TABLE IF NOT EXISTS song_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    tenant_id INTEGER NOT NULL REFERENCES tenants (id),
+    type TEXT NOT NULL,
+    song_id INTEGER NOT NULL,
+    payload TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_song_events_created_at ON song_events (created_at);
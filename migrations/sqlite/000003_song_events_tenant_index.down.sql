@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_song_events_tenant;
@@ -0,0 +1,2 @@
+-- Поток событий читается по арендатору (Last-Event-ID и курсоры events.Broker)
+CREATE INDEX IF NOT EXISTS idx_song_events_tenant ON song_events (tenant_id, id);